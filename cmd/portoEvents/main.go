@@ -1,36 +1,115 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"github.com/BurntSushi/toml"
 	"github.com/oleksiy-os/porto-events/configs"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/event"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
 	"github.com/oleksiy-os/porto-events/internal/store"
 	"github.com/oleksiy-os/porto-events/internal/store/boltdb"
 	"github.com/oleksiy-os/porto-events/internal/web"
 	log "github.com/sirupsen/logrus"
+	"os"
 	"strconv"
+	"time"
+)
+
+var (
+	// gazetteerPath CSV file of the gazetteer to import into the store, see model.ReadGazetteerCsv
+	gazetteerPath string
+
+	// collectOnly collect events into the store and exit, for scheduled collections by cron
+	collectOnly bool
 )
 
 func main() {
 	config := configInit()
 
+	sources, err := event.LoadSources(config.SourcesListPath)
+	if err != nil {
+		log.Fatal("sources list config| ", err)
+	}
+
+	if err := fetcher.SetCache(config.HttpCache); err != nil {
+		log.Fatal("http cache| ", err)
+	}
+
 	var s store.StoreInterface = boltdb.New()
 
+	if gazetteerPath != "" {
+		importGazetteer(s, gazetteerPath)
+		return
+	}
+
+	if collectOnly {
+		collect(s, sources, config)
+		return
+	}
+
 	srv := web.New(config, &s)
 	srv.ListenAndServe()
 }
 
+// collect events from the sources into the store as a scheduled run, ended events are archived
+func collect(s store.StoreInterface, sources []model.Source, config *configs.Config) {
+	run := event.CollectAndStore(context.Background(), s, sources, model.TriggerScheduled)
+	event.NewArchiver(config.Archive).RunDue(s, time.Now())
+
+	log.Infoln("collected|", len(run.Added), "added,", len(run.Updated), "updated,", len(run.Errors), "errors")
+}
+
+// importGazetteer from the CSV file instead of the stored one
+func importGazetteer(s store.StoreInterface, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal("gazetteer| ", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Error("close gazetteer|", err)
+		}
+	}()
+
+	entries, err := model.ReadGazetteerCsv(f)
+	if err != nil {
+		log.Fatal("gazetteer| ", err)
+	}
+	if !s.Gazetteer().Import(entries) {
+		log.Fatal("gazetteer not imported")
+	}
+
+	log.Infoln("gazetteer imported|", len(entries), path)
+}
+
 func configInit() *configs.Config {
 	var (
 		config     *configs.Config
 		configPath string
 		logLevel   string
+		recordDir  string
+		replayDir  string
 	)
 
 	flag.StringVar(&configPath, "config-path", "configs/config.toml", "path to config file")
 	flag.StringVar(&logLevel, "log-level", "", "log level, int:0-6 (panic=0, fatal=1, error=2, warn=3, info=4, debug=5, trace=6)")
+	flag.StringVar(&recordDir, "record", "", "record sources http exchanges to cassettes in the dir")
+	flag.StringVar(&replayDir, "replay", "", "offline mode, replay sources http exchanges from cassettes in the dir")
+	flag.StringVar(&gazetteerPath, "import-gazetteer", "", "import gazetteer CSV for offline geocoding of addresses and exit")
+	flag.BoolVar(&collectOnly, "collect", false, "collect events from sources into the store and exit, for scheduled runs by cron")
 	flag.Parse()
 
+	switch {
+	case recordDir != "" && replayDir != "":
+		log.Fatal("-record and -replay can't be used together")
+	case recordDir != "":
+		fetcher.SetCassettes(fetcher.ModeRecord, recordDir)
+	case replayDir != "":
+		fetcher.SetCassettes(fetcher.ModeReplay, replayDir)
+	}
+
 	_, err := toml.DecodeFile(configPath, &config)
 	if err != nil {
 		log.Fatal(err)
@@ -1,13 +1,16 @@
 package configs
 
 import (
+	"github.com/oleksiy-os/porto-events/internal/model"
 	telegramApi "github.com/oleksiy-os/porto-events/internal/model/client/telegram"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
 	"github.com/oleksiy-os/porto-events/internal/store/notion"
 )
 
 type (
 	Server struct {
-		BindAddr string `toml:"bind_addr"`
+		BindAddr string     `toml:"bind_addr"`
+		Lang     model.Lang `toml:"lang"` // language of the web UI dates: "en" or "pt"
 	}
 
 	Config struct {
@@ -17,5 +20,7 @@ type (
 		Telegram        telegramApi.Telegram
 		Notion          notion.Notion
 		Server          Server
+		HttpCache       fetcher.CacheConfig `toml:"http_cache"`
+		Archive         model.ArchiveConfig `toml:"archive"`
 	}
 )
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type (
+	// ArchiveConfig lifecycle of ended events, [archive] in config.toml
+	ArchiveConfig struct {
+		Interval  uint `toml:"interval"`  // hours between archiving of ended events, default 6
+		Retention uint `toml:"retention"` // days archived events are kept, 0 - forever
+	}
+
+	// ArchivedEvent ended event out of the working lists, kept for statistics and search until the retention period
+	ArchivedEvent struct {
+		Event      Event     `json:"event"`
+		ArchivedAt time.Time `json:"archivedAt"`
+	}
+)
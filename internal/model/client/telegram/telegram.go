@@ -6,6 +6,7 @@ import (
 	"github.com/oleksiy-os/porto-events/internal/model"
 	log "github.com/sirupsen/logrus"
 	"strings"
+	"time"
 )
 
 type (
@@ -15,16 +16,20 @@ type (
 	}
 
 	Telegram struct {
-		ApiToken    string `toml:"bot_api_token"`
-		ChannelId   string `toml:"channel_id"`
-		ChannelName string `toml:"channel_name"`
+		ApiToken    string     `toml:"bot_api_token"`
+		ChannelId   string     `toml:"channel_id"`
+		ChannelName string     `toml:"channel_name"`
+		Lang        model.Lang `toml:"lang"` // language of posts dates: "en" or "pt"
 	}
 )
 
 func (t *Bot) Publish(e *model.Event) error {
+	post := *e
+	post.DateText, post.Time = model.FormatDates(*e, t.config.Lang, time.Now())
+
 	msg := `<b><a href="%s">%s</a></b> &#10;%s &#10;📍 <a href="%s">%s</a> &#10;🗓 %s &#10;🕒 %s &#10;%s`
-	e.Description = truncateString(e, &msg)
-	msg = fmt.Sprintf(msg, e.Url, e.Title, e.Description, e.LocationMap, e.Place, e.DateText, e.Time, e.Days)
+	post.Description = truncateString(&post, &msg)
+	msg = fmt.Sprintf(msg, post.Url, post.Title, post.Description, post.LocationMap, post.Place, post.DateText, post.Time, post.Days)
 
 	photo := tgbot.FileURL(e.Image)
 	cnf := tgbot.NewPhotoToChannel(t.config.ChannelId, photo)
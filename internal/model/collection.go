@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type (
+	// CollectionRun one collection of events from all sources, audit log of the events storage
+	CollectionRun struct {
+		ID       string      `json:"id"`
+		Started  time.Time   `json:"started"`
+		Finished time.Time   `json:"finished"`
+		Trigger  string      `json:"trigger"` // TriggerManual or TriggerScheduled
+		Sources  []SourceRun `json:"sources"`
+		Added    []string    `json:"added"`             // ids of new events
+		Skipped  []string    `json:"skipped"`           // ids of already stored events without changes, ended new events
+		Merged   []string    `json:"merged,omitempty"`  // ids of new events merged into their duplicates
+		Updated  []string    `json:"updated,omitempty"` // ids of stored events changed in their sources
+		Errors   []string    `json:"errors,omitempty"`
+	}
+)
+
+const (
+	TriggerManual    = "manual"    // "Get events" in web UI
+	TriggerScheduled = "scheduled" // -collect flag, run by cron
+
+	// collectionRunIdLayout sortable by time id of the run
+	collectionRunIdLayout = "20060102T150405.000000000Z"
+)
+
+// NewCollectionRun run started now
+func NewCollectionRun(trigger string) CollectionRun {
+	started := time.Now()
+
+	return CollectionRun{
+		ID:      started.UTC().Format(collectionRunIdLayout),
+		Started: started,
+		Trigger: trigger,
+	}
+}
@@ -0,0 +1,655 @@
+// Package dateparse dates and times of events from texts of sources, in portuguese and english:
+// "Sábado, 6 de Janeiro de 2024 às 21h30", "3 a 5 de Março", "Jan 02nd, 2023 9pm", "14/03/2030 21:00 - 23:30"
+package dateparse
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrNoDate the text has no day of the event
+var ErrNoDate = errors.New("no date")
+
+type (
+	// Dates of the event parsed from the text, in the location of the reference time
+	Dates struct {
+		Start  time.Time   // the first day with the start time, midnight for AllDay
+		End    time.Time   // the last day with the end time, zero if unknown. Exclusive midnight after the last day for AllDay
+		AllDay bool        // the text has no time
+		Days   []time.Time // days of the text, midnight: the listed days "6, 7 e 8 de Janeiro", the first and the last day of a range
+		Range  bool        // the event is on every day from the first to the last day: "3 a 5 de Março", "Janeiro 2024"
+	}
+
+	// WeekdayHours hours of the weekday of a schedule in minutes after midnight,
+	// End is equal to Start if the end is unknown, both are zero without hours
+	WeekdayHours struct {
+		Weekday    time.Weekday
+		Start, End int
+	}
+
+	atomKind int
+
+	// atom of the text meaningful for dates
+	atom struct {
+		kind  atomKind
+		value int // day, month, year, weekday, minutes after midnight for time, days after the reference day for relative
+	}
+
+	// date of the text, zero fields are not in the text
+	date struct {
+		day, month, year int
+		explicitYear     bool
+	}
+)
+
+const (
+	atomDay atomKind = iota
+	atomMonth
+	atomYear
+	atomWeekday
+	atomTime
+	atomRelative
+	atomRange // "-", "a", "até", "às", "to"
+)
+
+// rangeUntil value of the range atom of "até", "until": the first day is the reference day if the text starts with it
+const rangeUntil = 1
+
+// pastGraceDays days before the reference day still resolved to the current year for dates without year,
+// the started events of the sources, older dates are of the next year
+const pastGraceDays = 7
+
+// Parse dates of the event from the text. Missing month and year are taken from other days of the text,
+// or are the next ones from the reference time ref, sources list upcoming events: "12 Jan" in December is January
+// of the next year, "3 de setembro" in January is of the same year. Days of the last week are still of the current
+// year, see pastGraceDays. The text starting with "até" is a range from the reference day: "Até 31 de Dezembro".
+//
+// Weekdays are ignored if the text has days, the only weekday without days is the next such day from ref: "Qua, 21h30".
+// Numbered portuguese weekdays are weekdays, not days: "5ª feira, 22 Out", "2ª a 6ª".
+// Two times separated by "-", "a", "até" or "às" are the start and the end time, otherwise the end time is unknown.
+//
+// Returns ErrNoDate if the text has no days
+func Parse(text string, ref time.Time) (Dates, error) {
+	atoms, err := scan(text)
+	if err != nil {
+		return Dates{}, err
+	}
+
+	dates, isRange, weekdayList := group(atoms, ref)
+	if len(dates) > 0 && startsUntil(atoms) { // "Até 31 de Dezembro", from the reference day
+		today := midnight(ref, ref.Location())
+		dates = append([]date{{day: today.Day(), month: int(today.Month()), year: today.Year(), explicitYear: true}}, dates...)
+		isRange = true
+	}
+	if len(dates) == 0 {
+		if len(weekdayList) != 1 {
+			return Dates{}, ErrNoDate
+		}
+		day := midnight(ref, ref.Location())
+		for day.Weekday() != weekdayList[0] {
+			day = day.AddDate(0, 0, 1)
+		}
+		dates = []date{{day: day.Day(), month: int(day.Month()), year: day.Year(), explicitYear: true}}
+	}
+
+	days, err := resolve(dates, ref)
+	if err != nil {
+		return Dates{}, err
+	}
+
+	first, last := days[0], days[len(days)-1]
+	if dates[0].day == 0 || dates[len(dates)-1].day == 0 { // whole months: "Março a Abril", "Janeiro 2024"
+		isRange = true
+		if len(days) == 1 {
+			days = append(days, first)
+		}
+		if dates[len(dates)-1].day == 0 {
+			last = last.AddDate(0, 1, -1)
+			days[len(days)-1] = last
+		}
+	}
+
+	res := Dates{Days: days, Range: isRange}
+
+	start, end, hasStart, hasEnd := times(atoms)
+	if !hasStart {
+		res.Start, res.End, res.AllDay = first, last.AddDate(0, 0, 1), true
+		return res, nil
+	}
+
+	res.Start = at(first, start)
+	switch {
+	case hasEnd && end <= start: // ends after midnight
+		res.End = at(last.AddDate(0, 0, 1), end)
+	case hasEnd:
+		res.End = at(last, end)
+	case len(days) > 1:
+		res.End = at(last, start) // the start of the last day, the end is unknown
+	}
+
+	return res, nil
+}
+
+// Schedule weekdays with their hours of the text: "3ª a 6ª, 10h–18h; sáb e dom, 14h–18h", "Qua a Dom, 10h - 18h".
+// Weekdays go before their hours, a range of weekdays is every weekday from the first to the last one.
+// Weekdays of dates are skipped: "Sábado, 6 de Janeiro". Empty if the text has no schedule
+func Schedule(text string) ([]WeekdayHours, error) {
+	atoms, err := scan(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		list  []WeekdayHours
+		group []atom // weekdays with their hours
+	)
+	hasTime := func() bool {
+		return slices.ContainsFunc(group, func(a atom) bool { return a.kind == atomTime })
+	}
+	for _, a := range atoms {
+		if a.kind == atomWeekday && hasTime() {
+			list = append(list, scheduleGroup(group)...)
+			group = nil
+		}
+		group = append(group, a)
+	}
+
+	return append(list, scheduleGroup(group)...), nil
+}
+
+// scheduleGroup hours of the weekdays of atoms, nil for a date
+func scheduleGroup(atoms []atom) []WeekdayHours {
+	var days []time.Weekday
+	for i, a := range atoms {
+		switch a.kind {
+		case atomDay, atomMonth, atomYear, atomRelative:
+			return nil
+		case atomWeekday:
+			wd := time.Weekday(a.value)
+			if i >= 2 && atoms[i-1].kind == atomRange && atoms[i-2].kind == atomWeekday { // "Qua a Dom"
+				for d := (time.Weekday(atoms[i-2].value) + 1) % 7; d != wd; d = (d + 1) % 7 {
+					days = append(days, d)
+				}
+			}
+			days = append(days, wd)
+		}
+	}
+
+	start, end, _, hasEnd := times(atoms)
+	if !hasEnd {
+		end = start // zero without hours
+	}
+
+	list := make([]WeekdayHours, 0, len(days))
+	for _, wd := range days {
+		list = append(list, WeekdayHours{Weekday: wd, Start: start, End: end})
+	}
+
+	return list
+}
+
+// scan atoms of the text, other words are skipped
+func scan(text string) ([]atom, error) {
+	r := []rune(normalize(text))
+
+	var atoms []atom
+	for i := 0; i < len(r); {
+		switch {
+		case unicode.IsDigit(r[i]):
+			if a, n, ok := numberedWeekday(r[i:], atoms); ok {
+				atoms = append(atoms, a)
+				i += n
+				continue
+			}
+			a, n, err := scanNumber(r[i:])
+			if err != nil {
+				return nil, err
+			}
+			atoms = append(atoms, a...)
+			i += n
+		case unicode.IsLetter(r[i]):
+			n := letters(r[i:])
+			word := string(r[i : i+n])
+			i += n
+			if strings.HasPrefix(string(r[i:]), "-feira") {
+				i += len("-feira")
+			}
+			if a, ok := wordAtom(word); ok {
+				atoms = append(atoms, a)
+			}
+		case r[i] == '-':
+			atoms = append(atoms, atom{kind: atomRange})
+			i++
+		default:
+			i++
+		}
+	}
+
+	return atoms, nil
+}
+
+func wordAtom(word string) (atom, bool) {
+	if m, ok := months[word]; ok {
+		return atom{kind: atomMonth, value: int(m)}, true
+	}
+	if wd, ok := weekdays[word]; ok {
+		return atom{kind: atomWeekday, value: int(wd)}, true
+	}
+	if rangeWords[word] {
+		if untilWords[word] {
+			return atom{kind: atomRange, value: rangeUntil}, true
+		}
+		return atom{kind: atomRange}, true
+	}
+	if days, ok := relativeDays[word]; ok {
+		return atom{kind: atomRelative, value: days}, true
+	}
+
+	return atom{}, false
+}
+
+// scanNumber atoms of the number at the start of r and count of scanned runes:
+// "2024-01-06", "06/01/2024", "21:30", "21h30", "9pm", "1st", "2024", "6"
+func scanNumber(r []rune) ([]atom, int, error) {
+	n := digits(r)
+	v, _ := strconv.Atoi(string(r[:n]))
+	rest := r[n:]
+
+	// "2024-01-06"
+	if n == 4 && len(rest) >= 6 && rest[0] == '-' && digits(rest[1:]) == 2 && rest[3] == '-' && digits(rest[4:]) == 2 {
+		month, _ := strconv.Atoi(string(rest[1:3]))
+		day, _ := strconv.Atoi(string(rest[4:6]))
+		return []atom{{atomDay, day}, {atomMonth, month}, {atomYear, v}}, n + 6, nil
+	}
+
+	if n > 2 {
+		if n == 4 {
+			return []atom{{atomYear, v}}, n, nil
+		}
+		return nil, n, nil // not a date
+	}
+
+	switch {
+	// "21:30", "21:30:00", "9:30 pm"
+	case len(rest) >= 3 && rest[0] == ':' && digits(rest[1:]) == 2:
+		minutes, _ := strconv.Atoi(string(rest[1:3]))
+		used := n + 3
+		if len(rest) >= 6 && rest[3] == ':' && digits(rest[4:]) == 2 {
+			used += 3 // seconds
+		}
+		return timeAtom(v, minutes, r[used:], used)
+
+	// "21h", "21h30", "21horas"
+	case len(rest) >= 1 && rest[0] == 'h' && (len(rest) == 1 || !unicode.IsLetter(rest[1]) || timeWords[string(rest[1:1+letters(rest[1:])])]):
+		used := n + 1
+		minutes := 0
+		if m := digits(rest[1:]); m == 2 {
+			minutes, _ = strconv.Atoi(string(rest[1:3]))
+			used += 2
+		}
+		if w := letters(r[used:]); timeWords[string(r[used:used+w])] {
+			used += w
+		}
+		return timeAtom(v, minutes, nil, used)
+
+	// "06/01/2024", "6.1.24", "06/01", and "21.30" time
+	case len(rest) >= 2 && (rest[0] == '/' || rest[0] == '.') && digits(rest[1:]) > 0 && digits(rest[1:]) <= 2:
+		m := digits(rest[1:])
+		month, _ := strconv.Atoi(string(rest[1 : 1+m]))
+		used := n + 1 + m
+		if rest[0] == '.' && m == 2 && (len(r) == used || r[used] != '.') && (month > 12 || (len(r) > used && r[used] == 'h')) {
+			if len(r) > used && r[used] == 'h' {
+				used++
+			}
+			return timeAtom(v, month, r[used:], used)
+		}
+		atoms := []atom{{atomDay, v}, {atomMonth, month}}
+		if len(r) > used+1 && r[used] == rest[0] {
+			if y := digits(r[used+1:]); y == 2 || y == 4 {
+				year, _ := strconv.Atoi(string(r[used+1 : used+1+y]))
+				if y == 2 {
+					year += 2000
+				}
+				atoms = append(atoms, atom{atomYear, year})
+				used += 1 + y
+			}
+		}
+		return atoms, used, nil
+	}
+
+	// "21 horas"
+	if s := spaces(rest); s > 0 {
+		if w := letters(rest[s:]); hoursWords[string(rest[s:s+w])] {
+			return timeAtom(v, 0, nil, n+s+w)
+		}
+	}
+
+	// "9pm", "9 am"
+	if s := spaces(rest); len(rest) > s && (rest[s] == 'a' || rest[s] == 'p') {
+		if a, used, err := timeAtom(v, 0, rest, n); used > n {
+			return a, used, err
+		}
+	}
+
+	used := n
+	if w := letters(rest); ordinals[string(rest[:w])] {
+		used += w // "1st", "2nd", "1º"
+	}
+	if v < 1 || v > 31 {
+		return nil, used, nil // not a day
+	}
+
+	return []atom{{atomDay, v}}, used, nil
+}
+
+// numberedWeekday portuguese weekday by number at the start of r and count of scanned runes: "5ª feira", "2ª-feira",
+// "2ª a 6ª". The number without "feira" is a weekday only in a range of such weekdays, otherwise it's a day: "1º Nov"
+func numberedWeekday(r []rune, atoms []atom) (atom, int, bool) {
+	if !isNumberedWeekday(r) {
+		return atom{}, 0, false
+	}
+	a := atom{kind: atomWeekday, value: int(r[0]-'0') - 1} // "2ª" is monday
+
+	rest := r[2:]
+	s := spaces(rest)
+	if len(rest) > s && rest[s] == '-' {
+		s++
+	}
+	if strings.HasPrefix(string(rest[s:]), "feira") {
+		return a, 2 + s + len("feira"), true
+	}
+
+	if n := len(atoms); n >= 2 && atoms[n-2].kind == atomWeekday && atoms[n-1].kind == atomRange {
+		return a, 2, true // "Seg a 6ª"
+	}
+
+	s = spaces(rest)
+	switch w := letters(rest[s:]); {
+	case w > 0 && rangeWords[string(rest[s:s+w])]:
+		s += w
+	case len(rest) > s && rest[s] == '-':
+		s++
+	default:
+		return atom{}, 0, false
+	}
+	if !isNumberedWeekday(rest[s+spaces(rest[s:]):]) {
+		return atom{}, 0, false
+	}
+
+	return a, 2, true
+}
+
+// isNumberedWeekday r starts with "2ª" to "6ª" or "2º" to "6º"
+func isNumberedWeekday(r []rune) bool {
+	return len(r) >= 2 && r[0] >= '2' && r[0] <= '6' && (r[1] == 'ª' || r[1] == 'º') &&
+		(len(r) == 2 || !unicode.IsLetter(r[2]) && !unicode.IsDigit(r[2]))
+}
+
+// timeAtom of hour and minutes with optional "am" or "pm" at the start of suffix, used is count of scanned runes before suffix
+func timeAtom(hour int, minutes int, suffix []rune, used int) ([]atom, int, error) {
+	s := spaces(suffix)
+	if w := letters(suffix[s:]); w > 0 {
+		switch string(suffix[s : s+w]) {
+		case "am":
+			if hour == 12 {
+				hour = 0
+			}
+			used += s + w
+		case "pm":
+			if hour < 12 {
+				hour += 12
+			}
+			used += s + w
+		}
+	}
+
+	if hour > 23 || minutes > 59 {
+		return nil, used, fmt.Errorf("wrong time %02d:%02d", hour, minutes)
+	}
+
+	return []atom{{atomTime, hour*60 + minutes}}, used, nil
+}
+
+// group atoms in dates: a repeated day, month or year starts the next date, "a" or "-" ends the date.
+// isRange if the dates are separated by a range atom. Weekdays are the named weekdays
+func group(atoms []atom, ref time.Time) (dates []date, isRange bool, weekdayList []time.Weekday) {
+	var (
+		cur     date
+		pending bool // range separator after the date
+	)
+	closeDate := func() {
+		switch {
+		case cur == date{}:
+			return
+		case cur.day == 0 && cur.month == 0 && len(dates) > 0 && dates[len(dates)-1].year == 0:
+			dates[len(dates)-1].year, dates[len(dates)-1].explicitYear = cur.year, true // "6 Jan, 2024"
+		case cur.day != 0 || cur.month != 0:
+			dates = append(dates, cur)
+		}
+		cur = date{}
+	}
+	set := func(field *int, v int) {
+		if *field != 0 {
+			closeDate()
+		}
+		if pending && len(dates) > 0 {
+			isRange = true
+		}
+		pending = false
+		*field = v
+	}
+
+	for _, a := range atoms {
+		switch a.kind {
+		case atomDay:
+			set(&cur.day, a.value)
+		case atomMonth:
+			set(&cur.month, a.value)
+		case atomYear:
+			set(&cur.year, a.value)
+			cur.explicitYear = true
+		case atomWeekday:
+			weekdayList = append(weekdayList, time.Weekday(a.value))
+		case atomRelative:
+			closeDate()
+			day := midnight(ref, ref.Location()).AddDate(0, 0, a.value)
+			set(&cur.day, day.Day())
+			cur.month, cur.year, cur.explicitYear = int(day.Month()), day.Year(), true
+			closeDate()
+		case atomRange:
+			closeDate()
+			pending = len(dates) > 0
+		}
+	}
+	closeDate()
+
+	return dates, isRange, weekdayList
+}
+
+// resolve days of the dates: missing fields from the next dates ("3 a 5 de Março"), then from the previous ones,
+// then the last date is the next one from ref and the previous dates are before it. Days go in order,
+// a date with not explicit year is moved to the next or the previous year
+func resolve(dates []date, ref time.Time) ([]time.Time, error) {
+	for i := len(dates) - 2; i >= 0; i-- {
+		fill(&dates[i], dates[i+1])
+	}
+	for i := 1; i < len(dates); i++ {
+		fill(&dates[i], dates[i-1])
+	}
+
+	if last := &dates[len(dates)-1]; last.month == 0 || last.year == 0 {
+		nextDate(last, ref)
+		last.explicitYear = true // the previous dates are moved before it
+		for i := len(dates) - 2; i >= 0; i-- {
+			fill(&dates[i], dates[i+1])
+		}
+	}
+
+	days := make([]time.Time, len(dates))
+	for i, d := range dates {
+		day, err := d.time(ref.Location())
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && day.Before(days[i-1]) {
+			switch {
+			case !d.explicitYear:
+				day = day.AddDate(1, 0, 0) // "28 Dez 2023 a 3 Jan"
+			case !dates[i-1].explicitYear:
+				days[i-1] = days[i-1].AddDate(-1, 0, 0) // "28 Dez a 3 Jan 2024"
+			}
+		}
+		days[i] = day
+	}
+
+	for i := 1; i < len(days); i++ {
+		if days[i].Before(days[i-1]) {
+			return nil, fmt.Errorf("day %s before %s", days[i].Format(time.DateOnly), days[i-1].Format(time.DateOnly))
+		}
+	}
+
+	return days, nil
+}
+
+// fill missing month and year of d from the other date
+func fill(d *date, other date) {
+	if d.month == 0 {
+		d.month = other.month
+	}
+	if d.year == 0 {
+		d.year = other.year
+	}
+}
+
+// nextDate fill missing month and year of d with the first ones from ref: the month of or after ref for days
+// without month, the year of or after ref. Dates of the last pastGraceDays are not moved to the next year
+func nextDate(d *date, ref time.Time) {
+	loc := ref.Location()
+	from := midnight(ref, loc).AddDate(0, 0, -pastGraceDays)
+
+	var candidates []date
+	for _, years := range []int{-1, 0, 1} {
+		c := *d
+		if c.year == 0 {
+			c.year = ref.Year() + years
+		} else if years != 0 {
+			continue
+		}
+		if c.month != 0 {
+			candidates = append(candidates, c)
+			continue
+		}
+		for month := 1; month <= 12; month++ {
+			c.month = month
+			candidates = append(candidates, c)
+		}
+	}
+
+	for _, c := range candidates { // in time order
+		t, err := c.time(loc)
+		if err != nil {
+			continue
+		}
+		if c.day == 0 {
+			t = t.AddDate(0, 1, -1) // the whole month
+		}
+		if !t.Before(from) {
+			*d = c
+			return
+		}
+	}
+
+	*d = candidates[len(candidates)-1] // past month of the explicit year
+}
+
+// startsUntil the text starts with "até", "until" before its days, weekdays are skipped: "Sáb, até 6 Jan"
+func startsUntil(atoms []atom) bool {
+	for _, a := range atoms {
+		if a.kind != atomWeekday {
+			return a.kind == atomRange && a.value == rangeUntil
+		}
+	}
+
+	return false
+}
+
+// time midnight of the date, the first day of the month without day
+func (d date) time(loc *time.Location) (time.Time, error) {
+	day := d.day
+	if day == 0 {
+		day = 1
+	}
+	if d.month < 1 || d.month > 12 {
+		return time.Time{}, fmt.Errorf("wrong month %d", d.month)
+	}
+
+	t := time.Date(d.year, time.Month(d.month), day, 0, 0, 0, 0, loc)
+	if t.Day() != day {
+		return time.Time{}, fmt.Errorf("wrong day %d of %s %d", day, time.Month(d.month), d.year)
+	}
+
+	return t, nil
+}
+
+// times of the atoms in minutes after midnight: the first time, the end time is the next one after a range separator
+func times(atoms []atom) (start int, end int, hasStart bool, hasEnd bool) {
+	separated := false
+	for _, a := range atoms {
+		switch {
+		case a.kind == atomTime && !hasStart:
+			start, hasStart = a.value, true
+		case a.kind == atomTime && separated:
+			return start, a.value, true, true
+		case a.kind == atomTime:
+			return start, 0, true, false // list of times: "18h e 21h30"
+		case a.kind == atomRange && hasStart:
+			separated = true
+		}
+	}
+
+	return start, 0, hasStart, false
+}
+
+// at minutes after midnight of the day
+func at(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// midnight of the day of t in loc
+func midnight(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func digits(r []rune) int {
+	n := 0
+	for n < len(r) && unicode.IsDigit(r[n]) {
+		n++
+	}
+
+	return n
+}
+
+func letters(r []rune) int {
+	n := 0
+	for n < len(r) && unicode.IsLetter(r[n]) {
+		n++
+	}
+
+	return n
+}
+
+func spaces(r []rune) int {
+	n := 0
+	for n < len(r) && unicode.IsSpace(r[n]) {
+		n++
+	}
+
+	return n
+}
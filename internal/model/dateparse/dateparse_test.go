@@ -0,0 +1,473 @@
+package dateparse
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	ref := time.Date(2024, time.January, 10, 12, 0, 0, 0, lisbon) // wednesday
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, lisbon)
+	}
+	day := func(year int, month time.Month, d int) time.Time {
+		return at(year, month, d, 0, 0)
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		wantStart time.Time
+		wantEnd   time.Time
+		allDay    bool
+		wantDays  []time.Time
+		wantRange bool
+		wantErr   error
+	}{
+		// agendaculturalporto.org
+		{
+			name:      "agenda date",
+			text:      "06 Jan 2024",
+			wantStart: day(2024, time.January, 6),
+			wantEnd:   day(2024, time.January, 7),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.January, 6)},
+		},
+		{
+			name:      "agenda date and time range",
+			text:      "06 Jan 2024 21:00 - 23:30",
+			wantStart: at(2024, time.January, 6, 21, 0),
+			wantEnd:   at(2024, time.January, 6, 23, 30),
+			wantDays:  []time.Time{day(2024, time.January, 6)},
+		},
+		{
+			name:      "agenda date range labels",
+			text:      "18 - 20 Jan 2024 21:00 - 23:30",
+			wantStart: at(2024, time.January, 18, 21, 0),
+			wantEnd:   at(2024, time.January, 20, 23, 30),
+			wantDays:  []time.Time{day(2024, time.January, 18), day(2024, time.January, 20)},
+			wantRange: true,
+		},
+		{
+			name:      "agenda portuguese abbreviation, summer time",
+			text:      "15 Ago 2030 18:30",
+			wantStart: at(2030, time.August, 15, 18, 30),
+			wantDays:  []time.Time{day(2030, time.August, 15)},
+		},
+		{
+			name:      "ends after midnight",
+			text:      "06 Jan 2024 23:00 - 02:00",
+			wantStart: at(2024, time.January, 6, 23, 0),
+			wantEnd:   at(2024, time.January, 7, 2, 0),
+			wantDays:  []time.Time{day(2024, time.January, 6)},
+		},
+		// porto.pt and teatro municipal do porto
+		{
+			name:      "api date time",
+			text:      "2022-05-12 10:00:00",
+			wantStart: at(2022, time.May, 12, 10, 0),
+			wantDays:  []time.Time{day(2022, time.May, 12)},
+		},
+		{
+			name:      "iso date",
+			text:      "2024-01-19",
+			wantStart: day(2024, time.January, 19),
+			wantEnd:   day(2024, time.January, 20),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.January, 19)},
+		},
+		{
+			name:      "dates text of events",
+			text:      "26 Oct 2022 - 14 Jan 2023",
+			wantStart: day(2022, time.October, 26),
+			wantEnd:   day(2023, time.January, 15),
+			allDay:    true,
+			wantDays:  []time.Time{day(2022, time.October, 26), day(2023, time.January, 14)},
+			wantRange: true,
+		},
+		{
+			name:      "old dates text of events",
+			text:      "Jul 10th, 2030 - Jul 11th, 2030",
+			wantStart: day(2030, time.July, 10),
+			wantEnd:   day(2030, time.July, 12),
+			allDay:    true,
+			wantDays:  []time.Time{day(2030, time.July, 10), day(2030, time.July, 11)},
+			wantRange: true,
+		},
+		// selector sites and feeds
+		{
+			name:      "title with numeric date",
+			text:      "Maria João Quartet | 14/03/2030",
+			wantStart: day(2030, time.March, 14),
+			wantEnd:   day(2030, time.March, 15),
+			allDay:    true,
+			wantDays:  []time.Time{day(2030, time.March, 14)},
+		},
+		{
+			name:      "weekday and day without year",
+			text:      "sábado, 12 de janeiro",
+			wantStart: day(2024, time.January, 12),
+			wantEnd:   day(2024, time.January, 13),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.January, 12)},
+		},
+		{
+			name:      "english ordinal, past day of the next year",
+			text:      "1st of January",
+			wantStart: day(2025, time.January, 1),
+			wantEnd:   day(2025, time.January, 2),
+			allDay:    true,
+			wantDays:  []time.Time{day(2025, time.January, 1)},
+		},
+		{
+			name:      "full portuguese date with time",
+			text:      "Sábado, 6 de Janeiro de 2024 às 21h30",
+			wantStart: at(2024, time.January, 6, 21, 30),
+			wantDays:  []time.Time{day(2024, time.January, 6)},
+		},
+		{
+			name:      "weekday feira",
+			text:      "Quarta-feira, 17 Janeiro 2024, 19h",
+			wantStart: at(2024, time.January, 17, 19, 0),
+			wantDays:  []time.Time{day(2024, time.January, 17)},
+		},
+		{
+			name:      "range of days of the month",
+			text:      "3 a 5 de Março",
+			wantStart: day(2024, time.March, 3),
+			wantEnd:   day(2024, time.March, 6),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.March, 3), day(2024, time.March, 5)},
+			wantRange: true,
+		},
+		{
+			name:      "range of months",
+			text:      "De 28 de Fevereiro a 3 de Março de 2024, 10h00 - 18h00",
+			wantStart: at(2024, time.February, 28, 10, 0),
+			wantEnd:   at(2024, time.March, 3, 18, 0),
+			wantDays:  []time.Time{day(2024, time.February, 28), day(2024, time.March, 3)},
+			wantRange: true,
+		},
+		{
+			name:      "range through the new year",
+			text:      "28 Dez a 3 Jan 2024",
+			wantStart: day(2023, time.December, 28),
+			wantEnd:   day(2024, time.January, 4),
+			allDay:    true,
+			wantDays:  []time.Time{day(2023, time.December, 28), day(2024, time.January, 3)},
+			wantRange: true,
+		},
+		{
+			name:      "range through the new year, year of the start",
+			text:      "28 Dez 2023 até 3 Jan",
+			wantStart: day(2023, time.December, 28),
+			wantEnd:   day(2024, time.January, 4),
+			allDay:    true,
+			wantDays:  []time.Time{day(2023, time.December, 28), day(2024, time.January, 3)},
+			wantRange: true,
+		},
+		{
+			name:      "english range",
+			text:      "March 3 to 5, 2024 9pm",
+			wantStart: at(2024, time.March, 3, 21, 0),
+			wantEnd:   at(2024, time.March, 5, 21, 0),
+			wantDays:  []time.Time{day(2024, time.March, 3), day(2024, time.March, 5)},
+			wantRange: true,
+		},
+		{
+			name:      "list of days",
+			text:      "6, 7 e 8 de Janeiro, 21h30",
+			wantStart: at(2024, time.January, 6, 21, 30),
+			wantEnd:   at(2024, time.January, 8, 21, 30),
+			wantDays:  []time.Time{day(2024, time.January, 6), day(2024, time.January, 7), day(2024, time.January, 8)},
+		},
+		{
+			name:      "list of times",
+			text:      "Sáb 13 Jan, 18h e 21h30",
+			wantStart: at(2024, time.January, 13, 18, 0),
+			wantDays:  []time.Time{day(2024, time.January, 13)},
+		},
+		{
+			name:      "whole month",
+			text:      "Fevereiro 2024",
+			wantStart: day(2024, time.February, 1),
+			wantEnd:   day(2024, time.March, 1),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.February, 1), day(2024, time.February, 29)},
+			wantRange: true,
+		},
+		{
+			name:      "range of whole months",
+			text:      "Março a Abril",
+			wantStart: day(2024, time.March, 1),
+			wantEnd:   day(2024, time.May, 1),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.March, 1), day(2024, time.April, 30)},
+			wantRange: true,
+		},
+		{
+			name:      "only weekday and time",
+			text:      "Qua, 21h30",
+			wantStart: at(2024, time.January, 10, 21, 30),
+			wantDays:  []time.Time{day(2024, time.January, 10)},
+		},
+		{
+			name:      "next weekday",
+			text:      "Sexta-feira às 18.30h",
+			wantStart: at(2024, time.January, 12, 18, 30),
+			wantDays:  []time.Time{day(2024, time.January, 12)},
+		},
+		{
+			name:      "day without month, in the last week",
+			text:      "dia 5, 22h",
+			wantStart: at(2024, time.January, 5, 22, 0),
+			wantDays:  []time.Time{day(2024, time.January, 5)},
+		},
+		{
+			name:      "day without month, the next one",
+			text:      "dia 31, 22h",
+			wantStart: at(2024, time.January, 31, 22, 0),
+			wantDays:  []time.Time{day(2024, time.January, 31)},
+		},
+		{
+			name:      "today",
+			text:      "Hoje, 21h",
+			wantStart: at(2024, time.January, 10, 21, 0),
+			wantDays:  []time.Time{day(2024, time.January, 10)},
+		},
+		{
+			name:      "tomorrow with time range in english",
+			text:      "Tomorrow 9:30 am - 1 pm",
+			wantStart: at(2024, time.January, 11, 9, 30),
+			wantEnd:   at(2024, time.January, 11, 13, 0),
+			wantDays:  []time.Time{day(2024, time.January, 11)},
+		},
+		{
+			name:      "portuguese ordinal and hours",
+			text:      "1º de Dezembro, às 10 horas",
+			wantStart: at(2024, time.December, 1, 10, 0),
+			wantDays:  []time.Time{day(2024, time.December, 1)},
+		},
+		{
+			name:      "numbered weekday and day",
+			text:      "5ª feira, 22 Out",
+			wantStart: day(2024, time.October, 22),
+			wantEnd:   day(2024, time.October, 23),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.October, 22)},
+		},
+		{
+			name:      "weekday and ordinal day",
+			text:      "sáb, 1º Nov",
+			wantStart: day(2024, time.November, 1),
+			wantEnd:   day(2024, time.November, 2),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.November, 1)},
+		},
+		{
+			name:      "past month without year, the upcoming one",
+			text:      "3 de setembro",
+			wantStart: day(2024, time.September, 3),
+			wantEnd:   day(2024, time.September, 4),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.September, 3)},
+		},
+		{
+			name:      "until the day, from the reference day",
+			text:      "Até 31 de Dezembro",
+			wantStart: day(2024, time.January, 10),
+			wantEnd:   day(2025, time.January, 1),
+			allDay:    true,
+			wantDays:  []time.Time{day(2024, time.January, 10), day(2024, time.December, 31)},
+			wantRange: true,
+		},
+		{
+			name:      "running range started in the previous year",
+			text:      "1 Dez a 20 Jan",
+			wantStart: day(2023, time.December, 1),
+			wantEnd:   day(2024, time.January, 21),
+			allDay:    true,
+			wantDays:  []time.Time{day(2023, time.December, 1), day(2024, time.January, 20)},
+			wantRange: true,
+		},
+		{
+			name:      "portuguese times range",
+			text:      "Domingo, 14 de Janeiro, das 15h às 16h30",
+			wantStart: at(2024, time.January, 14, 15, 0),
+			wantEnd:   at(2024, time.January, 14, 16, 30),
+			wantDays:  []time.Time{day(2024, time.January, 14)},
+		},
+		{
+			name:      "short numeric date",
+			text:      "6.1.24, 21h - 23h",
+			wantStart: at(2024, time.January, 6, 21, 0),
+			wantEnd:   at(2024, time.January, 6, 23, 0),
+			wantDays:  []time.Time{day(2024, time.January, 6)},
+		},
+		{
+			name:    "description without date",
+			text:    "A voz de Maria João regressa ao Porto.Hot Clube, 21h30.",
+			wantErr: ErrNoDate,
+		},
+		{
+			name:    "weekdays without date",
+			text:    "Qua a Dom, 10h - 18h",
+			wantErr: ErrNoDate,
+		},
+		{
+			name:    "numbered weekdays range without date",
+			text:    "2ª a 6ª, 10h–18h",
+			wantErr: ErrNoDate,
+		},
+		{
+			name:    "empty",
+			wantErr: ErrNoDate,
+		},
+		{
+			name: "wrong day",
+			text: "30 Fev 2024",
+		},
+		{
+			name: "wrong time",
+			text: "06 Jan 2024 25:00",
+		},
+		{
+			name: "wrong month",
+			text: "06/13/2024",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text, ref)
+			if tt.wantErr != nil || tt.wantStart.IsZero() {
+				assert.Error(t, err)
+				if tt.wantErr != nil {
+					assert.Truef(t, errors.Is(err, tt.wantErr), "want %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Truef(t, tt.wantStart.Equal(got.Start), "start %s, want %s", got.Start, tt.wantStart)
+			assert.Truef(t, tt.wantEnd.Equal(got.End), "end %s, want %s", got.End, tt.wantEnd)
+			assert.Equal(t, tt.allDay, got.AllDay)
+			assert.Equal(t, tt.wantRange, got.Range)
+			if assert.Len(t, got.Days, len(tt.wantDays)) {
+				for i := range tt.wantDays {
+					assert.Truef(t, tt.wantDays[i].Equal(got.Days[i]), "day %d: %s, want %s", i, got.Days[i], tt.wantDays[i])
+				}
+			}
+			assert.Equal(t, lisbon, got.Start.Location())
+		})
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []WeekdayHours
+		wantErr bool
+	}{
+		{
+			name: "numbered weekdays range and listed weekdays",
+			text: "3ª a 6ª, 10h–18h; sáb e dom, 14h–18h",
+			want: []WeekdayHours{
+				{time.Tuesday, 600, 1080}, {time.Wednesday, 600, 1080}, {time.Thursday, 600, 1080}, {time.Friday, 600, 1080},
+				{time.Saturday, 840, 1080}, {time.Sunday, 840, 1080},
+			},
+		},
+		{
+			name: "range over the week end",
+			text: "Horário: Qua a Dom, 10h - 18h",
+			want: []WeekdayHours{
+				{time.Wednesday, 600, 1080}, {time.Thursday, 600, 1080}, {time.Friday, 600, 1080},
+				{time.Saturday, 600, 1080}, {time.Sunday, 600, 1080},
+			},
+		},
+		{
+			name: "unknown end and without hours",
+			text: "Sexta-feira às 21h30, sábado",
+			want: []WeekdayHours{{time.Friday, 1290, 1290}, {time.Saturday, 0, 0}},
+		},
+		{
+			name: "weekday of a date",
+			text: "Sábado, 6 de Janeiro, 21h",
+		},
+		{
+			name: "without weekdays",
+			text: "Das 10h às 18h",
+		},
+		{
+			name:    "wrong time",
+			text:    "Seg 25:00",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Schedule(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.want), len(got))
+			if len(tt.want) > 0 {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_nextYear(t *testing.T) {
+	december := time.Date(2023, time.December, 20, 0, 0, 0, 0, time.UTC)
+
+	got, err := Parse("12 Jan", december)
+	assert.NoError(t, err)
+	assert.Equal(t, 2024, got.Start.Year(), "next year")
+
+	january := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+	got, err = Parse("12 Dec", january)
+	assert.NoError(t, err)
+	assert.Equal(t, 2024, got.Start.Year(), "upcoming, not the past one")
+
+	got, err = Parse("28 Dec", january)
+	assert.NoError(t, err)
+	assert.Equal(t, 2023, got.Start.Year(), "started in the last week")
+}
+
+func TestMonth(t *testing.T) {
+	for name, want := range map[string]time.Month{
+		"Janeiro": time.January, "fev": time.February, "Março": time.March, "MARCO": time.March, "Abr.": time.April,
+		"mai": time.May, "June": time.June, "ago": time.August, "Set": time.September, "Sept": time.September,
+		"out": time.October, "Novembro": time.November, "Dez": time.December, "december": time.December,
+	} {
+		got, ok := Month(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, ok := Month("segunda")
+	assert.False(t, ok)
+}
+
+func TestWeekday(t *testing.T) {
+	for name, want := range map[string]time.Weekday{
+		"Domingo": time.Sunday, "segunda-feira": time.Monday, "Terça": time.Tuesday, "ter": time.Tuesday,
+		"qua": time.Wednesday, "Quinta-Feira": time.Thursday, "sex": time.Friday, "Sáb.": time.Saturday,
+		"sabado": time.Saturday, "Sunday": time.Sunday, "thurs": time.Thursday, "fri": time.Friday,
+	} {
+		got, ok := Weekday(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, ok := Weekday("março")
+	assert.False(t, ok)
+}
@@ -0,0 +1,87 @@
+package dateparse
+
+import (
+	"strings"
+	"time"
+)
+
+// months names in portuguese and english, full and abbreviated, without accents
+var months = map[string]time.Month{
+	"janeiro": time.January, "jan": time.January, "january": time.January,
+	"fevereiro": time.February, "fev": time.February, "february": time.February, "feb": time.February,
+	"marco": time.March, "mar": time.March, "march": time.March,
+	"abril": time.April, "abr": time.April, "april": time.April, "apr": time.April,
+	"maio": time.May, "mai": time.May, "may": time.May,
+	"junho": time.June, "jun": time.June, "june": time.June,
+	"julho": time.July, "jul": time.July, "july": time.July,
+	"agosto": time.August, "ago": time.August, "august": time.August, "aug": time.August,
+	"setembro": time.September, "set": time.September, "september": time.September, "sep": time.September, "sept": time.September,
+	"outubro": time.October, "out": time.October, "october": time.October, "oct": time.October,
+	"novembro": time.November, "nov": time.November, "november": time.November,
+	"dezembro": time.December, "dez": time.December, "december": time.December, "dec": time.December,
+}
+
+// weekdays names in portuguese (without "-feira") and english, full and abbreviated, without accents
+var weekdays = map[string]time.Weekday{
+	"domingo": time.Sunday, "dom": time.Sunday, "sunday": time.Sunday, "sun": time.Sunday,
+	"segunda": time.Monday, "seg": time.Monday, "monday": time.Monday, "mon": time.Monday,
+	"terca": time.Tuesday, "ter": time.Tuesday, "tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"quarta": time.Wednesday, "qua": time.Wednesday, "wednesday": time.Wednesday, "wed": time.Wednesday,
+	"quinta": time.Thursday, "qui": time.Thursday, "thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"sexta": time.Friday, "sex": time.Friday, "friday": time.Friday, "fri": time.Friday,
+	"sabado": time.Saturday, "sab": time.Saturday, "saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// rangeWords separators of the first and the last day or time: "3 a 5 de Março", "Mar 3 to Mar 5", "das 15h às 16h"
+var rangeWords = map[string]bool{
+	"a": true, "ao": true, "ate": true, "as": true,
+	"to": true, "till": true, "until": true, "through": true, "thru": true,
+}
+
+// untilWords range words of the last day only: "Até 31 de Dezembro"
+var untilWords = map[string]bool{"ate": true, "until": true, "till": true}
+
+// relativeDays days after the reference day
+var relativeDays = map[string]int{
+	"hoje": 0, "today": 0,
+	"amanha": 1, "tomorrow": 1,
+}
+
+// ordinals suffixes of days: "1st", "2nd", "1º"
+var ordinals = map[string]bool{"st": true, "nd": true, "rd": true, "th": true, "º": true, "ª": true}
+
+// timeWords suffixes of "21h": "21horas", "21h30min"
+var timeWords = map[string]bool{"oras": true, "rs": true, "min": true, "m": true}
+
+// hoursWords after the hour: "21 horas", "21 h"
+var hoursWords = map[string]bool{"h": true, "horas": true, "hrs": true}
+
+var unaccent = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e",
+	"í", "i",
+	"ó", "o", "ô", "o", "õ", "o",
+	"ú", "u", "ü", "u",
+	"ç", "c",
+	"–", "-", "—", "-",
+)
+
+// Month by portuguese or english name, full or abbreviated: "Março", "mar", "March"
+func Month(name string) (time.Month, bool) {
+	m, ok := months[normalize(strings.TrimSuffix(strings.TrimSpace(name), "."))]
+
+	return m, ok
+}
+
+// Weekday by portuguese or english name, full or abbreviated: "Sábado", "segunda-feira", "qua", "Sunday"
+func Weekday(name string) (time.Weekday, bool) {
+	name = strings.TrimSuffix(normalize(strings.TrimSuffix(strings.TrimSpace(name), ".")), "-feira")
+	wd, ok := weekdays[name]
+
+	return wd, ok
+}
+
+// normalize lower case without accents, dashes as "-"
+func normalize(text string) string {
+	return unaccent.Replace(strings.ToLower(text))
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"time"
+	_ "time/tzdata" // Europe/Lisbon without system zoneinfo
+)
+
+const (
+	layoutOutDate = "02 Jan 2006"
+	layoutOutTime = "15:04"
+)
+
+// Lisbon time zone of Porto events: WET in winter and WEST in summer
+var Lisbon = loadLisbon()
+
+func loadLisbon() *time.Location {
+	loc, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		panic(err) // zoneinfo is embedded by time/tzdata
+	}
+
+	return loc
+}
+
+// LisbonDate midnight in Lisbon of the calendar day of t (in the location of t)
+func LisbonDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Lisbon)
+}
+
+// SetDates of the event: Start and End (zero if unknown) in Lisbon, AllDay, and DateText with Time derived from them.
+//
+// Days of all day events are taken as calendar days, End is exclusive: midnight after the last day
+func (e *Event) SetDates(start time.Time, end time.Time, allDay bool) {
+	if allDay {
+		start = LisbonDate(start)
+		if end.IsZero() || !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		} else {
+			end = LisbonDate(end)
+		}
+	} else {
+		start = start.In(Lisbon)
+		if !end.IsZero() {
+			end = end.In(Lisbon)
+		}
+	}
+
+	e.Start, e.End, e.AllDay = start, end, allDay
+	e.DateText, e.Time = DatesText(start, end, allDay)
+}
+
+// DatesText "12 Jan 2030 - 14 Jan 2030" and "19:30 - 21:00" in Lisbon. All day events have no time
+func DatesText(start time.Time, end time.Time, allDay bool) (string, string) {
+	start = start.In(Lisbon)
+	if !end.IsZero() {
+		end = end.In(Lisbon)
+	}
+	if allDay && !end.IsZero() {
+		end = end.Add(-time.Nanosecond) // exclusive end of all day event
+	}
+
+	date := start.Format(layoutOutDate)
+	if end.After(start) && end.Format(layoutOutDate) != date {
+		date += " - " + end.Format(layoutOutDate)
+	}
+
+	switch {
+	case allDay:
+		return date, ""
+	case !end.After(start):
+		return date, start.Format(layoutOutTime)
+	}
+
+	return date, start.Format(layoutOutTime) + " - " + end.Format(layoutOutTime)
+}
+
+// EndTime when the event is over: End, or midnight after the Start day if the end is unknown
+func (e Event) EndTime() time.Time {
+	if !e.End.IsZero() {
+		return e.End
+	}
+
+	return LisbonDate(e.Start.In(Lisbon)).AddDate(0, 0, 1)
+}
+
+// Ended the event is over at now. Events without dates never end
+func (e Event) Ended(now time.Time) bool {
+	return !e.Start.IsZero() && !e.EndTime().After(now)
+}
+
+// Ongoing the event started before now and is not over
+func (e Event) Ongoing(now time.Time) bool {
+	return !e.Start.IsZero() && !e.Start.After(now) && !e.Ended(now)
+}
+
+// Before sort order of events: by Start, then by EndTime. Events without dates are the last
+func (e Event) Before(other Event) bool {
+	switch {
+	case e.Start.IsZero() || other.Start.IsZero():
+		return !e.Start.IsZero() && other.Start.IsZero()
+	case !e.Start.Equal(other.Start):
+		return e.Start.Before(other.Start)
+	}
+
+	return e.EndTime().Before(other.EndTime())
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvent_SetDates(t *testing.T) {
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2030, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name         string
+		start        time.Time
+		end          time.Time
+		allDay       bool
+		wantStart    time.Time
+		wantEnd      time.Time
+		wantDateText string
+		wantTime     string
+	}{
+		{
+			name:         "summer time",
+			start:        utc(time.July, 12, 18, 30),
+			end:          utc(time.July, 12, 20, 0),
+			wantStart:    time.Date(2030, time.July, 12, 19, 30, 0, 0, Lisbon),
+			wantEnd:      time.Date(2030, time.July, 12, 21, 0, 0, 0, Lisbon),
+			wantDateText: "12 Jul 2030",
+			wantTime:     "19:30 - 21:00",
+		},
+		{
+			name:         "winter time, date range",
+			start:        utc(time.January, 12, 10, 0),
+			end:          utc(time.February, 1, 18, 0),
+			wantStart:    time.Date(2030, time.January, 12, 10, 0, 0, 0, Lisbon),
+			wantEnd:      time.Date(2030, time.February, 1, 18, 0, 0, 0, Lisbon),
+			wantDateText: "12 Jan 2030 - 01 Feb 2030",
+			wantTime:     "10:00 - 18:00",
+		},
+		{
+			name:         "without end",
+			start:        utc(time.January, 12, 21, 0),
+			wantStart:    time.Date(2030, time.January, 12, 21, 0, 0, 0, Lisbon),
+			wantDateText: "12 Jan 2030",
+			wantTime:     "21:00",
+		},
+		{
+			name:         "all day, calendar day of other zone",
+			start:        time.Date(2030, time.July, 12, 0, 0, 0, 0, time.UTC),
+			allDay:       true,
+			wantStart:    time.Date(2030, time.July, 12, 0, 0, 0, 0, Lisbon),
+			wantEnd:      time.Date(2030, time.July, 13, 0, 0, 0, 0, Lisbon),
+			wantDateText: "12 Jul 2030",
+		},
+		{
+			name:         "all day, exclusive end",
+			start:        time.Date(2030, time.April, 1, 0, 0, 0, 0, Lisbon),
+			end:          time.Date(2030, time.May, 1, 0, 0, 0, 0, Lisbon),
+			allDay:       true,
+			wantStart:    time.Date(2030, time.April, 1, 0, 0, 0, 0, Lisbon),
+			wantEnd:      time.Date(2030, time.May, 1, 0, 0, 0, 0, Lisbon),
+			wantDateText: "01 Apr 2030 - 30 Apr 2030",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ev Event
+			ev.SetDates(tt.start, tt.end, tt.allDay)
+
+			assert.Truef(t, tt.wantStart.Equal(ev.Start), "start %s", ev.Start)
+			assert.Truef(t, tt.wantEnd.Equal(ev.End), "end %s", ev.End)
+			assert.Equal(t, Lisbon, ev.Start.Location())
+			assert.Equal(t, tt.allDay, ev.AllDay)
+			assert.Equal(t, tt.wantDateText, ev.DateText)
+			assert.Equal(t, tt.wantTime, ev.Time)
+		})
+	}
+}
+
+func TestEvent_Ended(t *testing.T) {
+	now := time.Date(2030, time.January, 12, 20, 0, 0, 0, Lisbon)
+
+	var concert, exhibition, allDay, finished Event
+	concert.SetDates(now.Add(-time.Hour), time.Time{}, false)
+	exhibition.SetDates(now.AddDate(0, -1, 0), now.AddDate(0, 1, 0), false)
+	allDay.SetDates(now, time.Time{}, true)
+	finished.SetDates(now.Add(-3*time.Hour), now.Add(-time.Hour), false)
+
+	assert.True(t, concert.Ongoing(now), "without end, lasts until the end of the day")
+	assert.True(t, concert.Ended(now.Add(4*time.Hour)))
+	assert.True(t, exhibition.Ongoing(now))
+	assert.True(t, allDay.Ongoing(now))
+	assert.True(t, finished.Ended(now))
+	assert.False(t, finished.Ongoing(now))
+	assert.False(t, Event{}.Ended(now), "without dates")
+}
+
+func TestEvent_Before(t *testing.T) {
+	start := time.Date(2030, time.January, 12, 20, 0, 0, 0, Lisbon)
+
+	var early, short, long Event
+	early.SetDates(start.Add(-time.Hour), time.Time{}, false)
+	short.SetDates(start, start.Add(time.Hour), false)
+	long.SetDates(start, start.Add(2*time.Hour), false)
+
+	assert.True(t, early.Before(short))
+	assert.True(t, short.Before(long), "the same start, earlier end first")
+	assert.False(t, long.Before(short))
+	assert.True(t, long.Before(Event{}), "events without dates are the last")
+	assert.False(t, Event{}.Before(early))
+}
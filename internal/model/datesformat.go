@@ -0,0 +1,160 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lang language of texts for people: posts and the web UI
+type Lang string
+
+const (
+	LangEn Lang = "en"
+	LangPt Lang = "pt"
+)
+
+var monthsPt = [...]string{
+	"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+	"julho", "agosto", "setembro", "outubro", "novembro", "dezembro",
+}
+
+// FormatDates date and time texts of the event for people in lang at now:
+// "Jan 6th" or "6 de janeiro", "Jan 12th – 14th, 2031", "until Dec 31st" for ongoing events of several days, "19:30 – 21:00".
+// Years are omitted in the year of now, all day events have no time.
+//
+// Own texts of the event are kept: DateText or Time of the site or edited ones, different from SetDates texts.
+// English by default
+func FormatDates(e Event, lang Lang, now time.Time) (string, string) {
+	if e.Start.IsZero() {
+		return e.DateText, e.Time
+	}
+
+	dateText, timeText := DatesText(e.Start, e.End, e.AllDay)
+	if e.DateText == dateText {
+		dateText = formatDays(e, lang, now)
+	} else {
+		dateText = e.DateText
+	}
+	if e.Time == timeText {
+		timeText = formatHours(e)
+	} else {
+		timeText = e.Time
+	}
+
+	return dateText, timeText
+}
+
+// formatDays of the event: the day, the range of days, or the last day of ongoing event
+func formatDays(e Event, lang Lang, now time.Time) string {
+	start := e.Start.In(Lisbon)
+	first, last := LisbonDate(start), LisbonDate(start)
+	switch {
+	case e.AllDay:
+		last = LisbonDate(e.End.In(Lisbon).Add(-time.Nanosecond)) // exclusive end
+	case !e.End.IsZero() && e.End.Sub(e.Start) >= 24*time.Hour: // not the same night
+		last = LisbonDate(e.End.In(Lisbon))
+	}
+
+	today := LisbonDate(now.In(Lisbon))
+	withYear := first.Year() != today.Year() || last.Year() != today.Year()
+
+	switch {
+	case last.After(first) && first.Before(today) && !last.Before(today): // ongoing exhibition
+		if lang == LangPt {
+			return "até " + formatDay(last, lang, last.Year() != today.Year())
+		}
+		return "until " + formatDay(last, lang, last.Year() != today.Year())
+	case !last.After(first):
+		return formatDay(first, lang, withYear)
+	}
+
+	sameMonth := first.Year() == last.Year() && first.Month() == last.Month()
+	sameYear := first.Year() == last.Year()
+
+	if lang == LangPt {
+		switch {
+		case sameMonth:
+			return fmt.Sprintf("%d a %s", first.Day(), formatDay(last, lang, withYear))
+		case sameYear:
+			return fmt.Sprintf("%s a %s", formatDay(first, lang, false), formatDay(last, lang, withYear))
+		}
+		return fmt.Sprintf("%s a %s", formatDay(first, lang, true), formatDay(last, lang, true))
+	}
+
+	switch {
+	case sameMonth && withYear:
+		return fmt.Sprintf("%s – %s, %d", formatDay(first, lang, false), ordinal(last.Day()), last.Year())
+	case sameMonth:
+		return fmt.Sprintf("%s – %s", formatDay(first, lang, false), ordinal(last.Day()))
+	case sameYear && withYear:
+		return fmt.Sprintf("%s – %s", formatDay(first, lang, false), formatDay(last, lang, true))
+	case sameYear:
+		return fmt.Sprintf("%s – %s", formatDay(first, lang, false), formatDay(last, lang, false))
+	}
+
+	return fmt.Sprintf("%s – %s", formatDay(first, lang, true), formatDay(last, lang, true))
+}
+
+// formatDay "Jan 6th, 2024" or "6 de janeiro de 2024"
+func formatDay(day time.Time, lang Lang, withYear bool) string {
+	if lang == LangPt {
+		text := fmt.Sprintf("%d de %s", day.Day(), monthsPt[day.Month()-1])
+		if withYear {
+			text += fmt.Sprintf(" de %d", day.Year())
+		}
+		return text
+	}
+
+	text := day.Format("Jan") + " " + ordinal(day.Day())
+	if withYear {
+		text += fmt.Sprintf(", %d", day.Year())
+	}
+
+	return text
+}
+
+// formatHours of the event: "19:30 – 21:00", the start without known end, daily hours of the schedule
+func formatHours(e Event) string {
+	if e.AllDay {
+		return ""
+	}
+
+	open, closing := ClockOf(e.Start), ClockOf(e.Start)
+	if !e.End.IsZero() {
+		closing = ClockOf(e.End)
+	}
+	if e.Recurrence != nil && len(e.Recurrence.Days) > 0 {
+		hours := e.Recurrence.Days[0]
+		for _, d := range e.Recurrence.Days {
+			if d.Open != hours.Open || d.Close != hours.Close {
+				return open.String() // different hours of days
+			}
+		}
+		if hours.Open == 0 && hours.Close == 0 {
+			return ""
+		}
+		open, closing = hours.Open, hours.Close
+	}
+
+	if closing == open {
+		return open.String()
+	}
+
+	return open.String() + " – " + closing.String()
+}
+
+// ordinal english day of the month: "1st", "12th", "22nd"
+func ordinal(day int) string {
+	suffix := "th"
+	switch {
+	case day%100 >= 11 && day%100 <= 13:
+	case day%10 == 1:
+		suffix = "st"
+	case day%10 == 2:
+		suffix = "nd"
+	case day%10 == 3:
+		suffix = "rd"
+	}
+
+	return fmt.Sprintf("%d%s", day, suffix)
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFormatDates(t *testing.T) {
+	now := time.Date(2030, time.January, 10, 12, 0, 0, 0, Lisbon)
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, Lisbon)
+	}
+	event := func(start time.Time, end time.Time, allDay bool) Event {
+		var ev Event
+		ev.SetDates(start, end, allDay)
+		return ev
+	}
+
+	tests := []struct {
+		name     string
+		ev       Event
+		wantEn   string
+		wantPt   string
+		wantTime string
+	}{
+		{
+			name:     "one day with times",
+			ev:       event(at(2030, time.January, 22, 19, 30), at(2030, time.January, 22, 21, 0), false),
+			wantEn:   "Jan 22nd",
+			wantPt:   "22 de janeiro",
+			wantTime: "19:30 – 21:00",
+		},
+		{
+			name:     "ordinals, unknown end",
+			ev:       event(at(2030, time.February, 1, 10, 0), time.Time{}, false),
+			wantEn:   "Feb 1st",
+			wantPt:   "1 de fevereiro",
+			wantTime: "10:00",
+		},
+		{
+			name:     "the same start and end time",
+			ev:       event(at(2030, time.March, 13, 10, 0), at(2030, time.March, 13, 10, 0), false),
+			wantEn:   "Mar 13th",
+			wantPt:   "13 de março",
+			wantTime: "10:00",
+		},
+		{
+			name:     "ends after midnight",
+			ev:       event(at(2030, time.March, 23, 23, 0), at(2030, time.March, 24, 2, 0), false),
+			wantEn:   "Mar 23rd",
+			wantPt:   "23 de março",
+			wantTime: "23:00 – 02:00",
+		},
+		{
+			name:   "all day",
+			ev:     event(at(2030, time.April, 11, 0, 0), time.Time{}, true),
+			wantEn: "Apr 11th",
+			wantPt: "11 de abril",
+		},
+		{
+			name:     "the same month",
+			ev:       event(at(2030, time.May, 12, 10, 0), at(2030, time.May, 14, 18, 0), false),
+			wantEn:   "May 12th – 14th",
+			wantPt:   "12 a 14 de maio",
+			wantTime: "10:00 – 18:00",
+		},
+		{
+			name:   "the same year, all day",
+			ev:     event(at(2030, time.February, 1, 0, 0), at(2030, time.April, 1, 0, 0), true),
+			wantEn: "Feb 1st – Mar 31st",
+			wantPt: "1 de fevereiro a 31 de março",
+		},
+		{
+			name:   "the same month of other year",
+			ev:     event(at(2031, time.May, 2, 0, 0), at(2031, time.May, 4, 0, 0), true),
+			wantEn: "May 2nd – 3rd, 2031",
+			wantPt: "2 a 3 de maio de 2031",
+		},
+		{
+			name:   "other year",
+			ev:     event(at(2031, time.May, 2, 0, 0), at(2031, time.July, 1, 0, 0), true),
+			wantEn: "May 2nd – Jun 30th, 2031",
+			wantPt: "2 de maio a 30 de junho de 2031",
+		},
+		{
+			name:   "through the new year",
+			ev:     event(at(2030, time.December, 28, 0, 0), at(2031, time.January, 4, 0, 0), true),
+			wantEn: "Dec 28th, 2030 – Jan 3rd, 2031",
+			wantPt: "28 de dezembro de 2030 a 3 de janeiro de 2031",
+		},
+		{
+			name:     "ongoing exhibition",
+			ev:       event(at(2029, time.October, 26, 10, 0), at(2030, time.December, 31, 18, 0), false),
+			wantEn:   "until Dec 31st",
+			wantPt:   "até 31 de dezembro",
+			wantTime: "10:00 – 18:00",
+		},
+		{
+			name:   "ongoing until the next year",
+			ev:     event(at(2030, time.January, 1, 0, 0), at(2031, time.January, 12, 0, 0), true),
+			wantEn: "until Jan 11th, 2031",
+			wantPt: "até 11 de janeiro de 2031",
+		},
+		{
+			name: "schedule hours",
+			ev: func() Event {
+				ev := event(at(2030, time.January, 11, 21, 0), at(2030, time.January, 20, 18, 0), false)
+				ev.Recurrence = &Recurrence{Days: []DayHours{
+					{Weekday: time.Friday, Open: 21 * 60, Close: 21 * 60},
+					{Weekday: time.Saturday, Open: 21 * 60, Close: 21 * 60},
+				}}
+				return ev
+			}(),
+			wantEn:   "Jan 11th – 20th",
+			wantPt:   "11 a 20 de janeiro",
+			wantTime: "21:00",
+		},
+		{
+			name: "own texts",
+			ev: func() Event {
+				ev := event(at(2030, time.January, 19, 19, 0), at(2030, time.January, 20, 21, 0), false)
+				ev.DateText, ev.Time = "19 e 20 Jan", "19:00, 21:00"
+				return ev
+			}(),
+			wantEn:   "19 e 20 Jan",
+			wantPt:   "19 e 20 Jan",
+			wantTime: "19:00, 21:00",
+		},
+		{
+			name:     "without dates",
+			ev:       Event{DateText: "em breve", Time: "21h"},
+			wantEn:   "em breve",
+			wantPt:   "em breve",
+			wantTime: "21h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, hours := FormatDates(tt.ev, LangEn, now)
+			assert.Equal(t, tt.wantEn, date)
+			assert.Equal(t, tt.wantTime, hours)
+
+			date, hours = FormatDates(tt.ev, LangPt, now)
+			assert.Equal(t, tt.wantPt, date)
+			assert.Equal(t, tt.wantTime, hours)
+		})
+	}
+}
+
+func Test_ordinal(t *testing.T) {
+	for day, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd", 23: "23rd", 31: "31st"} {
+		assert.Equal(t, want, ordinal(day))
+	}
+}
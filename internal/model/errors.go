@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// SourceError fatal failure of the source: the site is down, wrong response, wrong config, panic or timeout.
+	// The source has no events, or only ones collected before the timeout
+	SourceError struct {
+		Url string
+		Err error
+	}
+
+	// ItemKind part of the source where the item failed
+	ItemKind string
+
+	// ItemError failed event of the source, events of other items are collected
+	ItemError struct {
+		Kind ItemKind
+		Url  string // the event page, or the source page with the item
+		Err  error
+	}
+
+	// ItemErrors per-item failures of the source, see JoinItemErrors
+	ItemErrors []*ItemError
+)
+
+const (
+	ItemDetail ItemKind = "detail" // event page failed to load or parse
+	ItemParse  ItemKind = "parse"  // element of the event not found or has wrong format
+	ItemPage   ItemKind = "page"   // next page of the events list failed, events of loaded pages are collected
+)
+
+// SourceFailed fatal error of the source with url
+func SourceFailed(url string, err error) *SourceError {
+	return &SourceError{Url: url, Err: err}
+}
+
+func (e *SourceError) Error() string {
+	return "source " + e.Url + ": " + e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// ItemFailed error of the item with url
+func ItemFailed(kind ItemKind, url string, err error) *ItemError {
+	return &ItemError{Kind: kind, Url: url, Err: err}
+}
+
+func (e *ItemError) Error() string {
+	return string(e.Kind) + " " + e.Url + ": " + e.Err.Error()
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// JoinItemErrors not nil items errors, nil if there are no ones.
+// Convenient for errors collected by index in RunParallel
+func JoinItemErrors(errs ...*ItemError) error {
+	var items ItemErrors
+	for _, err := range errs {
+		if err != nil {
+			items = append(items, err)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return items
+}
+
+func (e ItemErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d failed events: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e ItemErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJoinItemErrors(t *testing.T) {
+	assert.NoError(t, JoinItemErrors())
+	assert.NoError(t, JoinItemErrors(nil, nil), "without errors")
+
+	notFound := errors.New("not found wrap")
+	err := JoinItemErrors(nil, ItemFailed(ItemDetail, "https://www.porto.pt/en/event/1", notFound))
+
+	var items ItemErrors
+	if assert.ErrorAs(t, err, &items) {
+		assert.Len(t, items, 1)
+	}
+	assert.ErrorIs(t, err, notFound)
+	assert.EqualError(t, err, "1 failed events: detail https://www.porto.pt/en/event/1: not found wrap")
+
+	var sourceErr *SourceError
+	assert.False(t, errors.As(err, &sourceErr), "items errors are not fatal")
+}
+
+func TestSourceError(t *testing.T) {
+	err := fmt.Errorf("collect: %w", SourceFailed("https://www.porto.pt", context.DeadlineExceeded))
+
+	var sourceErr *SourceError
+	if assert.ErrorAs(t, err, &sourceErr) {
+		assert.Equal(t, "https://www.porto.pt", sourceErr.Url)
+	}
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,90 @@
+package event
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultArchiveInterval between archiving jobs without own "interval" in the config
+const defaultArchiveInterval = 6 * time.Hour
+
+type (
+	// Archiver lifecycle job of ended events, run by the web server not more often than the interval
+	Archiver struct {
+		interval  time.Duration
+		retention time.Duration
+
+		mu   sync.Mutex
+		last time.Time // the last run
+	}
+
+	// ArchiveResult of one archiving job
+	ArchiveResult struct {
+		Archived []string // ids of archived events
+		Purged   int      // count of archived events removed after the retention period
+		Errors   []string
+	}
+)
+
+// ArchiveEnded move events ended at now from the working lists to the archive,
+// archived events older than the retention period are purged, 0 - kept forever
+func ArchiveEnded(s store.StoreInterface, now time.Time, retention time.Duration) ArchiveResult {
+	var (
+		res   ArchiveResult
+		ended []model.Event
+	)
+
+	for _, ev := range *s.Event().Get() {
+		if ev.Ended(now) {
+			ended = append(ended, ev)
+		}
+	}
+	sort.Slice(ended, func(i, j int) bool { return ended[i].ID < ended[j].ID })
+
+	for _, ev := range ended {
+		if !s.Archive().Add(model.ArchivedEvent{Event: ev, ArchivedAt: now}) || !s.Event().Delete(ev.ID) {
+			res.Errors = append(res.Errors, "event not archived: "+ev.ID)
+			continue
+		}
+		res.Archived = append(res.Archived, ev.ID)
+	}
+
+	if retention > 0 {
+		res.Purged = s.Archive().Purge(now.Add(-retention))
+	}
+
+	log.WithFields(log.Fields{"archived": len(res.Archived), "purged": res.Purged, "errors": len(res.Errors)}).
+		Infoln("archive ended events|")
+
+	return res
+}
+
+// NewArchiver of ended events by the config
+func NewArchiver(config model.ArchiveConfig) *Archiver {
+	a := &Archiver{
+		interval:  defaultArchiveInterval,
+		retention: time.Duration(config.Retention) * 24 * time.Hour,
+	}
+	if config.Interval > 0 {
+		a.interval = time.Duration(config.Interval) * time.Hour
+	}
+
+	return a
+}
+
+// RunDue archive ended events if the interval passed since the last run, false if it's not due
+func (a *Archiver) RunDue(s store.StoreInterface, now time.Time) (ArchiveResult, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.last.IsZero() && now.Sub(a.last) < a.interval {
+		return ArchiveResult{}, false
+	}
+	a.last = now
+
+	return ArchiveEnded(s, now, a.retention), true
+}
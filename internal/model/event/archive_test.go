@@ -0,0 +1,67 @@
+package event
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestArchiveEnded(t *testing.T) {
+	now := time.Date(2030, time.January, 10, 4, 0, 0, 0, model.Lisbon)
+	event := func(id string, start time.Time, end time.Time) *model.Event {
+		ev := &model.Event{ID: id, Title: "Event " + id}
+		if !start.IsZero() {
+			ev.SetDates(start, end, false)
+		}
+		return ev
+	}
+
+	s := teststore.New()
+	s.Event().Add(event("yesterday", now.AddDate(0, 0, -1), time.Time{}))
+	s.Event().Add(event("tonight", now.Add(15*time.Hour), time.Time{}))
+	s.Event().Add(event("exhibition", now.AddDate(0, -1, 0), now.AddDate(0, 1, 0)))
+	s.Event().Add(event("ended exhibition", now.AddDate(0, -2, 0), now.AddDate(0, 0, -3)))
+	s.Event().Add(event("without dates", time.Time{}, time.Time{}))
+	s.Archive().Add(model.ArchivedEvent{Event: *event("old", now.AddDate(-1, 0, -1), time.Time{}), ArchivedAt: now.AddDate(-1, 0, 0)})
+
+	res := ArchiveEnded(s, now, 180*24*time.Hour)
+
+	assert.Equal(t, []string{"ended exhibition", "yesterday"}, res.Archived)
+	assert.Equal(t, 1, res.Purged)
+	assert.Empty(t, res.Errors)
+
+	assert.Len(t, *s.Event().Get(), 3)
+	_, ok := s.Event().GetById("yesterday")
+	assert.False(t, ok, "out of the lists")
+
+	archived, ok := s.Archive().GetById("yesterday")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Event yesterday", archived.Event.Title)
+		assert.Equal(t, now, archived.ArchivedAt)
+	}
+	_, ok = s.Archive().GetById("old")
+	assert.False(t, ok, "purged after retention")
+
+	res = ArchiveEnded(s, now, 0)
+	assert.Empty(t, res.Archived)
+	assert.Len(t, s.Archive().List(), 2, "kept forever")
+}
+
+func TestArchiver_RunDue(t *testing.T) {
+	now := time.Date(2030, time.January, 10, 4, 0, 0, 0, model.Lisbon)
+	s := teststore.New()
+	a := NewArchiver(model.ArchiveConfig{Interval: 2})
+
+	_, ok := a.RunDue(s, now)
+	assert.True(t, ok, "the first run")
+
+	_, ok = a.RunDue(s, now.Add(time.Hour))
+	assert.False(t, ok, "not due")
+
+	_, ok = a.RunDue(s, now.Add(2*time.Hour))
+	assert.True(t, ok)
+
+	assert.Equal(t, defaultArchiveInterval, NewArchiver(model.ArchiveConfig{}).interval)
+}
@@ -0,0 +1,122 @@
+package event
+
+import (
+	"errors"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	"slices"
+	"strings"
+)
+
+// sourceFields values of model.ChangeFields of the event
+func sourceFields(ev model.Event) map[string]string {
+	dates := strings.TrimSpace(ev.DateText + " " + ev.Time)
+	if ev.Days != "" {
+		dates += " (" + ev.Days + ")"
+	}
+
+	return map[string]string{
+		"title":       ev.Title,
+		"description": ev.Description,
+		"image":       ev.Image,
+		"url":         ev.Url,
+		"place":       ev.Place,
+		"location":    ev.Location,
+		"dates":       dates,
+	}
+}
+
+// setField of the event to the value of the collected one
+func setField(ev *model.Event, collected model.Event, field string) {
+	switch field {
+	case "title":
+		ev.Title = collected.Title
+	case "description":
+		ev.Description = collected.Description
+	case "image":
+		ev.Image = collected.Image
+	case "url":
+		ev.Url = collected.Url
+	case "place":
+		ev.Place, ev.Venue = collected.Place, collected.Venue
+	case "location":
+		ev.Location, ev.LocationMap, ev.Coordinates = collected.Location, collected.LocationMap, collected.Coordinates
+	case "dates":
+		ev.Start, ev.End, ev.AllDay, ev.Recurrence = collected.Start, collected.End, collected.AllDay, collected.Recurrence
+		ev.DateText, ev.Time, ev.Days = collected.DateText, collected.Time, collected.Days
+	}
+}
+
+// detectChanges of the collected event in its source since the last collection of the stored one.
+// Changed fields not edited or locked by an editor get the new values. Changes are kept on the event until the editor
+// reviews them: the unpublished event goes back to new events, the published one stays flagged by its changes.
+// Events stored before changes detection get the values of the source without changes, blocked events are not changed
+func detectChanges(stored model.Event, collected model.Event) (model.Event, []model.EventChange) {
+	fields := sourceFields(collected)
+	switch {
+	case stored.Category == store.CategoryBlocked:
+		return stored, nil
+	case stored.Collected == nil:
+		stored.Collected = fields
+		return stored, nil
+	}
+
+	var changes []model.EventChange
+	current := sourceFields(stored)
+	for _, field := range model.ChangeFields {
+		old := stored.Collected[field]
+		if old == fields[field] {
+			continue
+		}
+		changes = append(changes, model.EventChange{Field: field, Old: old, New: fields[field]})
+		if current[field] == old && !stored.Edited(field) && !stored.Locked(field) {
+			setField(&stored, collected, field)
+			withProvenance(&stored, collected, field)
+		}
+	}
+	if len(changes) == 0 {
+		return stored, nil
+	}
+
+	stored.Collected = fields
+	stored.Changes = withChanges(stored.Changes, changes)
+	if stored.Category == store.CategoryPublish {
+		stored.Category = store.CategoryNew
+	}
+
+	return stored, changes
+}
+
+// withChanges not reviewed changes with new ones: the first old value of the field is kept,
+// the field changed back to it has no changes
+func withChanges(pending []model.EventChange, changes []model.EventChange) []model.EventChange {
+	list := slices.Clone(pending)
+	for _, c := range changes {
+		i := slices.IndexFunc(list, func(p model.EventChange) bool { return p.Field == c.Field })
+		switch {
+		case i < 0:
+			list = append(list, c)
+		case list[i].Old == c.New:
+			list = slices.Delete(list, i, i+1)
+		default:
+			list[i].New = c.New
+		}
+	}
+
+	return list
+}
+
+// ReviewChanges of the stored event are seen by an editor, the event is not flagged anymore
+func ReviewChanges(s store.StoreInterface, id string) error {
+	ev, ok := s.Event().GetById(id)
+	if !ok {
+		return errors.New("not found event " + id)
+	}
+
+	ev.Changes = nil
+	if !s.Event().Save(ev) {
+		return errors.New("failed save event " + ev.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package event
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_detectChanges(t *testing.T) {
+	collected := model.Event{ID: "porto:36013", Title: "Orfélia", Place: "Maus Hábitos", Description: "Concerto"}
+	collected.SetDates(time.Date(2030, time.January, 6, 21, 0, 0, 0, model.Lisbon), time.Time{}, false)
+
+	stored := collected
+	stored.Collected = sourceFields(collected)
+
+	changed := collected
+	changed.Place = "Casa da Música"
+	changed.SetDates(time.Date(2030, time.January, 6, 22, 0, 0, 0, model.Lisbon), time.Time{}, false)
+
+	t.Run("without changes", func(t *testing.T) {
+		ev, changes := detectChanges(stored, collected)
+		assert.Nil(t, changes)
+		assert.Equal(t, stored, ev)
+	})
+
+	t.Run("stored before changes detection", func(t *testing.T) {
+		old := changed
+		old.Collected = nil
+
+		ev, changes := detectChanges(old, collected)
+		assert.Nil(t, changes)
+		assert.Equal(t, sourceFields(collected), ev.Collected)
+		assert.Equal(t, "Casa da Música", ev.Place, "values of the source are not known")
+	})
+
+	t.Run("changed, back to review", func(t *testing.T) {
+		ev := stored
+		ev.Category = store.CategoryPublish
+
+		ev, changes := detectChanges(ev, changed)
+		assert.Equal(t, []model.EventChange{
+			{Field: "place", Old: "Maus Hábitos", New: "Casa da Música"},
+			{Field: "dates", Old: "06 Jan 2030 21:00", New: "06 Jan 2030 22:00"},
+		}, changes)
+		assert.Equal(t, changes, ev.Changes)
+		assert.Equal(t, "Casa da Música", ev.Place)
+		assert.True(t, changed.Start.Equal(ev.Start))
+		assert.Equal(t, uint8(store.CategoryNew), ev.Category)
+		assert.Equal(t, sourceFields(changed), ev.Collected)
+	})
+
+	t.Run("edited by editor", func(t *testing.T) {
+		ev := stored
+		ev.Place = "Maus Hábitos - 4º piso"
+
+		ev, changes := detectChanges(ev, changed)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "Maus Hábitos - 4º piso", ev.Place, "edited value is kept")
+		assert.True(t, changed.Start.Equal(ev.Start))
+	})
+
+	t.Run("locked and edited", func(t *testing.T) {
+		ev := stored
+		ev.Provenance = map[string]model.FieldProvenance{
+			"place": {Source: "porto", Locked: true},
+			"dates": {Source: "porto", EditedBy: "ana"},
+		}
+
+		ev, changes := detectChanges(ev, changed)
+		assert.Len(t, changes, 2, "changes are shown")
+		assert.Equal(t, "Maus Hábitos", ev.Place, "locked")
+		assert.True(t, collected.Start.Equal(ev.Start), "edited")
+	})
+
+	t.Run("provenance of the new values", func(t *testing.T) {
+		fetched := time.Date(2030, time.January, 5, 10, 0, 0, 0, time.UTC)
+		source := changed
+		source.Provenance = collectedProvenance(changed, fetched)
+
+		ev, _ := detectChanges(stored, source)
+		assert.Equal(t, fetched, ev.Provenance["place"].FetchedAt)
+		assert.Empty(t, ev.Provenance["title"], "not changed")
+	})
+
+	t.Run("published", func(t *testing.T) {
+		ev := stored
+		ev.Category = store.CategoryPublished
+
+		ev, changes := detectChanges(ev, changed)
+		assert.Len(t, changes, 2)
+		assert.Len(t, ev.Changes, 2)
+		assert.Equal(t, uint8(store.CategoryPublished), ev.Category, "flagged by changes")
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		ev := stored
+		ev.Category = store.CategoryBlocked
+
+		ev, changes := detectChanges(ev, changed)
+		assert.Nil(t, changes)
+		assert.Equal(t, "Maus Hábitos", ev.Place)
+	})
+
+	t.Run("changed back before review", func(t *testing.T) {
+		ev, _ := detectChanges(stored, changed)
+		ev, changes := detectChanges(ev, collected)
+
+		assert.Len(t, changes, 2)
+		assert.Empty(t, ev.Changes)
+		assert.Equal(t, "Maus Hábitos", ev.Place)
+	})
+}
+
+func Test_withChanges(t *testing.T) {
+	pending := []model.EventChange{{Field: "title", Old: "a", New: "b"}, {Field: "place", Old: "x", New: "y"}}
+
+	list := withChanges(pending, []model.EventChange{{Field: "title", Old: "b", New: "c"}, {Field: "image", Old: "", New: "i.jpg"}})
+
+	assert.Equal(t, []model.EventChange{
+		{Field: "title", Old: "a", New: "c"},
+		{Field: "place", Old: "x", New: "y"},
+		{Field: "image", Old: "", New: "i.jpg"},
+	}, list)
+	assert.Equal(t, "b", pending[0].New, "pending changes are not modified")
+}
+
+func TestCollectAndStore_changes(t *testing.T) {
+	s := teststore.New()
+	sources := []model.Source{
+		{Name: "fakecollect", Url: "https://agenda.example.com", Options: map[string]string{"ids": "ab"}},
+	}
+	CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+
+	ev, _ := s.Event().GetById("fakecollect:a")
+	ev.Title, ev.Collected["title"], ev.Category = "Event a - old", "Event a - old", store.CategoryPublish
+	s.Event().Save(ev)
+
+	run := CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+
+	assert.Empty(t, run.Added)
+	assert.Equal(t, []string{"fakecollect:a"}, run.Updated)
+	assert.Equal(t, []string{"fakecollect:b"}, run.Skipped)
+
+	ev, _ = s.Event().GetById("fakecollect:a")
+	assert.Equal(t, "Event a", ev.Title)
+	assert.Equal(t, uint8(store.CategoryNew), ev.Category)
+	assert.Equal(t, []model.EventChange{{Field: "title", Old: "Event a - old", New: "Event a"}}, ev.Changes)
+
+	assert.NoError(t, ReviewChanges(s, ev.ID))
+	ev, _ = s.Event().GetById("fakecollect:a")
+	assert.Empty(t, ev.Changes)
+	assert.Error(t, ReviewChanges(s, "fakecollect:x"))
+}
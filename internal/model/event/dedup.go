@@ -0,0 +1,405 @@
+package event
+
+import (
+	"errors"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	log "github.com/sirupsen/logrus"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	// mergeScore min score of duplicates merged automatically
+	mergeScore = 0.85
+
+	// possibleScore min score of possible duplicates, an editor confirms or splits them
+	possibleScore = 0.65
+)
+
+type (
+	// priorities of sources fields by source url, see model.Source.Priority
+	priorities map[string]map[string]int
+
+	// dedupResult new events without duplicates
+	dedupResult struct {
+		events  []model.Event // new events, duplicates of each other are merged, possible duplicates are flagged
+		updated []model.Event // stored events with merged new duplicates
+		merged  []string      // ids of new events merged into other events
+	}
+)
+
+var stopWords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "de": true, "da": true, "do": true, "das": true, "dos": true,
+	"e": true, "em": true, "no": true, "na": true, "nos": true, "nas": true, "ao": true, "com": true, "por": true,
+	"para": true, "um": true, "uma": true,
+	"the": true, "of": true, "and": true, "in": true, "at": true, "on": true, "with": true, "for": true, "to": true,
+}
+
+func newPriorities(sources []model.Source) priorities {
+	p := make(priorities, len(sources))
+	for _, src := range sources {
+		p[src.Url] = src.Priority
+	}
+
+	return p
+}
+
+// of the field of the source, "default" priority of the source for not listed fields
+func (p priorities) of(sourceUrl string, field string) int {
+	if v, ok := p[sourceUrl][field]; ok {
+		return v
+	}
+
+	return p[sourceUrl]["default"]
+}
+
+// dedup new events: duplicates from other sources are merged, with each other and into stored events,
+// uncertain ones are flagged as possible duplicates
+func dedup(events []model.Event, stored map[string]model.Event, p priorities) dedupResult {
+	var res dedupResult
+
+	old := make([]model.Event, 0, len(stored))
+	for _, ev := range stored {
+		old = append(old, ev)
+	}
+	slices.SortFunc(old, func(a, b model.Event) int { return strings.Compare(a.ID, b.ID) }) // the same result every time
+	updated := make(map[int]bool)
+
+	for _, ev := range events {
+		kept, keptScore := bestDuplicate(ev, res.events)
+		oldI, oldScore := bestDuplicate(ev, old)
+
+		switch {
+		case keptScore >= mergeScore && keptScore >= oldScore:
+			res.events[kept] = mergeNew(res.events[kept], ev, p)
+			res.merged = append(res.merged, ev.ID)
+			continue
+		case oldScore >= mergeScore:
+			old[oldI], updated[oldI] = mergeStored(old[oldI], ev), true
+			res.merged = append(res.merged, ev.ID)
+			continue
+		case keptScore >= possibleScore && keptScore >= oldScore:
+			ev.PossibleDuplicate = res.events[kept].ID
+		case oldScore >= possibleScore:
+			ev.PossibleDuplicate = old[oldI].ID
+		}
+
+		res.events = append(res.events, ev)
+	}
+
+	for i := range updated {
+		res.updated = append(res.updated, old[i])
+	}
+
+	return res
+}
+
+// bestDuplicate index of the event of the list most likely duplicated by ev, -1 if none
+func bestDuplicate(ev model.Event, list []model.Event) (int, float64) {
+	best, bestScore := -1, 0.0
+	for i, other := range list {
+		if score := duplicateScore(ev, other); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return best, bestScore
+}
+
+// duplicateScore probability of two events from different sources to be the same event, from 0 to 1:
+// similar titles, overlapping dates and the same venue. Events without dates are never duplicates
+func duplicateScore(a model.Event, b model.Event) float64 {
+	switch {
+	case a.Start.IsZero() || b.Start.IsZero():
+		return 0
+	case a.Source != "" && a.Source == b.Source: // different events of the same source
+		return 0
+	case slices.Contains(a.NotDuplicates, b.ID) || slices.Contains(b.NotDuplicates, a.ID):
+		return 0
+	case !a.Start.Before(b.EndTime()) || !b.Start.Before(a.EndTime()):
+		return 0
+	}
+
+	dates := 0.5 // overlapping
+	switch {
+	case a.Start.Equal(b.Start):
+		dates = 1
+	case model.LisbonDate(a.Start.In(model.Lisbon)).Equal(model.LisbonDate(b.Start.In(model.Lisbon))):
+		dates = 0.8
+	}
+
+	venue := 0.5 // unknown
+	if a.Place != "" && b.Place != "" {
+		venue = similarity(a.Place, b.Place)
+	}
+
+	score := 0.6*similarity(a.Title, b.Title) + 0.25*dates + 0.15*venue
+	if venue < 0.3 && score >= mergeScore {
+		score = mergeScore - 0.01 // different venues, only an editor merges them
+	}
+
+	return score
+}
+
+// similarity of two names, from 0 to 1: bigrams of letters, or words of the shorter name in the longer one
+func similarity(a string, b string) float64 {
+	wordsA, wordsB := words(a), words(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	common := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			common++
+		}
+	}
+	containment := float64(common) / float64(min(len(wordsA), len(wordsB)))
+	if min(len(wordsA), len(wordsB)) == 1 {
+		containment *= 0.8 // one word names are less certain
+	}
+
+	return max(containment, dice(bigrams(a), bigrams(b)))
+}
+
+// words of the name without accents and stop words
+func words(name string) map[string]bool {
+	list := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(normalize(name), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if !stopWords[w] {
+			list[w] = true
+		}
+	}
+
+	return list
+}
+
+// bigrams of letters and digits of the name
+func bigrams(name string) map[string]int {
+	var r []rune
+	for _, c := range normalize(name) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			r = append(r, c)
+		}
+	}
+
+	list := make(map[string]int)
+	for i := 0; i+1 < len(r); i++ {
+		list[string(r[i:i+2])]++
+	}
+
+	return list
+}
+
+// dice coefficient of bigrams
+func dice(a map[string]int, b map[string]int) float64 {
+	total, common := 0, 0
+	for g, n := range a {
+		total += n
+		common += min(n, b[g])
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return 2 * float64(common) / float64(total)
+}
+
+func normalize(name string) string {
+	return model.Unaccent.Replace(strings.ToLower(name))
+}
+
+// mergeNew duplicate into the new event: every field from the source with higher priority, or the not empty one,
+// fields locked by an editor win. The event of the source with higher "default" priority keeps its id
+func mergeNew(ev model.Event, dup model.Event, p priorities) model.Event {
+	if p.of(dup.Source, "default") > p.of(ev.Source, "default") {
+		ev, dup = dup, ev
+	}
+
+	// take the field of dup, locked fields are kept
+	take := func(field string, evEmpty bool, dupEmpty bool) bool {
+		switch {
+		case ev.Locked(field):
+			return false
+		case dup.Locked(field):
+			return true
+		case dupEmpty:
+			return false
+		case evEmpty:
+			return true
+		}
+		return p.of(dup.Source, field) > p.of(ev.Source, field)
+	}
+
+	if take("title", ev.Title == "", dup.Title == "") {
+		ev.Title = dup.Title
+		withProvenance(&ev, dup, "title")
+	}
+	if take("description", ev.Description == "", dup.Description == "") {
+		ev.Description = dup.Description
+		withProvenance(&ev, dup, "description")
+	}
+	if take("image", ev.Image == "", dup.Image == "") {
+		ev.Image = dup.Image
+		withProvenance(&ev, dup, "image")
+	}
+	if take("url", ev.Url == "", dup.Url == "") {
+		ev.Url = dup.Url
+		withProvenance(&ev, dup, "url")
+	}
+	if take("place", ev.Place == "", dup.Place == "") {
+		ev.Place, ev.Location, ev.LocationMap = dup.Place, dup.Location, dup.LocationMap
+		ev.Coordinates, ev.Venue = dup.Coordinates, dup.Venue
+		withProvenance(&ev, dup, "place", "location")
+	}
+	if take("dates", ev.Start.IsZero(), dup.Start.IsZero()) {
+		ev.Start, ev.End, ev.AllDay, ev.Recurrence = dup.Start, dup.End, dup.AllDay, dup.Recurrence
+		ev.DateText, ev.Time, ev.Days = dup.DateText, dup.Time, dup.Days
+		withProvenance(&ev, dup, "dates")
+	}
+
+	return withMerged(ev, dup)
+}
+
+// mergeStored new duplicate into the stored event, which may be edited or published:
+// only its empty not locked fields are filled
+func mergeStored(ev model.Event, dup model.Event) model.Event {
+	if ev.Description == "" && !ev.Locked("description") {
+		ev.Description = dup.Description
+		withProvenance(&ev, dup, "description")
+	}
+	if ev.Image == "" && !ev.Locked("image") {
+		ev.Image = dup.Image
+		withProvenance(&ev, dup, "image")
+	}
+	if ev.Place == "" && !ev.Locked("place") {
+		ev.Place, ev.Location, ev.LocationMap = dup.Place, dup.Location, dup.LocationMap
+		ev.Coordinates, ev.Venue = dup.Coordinates, dup.Venue
+		withProvenance(&ev, dup, "place", "location")
+	}
+
+	return withMerged(ev, dup)
+}
+
+// withMerged ids of dup in the merged ids of ev
+func withMerged(ev model.Event, dup model.Event) model.Event {
+	ev.Merged = append(append(slices.Clone(ev.Merged), dup.ID), dup.Merged...)
+	for _, id := range dup.NotDuplicates {
+		if !slices.Contains(ev.NotDuplicates, id) {
+			ev.NotDuplicates = append(ev.NotDuplicates, id)
+		}
+	}
+	if ev.PossibleDuplicate == dup.ID {
+		ev.PossibleDuplicate = ""
+	}
+
+	return ev
+}
+
+// mergedIds ids of events merged into the stored events: id of the merged event -> id of the stored one
+func mergedIds(stored map[string]model.Event) map[string]string {
+	ids := make(map[string]string)
+	for id, ev := range stored {
+		for _, merged := range ev.Merged {
+			ids[merged] = id
+		}
+	}
+
+	return ids
+}
+
+// ConfirmDuplicate merge the stored event into the event it possibly duplicates, by priorities of the sources.
+// The merged event is deleted, references of other events to it are moved to the kept one
+func ConfirmDuplicate(s store.StoreInterface, sources []model.Source, id string) (*model.Event, error) {
+	ev, ok := s.Event().GetById(id)
+	if !ok || ev.PossibleDuplicate == "" {
+		return nil, errors.New("not found possible duplicate event " + id)
+	}
+	target, ok := s.Event().GetById(ev.PossibleDuplicate)
+	if !ok {
+		return nil, errors.New("not found duplicated event " + ev.PossibleDuplicate)
+	}
+
+	merged := mergeNew(*target, *ev, newPriorities(sources))
+	merged.Category = target.Category
+	drop := ev.ID
+	if merged.ID == ev.ID { // the possible duplicate has higher priority
+		drop = target.ID
+	}
+
+	if !s.Event().Save(&merged) || !s.Event().Delete(drop) {
+		return nil, errors.New("failed save merged event " + merged.ID)
+	}
+	log.Infoln("duplicate merged|", drop, "into", merged.ID)
+
+	if err := moveDuplicateRefs(s, drop, merged); err != nil {
+		return nil, err
+	}
+
+	return &merged, nil
+}
+
+// moveDuplicateRefs of stored events to the deleted event to the event it's merged into:
+// the possible duplicate of it, split from the kept event earlier, is not flagged anymore
+func moveDuplicateRefs(s store.StoreInterface, drop string, kept model.Event) error {
+	for _, ev := range *s.Event().Get() {
+		if ev.ID == kept.ID || (ev.PossibleDuplicate != drop && !slices.Contains(ev.NotDuplicates, drop)) {
+			continue
+		}
+
+		if ev.PossibleDuplicate == drop {
+			ev.PossibleDuplicate = kept.ID
+			if slices.Contains(kept.NotDuplicates, ev.ID) || slices.Contains(ev.NotDuplicates, kept.ID) {
+				ev.PossibleDuplicate = ""
+			}
+		}
+
+		var notDuplicates []string
+		for _, id := range ev.NotDuplicates {
+			if id == drop {
+				id = kept.ID
+			}
+			if !slices.Contains(notDuplicates, id) {
+				notDuplicates = append(notDuplicates, id)
+			}
+		}
+		ev.NotDuplicates = notDuplicates
+
+		if !s.Event().Save(&ev) {
+			return errors.New("failed save event " + ev.ID)
+		}
+	}
+
+	return nil
+}
+
+// SplitDuplicate the stored event is not a duplicate of the flagged one, they are never flagged again
+func SplitDuplicate(s store.StoreInterface, id string) error {
+	ev, ok := s.Event().GetById(id)
+	if !ok || ev.PossibleDuplicate == "" {
+		return errors.New("not found possible duplicate event " + id)
+	}
+
+	if other, ok := s.Event().GetById(ev.PossibleDuplicate); ok {
+		other.NotDuplicates = append(other.NotDuplicates, ev.ID)
+		if other.PossibleDuplicate == ev.ID {
+			other.PossibleDuplicate = ""
+		}
+		if !s.Event().Save(other) {
+			return errors.New("failed save event " + other.ID)
+		}
+	}
+
+	ev.NotDuplicates = append(ev.NotDuplicates, ev.PossibleDuplicate)
+	ev.PossibleDuplicate = ""
+	if !s.Event().Save(ev) {
+		return errors.New("failed save event " + ev.ID)
+	}
+
+	return nil
+}
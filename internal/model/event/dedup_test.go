@@ -0,0 +1,268 @@
+package event
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const (
+	portoUrl  = "https://www.porto.pt/api/graphql"
+	agendaUrl = "https://agendaculturalporto.org/agenda-maus-habitos-porto"
+	teatroUrl = "https://www.teatromunicipaldoporto.pt/en/programa/"
+)
+
+// concert of the same evening on porto.pt and agendaculturalporto
+func concerts() (model.Event, model.Event) {
+	start := time.Date(2024, time.January, 6, 21, 0, 0, 0, model.Lisbon)
+
+	porto := model.Event{
+		ID:     "36013",
+		Title:  "Orfélia",
+		Image:  "https://www.porto.pt/orfelia.jpg",
+		Place:  "Maus Hábitos",
+		Source: portoUrl,
+	}
+	porto.SetDates(start, time.Time{}, false)
+
+	agenda := model.Event{
+		ID:          "https://agendaculturalporto.org/orfelia-em-estreia-ao-vivo-no-maus-habitos",
+		Title:       "Orfélia em estreia ao vivo no Maus Hábitos",
+		Description: "Orfélia é um projeto que conta com Antera na voz e sintetizadores",
+		Image:       "https://agendaculturalporto.org/Orfelia-150x150.jpg",
+		Place:       "Maus Hábitos - Espaço de Intervenção Cultural",
+		Location:    "R. de Passos Manuel 178 4º Piso, 4000-382 Porto",
+		Source:      agendaUrl,
+	}
+	agenda.SetDates(start, start.Add(150*time.Minute), false)
+
+	return porto, agenda
+}
+
+func Test_duplicateScore(t *testing.T) {
+	porto, agenda := concerts()
+
+	otherDay := agenda
+	otherDay.SetDates(agenda.Start.AddDate(0, 0, 7), time.Time{}, false)
+
+	otherVenue := agenda
+	otherVenue.Title, otherVenue.Place = "Orfélia", "Casa da Música"
+
+	sameSource := agenda
+	sameSource.Source = portoUrl
+
+	notDuplicate := agenda
+	notDuplicate.NotDuplicates = []string{porto.ID}
+
+	withoutDates := agenda
+	withoutDates.Start, withoutDates.End = time.Time{}, time.Time{}
+
+	similarTitle := agenda
+	similarTitle.Title, similarTitle.Place = "Orfelia: concerto de estreia", ""
+	similarTitle.SetDates(agenda.Start.Add(30*time.Minute), time.Time{}, false)
+
+	tests := []struct {
+		name  string
+		other model.Event
+		min   float64
+		max   float64
+	}{
+		{name: "the same concert", other: agenda, min: mergeScore, max: 1},
+		{name: "similar title, the same day, unknown venue", other: similarTitle, min: possibleScore, max: mergeScore},
+		{name: "other venue", other: otherVenue, min: possibleScore, max: mergeScore},
+		{name: "other day", other: otherDay},
+		{name: "the same source", other: sameSource},
+		{name: "split by editor", other: notDuplicate},
+		{name: "without dates", other: withoutDates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := duplicateScore(porto, tt.other)
+			assert.Equal(t, score, duplicateScore(tt.other, porto), "symmetric")
+			assert.GreaterOrEqual(t, score, tt.min)
+			if tt.max > 0 {
+				assert.Less(t, score, tt.max)
+			} else {
+				assert.Zero(t, score)
+			}
+		})
+	}
+}
+
+func Test_similarity(t *testing.T) {
+	assert.Equal(t, 1.0, similarity("Orfélia em Estreia!", "orfelia  em estreia"))
+	assert.Greater(t, similarity("Concerto de Ano Novo", "Concerto Ano Novo 2024"), 0.9)
+	assert.Less(t, similarity("Hamlet", "O Lago dos Cisnes"), 0.3)
+	assert.Zero(t, similarity("", "Hamlet"))
+}
+
+func Test_dedup(t *testing.T) {
+	porto, agenda := concerts()
+	p := priorities{
+		portoUrl:  {"default": 1, "image": 2},
+		agendaUrl: {"description": 1, "place": 2},
+	}
+
+	t.Run("merge by fields priority", func(t *testing.T) {
+		res := dedup([]model.Event{agenda, porto}, nil, p)
+
+		assert.Equal(t, []string{porto.ID}, res.merged)
+		if assert.Len(t, res.events, 1) {
+			ev := res.events[0]
+			assert.Equal(t, porto.ID, ev.ID, "id of the source with higher default priority")
+			assert.Equal(t, porto.Title, ev.Title)
+			assert.Equal(t, porto.Image, ev.Image)
+			assert.Equal(t, agenda.Description, ev.Description, "empty field is filled")
+			assert.Equal(t, agenda.Place, ev.Place)
+			assert.Equal(t, agenda.Location, ev.Location)
+			assert.True(t, porto.Start.Equal(ev.Start))
+			assert.True(t, ev.End.IsZero(), "dates of porto")
+			assert.Equal(t, []string{agenda.ID}, ev.Merged)
+		}
+	})
+
+	t.Run("locked fields", func(t *testing.T) {
+		locked := agenda
+		locked.Provenance = map[string]model.FieldProvenance{"title": {Source: "agendaculturalporto", Locked: true}}
+		porto := porto
+		porto.Provenance = map[string]model.FieldProvenance{"image": {Source: "porto"}}
+
+		res := dedup([]model.Event{locked, porto}, nil, p)
+
+		if assert.Len(t, res.events, 1) {
+			ev := res.events[0]
+			assert.Equal(t, agenda.Title, ev.Title, "locked field wins")
+			assert.True(t, ev.Locked("title"))
+			assert.Equal(t, porto.Image, ev.Image)
+			assert.Equal(t, "porto", ev.Provenance["image"].Source)
+		}
+	})
+
+	t.Run("merge into stored event", func(t *testing.T) {
+		stored := porto
+		stored.Image, stored.Category = "https://www.porto.pt/orfelia-edited.jpg", 1
+
+		res := dedup([]model.Event{agenda}, map[string]model.Event{stored.ID: stored}, p)
+
+		assert.Empty(t, res.events)
+		assert.Equal(t, []string{agenda.ID}, res.merged)
+		if assert.Len(t, res.updated, 1) {
+			ev := res.updated[0]
+			assert.Equal(t, "https://www.porto.pt/orfelia-edited.jpg", ev.Image, "stored fields are kept")
+			assert.Equal(t, porto.Title, ev.Title)
+			assert.Equal(t, porto.Place, ev.Place)
+			assert.Equal(t, agenda.Description, ev.Description, "empty fields are filled")
+			assert.Equal(t, uint8(1), ev.Category)
+			assert.Equal(t, []string{agenda.ID}, ev.Merged)
+		}
+	})
+
+	t.Run("possible duplicate", func(t *testing.T) {
+		other := agenda
+		other.Title, other.Place = "Orfélia", "Casa da Música"
+
+		res := dedup([]model.Event{porto, other}, nil, p)
+
+		assert.Empty(t, res.merged)
+		if assert.Len(t, res.events, 2) {
+			assert.Empty(t, res.events[0].PossibleDuplicate)
+			assert.Equal(t, porto.ID, res.events[1].PossibleDuplicate)
+		}
+	})
+
+	t.Run("different events", func(t *testing.T) {
+		other := agenda
+		other.ID, other.Title, other.Source = "hamlet", "Hamlet", teatroUrl
+
+		res := dedup([]model.Event{porto, agenda, other}, nil, p)
+
+		assert.Len(t, res.events, 2)
+		assert.Empty(t, res.events[1].PossibleDuplicate)
+	})
+}
+
+func TestConfirmDuplicate(t *testing.T) {
+	porto, agenda := concerts()
+	agenda.Title, agenda.Place, agenda.PossibleDuplicate = "Orfélia", "Casa da Música", porto.ID
+	porto.Category = 1
+
+	s := teststore.New()
+	s.Event().Add(&porto)
+	s.Event().Add(&agenda)
+	sources := []model.Source{
+		{Url: portoUrl, Priority: map[string]int{"default": 1}},
+		{Url: agendaUrl, Priority: map[string]int{"description": 1}},
+	}
+
+	merged, err := ConfirmDuplicate(s, sources, agenda.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, porto.ID, merged.ID)
+	assert.Equal(t, agenda.Description, merged.Description)
+	assert.Equal(t, uint8(1), merged.Category)
+	assert.Len(t, *s.Event().Get(), 1)
+	stored, _ := s.Event().GetById(porto.ID)
+	assert.Equal(t, []string{agenda.ID}, stored.Merged)
+
+	_, err = ConfirmDuplicate(s, sources, porto.ID)
+	assert.Error(t, err, "not flagged")
+}
+
+func TestConfirmDuplicate_references(t *testing.T) {
+	porto, agenda := concerts()
+	agenda.PossibleDuplicate = porto.ID
+
+	teatro := porto
+	teatro.ID, teatro.Source, teatro.PossibleDuplicate = "teatromunicipaldoporto:orfelia", teatroUrl, porto.ID
+	split := porto
+	split.ID, split.Source, split.NotDuplicates = "feed:orfelia", "https://feed.example.com", []string{porto.ID}
+
+	s := teststore.New()
+	for _, ev := range []model.Event{porto, agenda, teatro, split} {
+		s.Event().Add(&ev)
+	}
+	sources := []model.Source{
+		{Url: portoUrl},
+		{Url: agendaUrl, Priority: map[string]int{"default": 1}},
+	}
+
+	merged, err := ConfirmDuplicate(s, sources, agenda.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, agenda.ID, merged.ID, "the possible duplicate of higher priority is kept")
+	_, ok := s.Event().GetById(porto.ID)
+	assert.False(t, ok, "deleted")
+
+	ev, _ := s.Event().GetById(teatro.ID)
+	assert.Equal(t, agenda.ID, ev.PossibleDuplicate, "flagged as duplicate of the kept event")
+	ev, _ = s.Event().GetById(split.ID)
+	assert.Equal(t, []string{agenda.ID}, ev.NotDuplicates, "split from the kept event")
+
+	merged, err = ConfirmDuplicate(s, sources, teatro.ID)
+	if assert.NoError(t, err, "the duplicate of the deleted event is merged") {
+		assert.Equal(t, agenda.ID, merged.ID)
+	}
+}
+
+func TestSplitDuplicate(t *testing.T) {
+	porto, agenda := concerts()
+	agenda.PossibleDuplicate = porto.ID
+
+	s := teststore.New()
+	s.Event().Add(&porto)
+	s.Event().Add(&agenda)
+
+	assert.NoError(t, SplitDuplicate(s, agenda.ID))
+
+	ev, _ := s.Event().GetById(agenda.ID)
+	assert.Empty(t, ev.PossibleDuplicate)
+	assert.Equal(t, []string{porto.ID}, ev.NotDuplicates)
+	other, _ := s.Event().GetById(porto.ID)
+	assert.Equal(t, []string{agenda.ID}, other.NotDuplicates)
+	assert.Zero(t, duplicateScore(*ev, *other))
+
+	assert.Error(t, SplitDuplicate(s, agenda.ID), "not flagged")
+}
@@ -1,70 +1,239 @@
 package event
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/oleksiy-os/porto-events/internal/model"
-	"github.com/oleksiy-os/porto-events/internal/model/sourceList/agendaCulturalPorto"
-	"github.com/oleksiy-os/porto-events/internal/model/sourceList/porto"
-	"github.com/oleksiy-os/porto-events/internal/model/sourceList/testing"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	"github.com/oleksiy-os/porto-events/internal/store"
 	log "github.com/sirupsen/logrus"
+	"maps"
 	"net/url"
+	"runtime/debug"
+	"slices"
 	"sort"
+	"time"
+
+	// sources register themselves in the model sources registry
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/agendaCulturalPorto"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/feed"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/ical"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/jsonLd"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/porto"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/selector"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/teatroMunicipalDoPorto"
+	_ "github.com/oleksiy-os/porto-events/internal/model/sourceList/testing"
 )
 
-type (
-	sourceInterface interface {
-		LoadEvents(url *url.URL) []model.Event
+// LoadSources read sources list from config file and validate it against the sources registry
+func LoadSources(confPath string) ([]model.Source, error) {
+	sources, err := model.GetSources(confPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = model.ValidateSources(sources); err != nil {
+		return nil, err
 	}
+
+	return sources, nil
+}
+
+const (
+	// sourceWorkers max sources collected in parallel
+	sourceWorkers = 4
+
+	// defaultSourceTimeout used when source has no own "timeout" in the sources list file
+	defaultSourceTimeout = 60 * time.Second
 )
 
-// Collect (web scrap OR get from API) events from sources
-func Collect(sources []model.Source) *[]model.Event {
+// Collect (web scrap OR get from API) events from sources, with statistics of every source run
+//
+// Sources are collected in parallel, each one limited by own timeout. Cancel ctx to stop the whole collection
+func Collect(ctx context.Context, sources []model.Source) (*[]model.Event, []model.SourceRun) {
 	var (
-		events           []model.Event
 		eventsCollection []model.Event
-		src              sourceInterface
+		sourcesEvents    = make([][]model.Event, len(sources))
+		runs             = make([]model.SourceRun, len(sources))
 	)
 
-	for _, item := range sources {
-		log.Debugln("getting events from source:", item.Name, item.Url)
+	fetcher.SetSources(sources) // limits of hosts by the current sources config
+	model.RunParallel(ctx, sourceWorkers, len(sources), func(ctx context.Context, i int) {
+		stats := model.NewRunStats(sources[i])
+		events, err := collectSource(model.WithRunStats(ctx, stats), sources[i])
+		for j := range events {
+			events[j].Source = sources[i].Url
+			events[j].SetSourceId(sources[i].Name, events[j].ID)
+		}
+		sourcesEvents[i], runs[i] = events, stats.Finish(len(events), err)
+	})
+
+	for _, events := range sourcesEvents {
+		eventsCollection = append(eventsCollection, events...)
+	}
 
-		u, err := url.ParseRequestURI(item.Url)
-		if err != nil {
-			log.Error("wrong source url", item)
+	sort.SliceStable(eventsCollection, func(i, j int) bool {
+		return eventsCollection[i].Before(eventsCollection[j]) // sort by dates ASC
+	})
+
+	log.Debugln("Events col", len(eventsCollection))
+
+	return &eventsCollection, runs
+}
+
+// CollectAndStore collect events from sources and add new ones to the store.
+// Stored events changed in their sources are updated with the changes for review, see detectChanges.
+// Ended events are not added, they are archived, see ArchiveEnded.
+// Places of events matched to known venues get the venue names, see matchVenue, events without coordinates
+// are geocoded by the local gazetteer, see Geocode.
+// Duplicates from other sources are merged, with each other and into stored events, possible ones are flagged.
+// Sources runs and the collection run with added, updated, skipped and merged events are saved to the store too
+func CollectAndStore(ctx context.Context, s store.StoreInterface, sources []model.Source, trigger string) model.CollectionRun {
+	run := model.NewCollectionRun(trigger)
+
+	events, sourceRuns := Collect(ctx, sources)
+
+	stored := *s.Event().Get()
+	merged := mergedIds(stored)
+	venues, geo := s.Venue().List(), newGeocoder(s)
+	var newEvents []model.Event
+	for _, e := range *events {
+		e = withCoordinates(withVenue(e, venues), geo)
+		e.Collected, e.Provenance = sourceFields(e), collectedProvenance(e, run.Started)
+
+		if old, exists := s.Event().GetById(e.ID); exists {
+			ev, changes := detectChanges(*old, e)
+			if !maps.Equal(old.Collected, ev.Collected) && !s.Event().Save(&ev) {
+				run.Errors = append(run.Errors, "event not saved: "+e.ID)
+				continue
+			}
+			if changes != nil {
+				log.WithField("changes", changes).Infoln("event changed in the source|", e.ID)
+				run.Updated = append(run.Updated, e.ID)
+				continue
+			}
+			run.Skipped = append(run.Skipped, e.ID)
 			continue
 		}
 
-		switch item.Name {
-		case "porto":
-			src = porto.New(item)
-		case "agendaculturalporto":
-			src = agendaCulturalPorto.New(item)
-		case "testing":
-			src = testing.New() // only for tests purpose
-
-		// ** will be added soon **
-		// *********
-		//case "teatromunicipaldoporto":
-		//	src = sourceList.Teatromunicipaldoporto(item)
-
-		default:
-			log.Errorln("undefined source name from source list file", item)
+		if _, isMerged := merged[e.ID]; isMerged || e.Ended(run.Started) || slices.ContainsFunc(newEvents, func(n model.Event) bool { return n.ID == e.ID }) {
+			run.Skipped = append(run.Skipped, e.ID)
 			continue
 		}
+		newEvents = append(newEvents, e)
+	}
+
+	res := dedup(newEvents, stored, newPriorities(sources))
+	run.Merged = res.merged
+	for _, e := range res.updated {
+		if !s.Event().Save(&e) {
+			run.Errors = append(run.Errors, "event not saved: "+e.ID)
+		}
+	}
 
-		if events = src.LoadEvents(u); len(events) == 0 {
-			log.Println("No events, strange...", item.Name)
+	for _, e := range res.events {
+		s.Event().Add(&e)
+		if _, ok := s.Event().GetById(e.ID); !ok {
+			run.Errors = append(run.Errors, "event not saved: "+e.ID)
 			continue
 		}
+		run.Added = append(run.Added, e.ID)
+	}
 
-		log.WithFields(log.Fields{"source": item.Name}).Debugln("Collected events")
-		eventsCollection = append(eventsCollection, events...)
+	for _, sourceRun := range sourceRuns {
+		s.SourceRun().Add(sourceRun)
+		if sourceRun.Error != "" {
+			run.Errors = append(run.Errors, sourceRun.Source+" "+sourceRun.Url+": "+sourceRun.Error)
+		}
 	}
+	run.Sources = sourceRuns
+	run.Finished = time.Now()
 
-	sort.Slice(eventsCollection, func(i, j int) bool {
-		return eventsCollection[i].Timestamp.Before(eventsCollection[j].Timestamp) // sort by date ASC
-	})
+	if !s.CollectionRun().Add(run) {
+		log.Error("collection run not saved|", run.ID)
+	}
 
-	log.Debugln("Events col", len(eventsCollection))
+	log.WithFields(log.Fields{"added": len(run.Added), "updated": len(run.Updated), "skipped": len(run.Skipped), "errors": len(run.Errors)}).
+		Infoln("collection run|", run.ID, run.Trigger)
+
+	return run
+}
+
+// collectSource events of the source within its timeout. Error is *model.SourceError if the source failed
+// or stopped (events collected before the stop are kept), or model.ItemErrors of failed events
+func collectSource(ctx context.Context, item model.Source) ([]model.Event, error) {
+	log.Debugln("getting events from source:", item.Name, item.Url)
+
+	u, err := url.ParseRequestURI(item.Url)
+	if err != nil {
+		log.Error("wrong source url", item)
+		return nil, model.SourceFailed(item.Url, err)
+	}
+
+	src, err := model.NewSource(item)
+	if err != nil {
+		log.Errorln("source from source list file|", err)
+		return nil, model.SourceFailed(item.Url, err)
+	}
+
+	timeout := defaultSourceTimeout
+	if item.Timeout > 0 {
+		timeout = time.Duration(item.Timeout) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, done, err := fetcher.WithSourceCassette(ctx, item.Name, item.Url)
+	if err != nil {
+		log.Errorln("source cassette|", item.Name, err)
+		return nil, model.SourceFailed(item.Url, err)
+	}
+	defer func() {
+		if err := done(); err != nil {
+			log.Errorln("save source cassette|", item.Name, err)
+		}
+	}()
+
+	events, err := loadEvents(ctx, src, item, u)
+
+	var (
+		sourceErr *model.SourceError
+		itemsErr  model.ItemErrors
+	)
+	switch {
+	case ctx.Err() != nil && !errors.As(err, &sourceErr):
+		log.Errorln("source collection stopped|", item.Name, ctx.Err())
+		err = model.SourceFailed(item.Url, fmt.Errorf("collection stopped: %w", ctx.Err()))
+	case errors.As(err, &itemsErr):
+		log.WithFields(log.Fields{"source": item.Name}).Warnln("failed events|", err)
+	case err != nil && !errors.As(err, &sourceErr):
+		err = model.SourceFailed(item.Url, err)
+	}
+	if errors.As(err, &sourceErr) {
+		log.WithFields(log.Fields{"source": item.Name}).Errorln("source failed|", err)
+	}
+
+	if len(events) == 0 {
+		log.Warnln("no events from the source|", item.Name)
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"source": item.Name}).Debugln("Collected events")
+
+	return events, err
+}
+
+// loadEvents of the source, panic of the source (or of its RunParallel calls) is returned as *model.SourceError,
+// so one broken source never stops the whole collection
+func loadEvents(ctx context.Context, src model.SourceInterface, item model.Source, u *url.URL) (events []model.Event, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("source panic| %s %v\n%s", item.Name, r, debug.Stack())
+			events, err = nil, model.SourceFailed(item.Url, fmt.Errorf("panic: %v", model.PanicValue(r)))
+		}
+	}()
 
-	return &eventsCollection
+	return src.LoadEvents(ctx, u)
 }
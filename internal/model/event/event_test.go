@@ -0,0 +1,123 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+type fakeSource struct {
+	events []model.Event
+}
+
+func (s *fakeSource) LoadEvents(_ context.Context, u *url.URL) ([]model.Event, error) {
+	return s.events, model.JoinItemErrors(model.ItemFailed(model.ItemParse, u.String(), errors.New("not found image")))
+}
+
+func init() {
+	model.RegisterSource(model.SourceInfo{
+		Name: "fakecollect",
+		New: func(sourceConfig model.Source) model.SourceInterface {
+			var events []model.Event
+			for _, id := range sourceConfig.Options["ids"] {
+				events = append(events, model.Event{ID: string(id), Title: "Event " + string(id), Place: sourceConfig.Options["place"]})
+			}
+			return &fakeSource{events: events}
+		},
+	})
+}
+
+type panicSource struct{}
+
+func (s *panicSource) LoadEvents(ctx context.Context, _ *url.URL) ([]model.Event, error) {
+	var locations []string
+	model.RunParallel(ctx, 2, 3, func(_ context.Context, i int) {
+		_ = locations[i] // malformed item
+	})
+
+	return nil, nil
+}
+
+func TestCollect_panic(t *testing.T) {
+	model.RegisterSource(model.SourceInfo{
+		Name: "fakepanic",
+		New:  func(_ model.Source) model.SourceInterface { return &panicSource{} },
+	})
+
+	sources := []model.Source{
+		{Name: "fakepanic", Url: "https://broken.example.com"},
+		{Name: "fakecollect", Url: "https://agenda.example.com", Options: map[string]string{"ids": "ab"}},
+	}
+
+	events, runs := Collect(context.Background(), sources)
+
+	assert.Len(t, *events, 2, "events of other sources are collected")
+	if assert.Len(t, runs, 2) {
+		assert.Contains(t, runs[0].Error, "panic: runtime error: index out of range")
+		assert.Empty(t, runs[1].Error)
+	}
+}
+
+func TestCollectAndStore(t *testing.T) {
+	s := teststore.New()
+	s.Event().Add(&model.Event{ID: "fakecollect:a", Title: "Event a - stored"})
+
+	sources := []model.Source{
+		{Name: "fakecollect", Url: "https://agenda.example.com", Options: map[string]string{"ids": "abc"}},
+		{Name: "fakecollect", Url: "https://teatro.example.com", Options: map[string]string{"ids": "cd"}},
+		{Name: "fakecollect", Url: "https://empty.example.com"},
+	}
+
+	run := CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+
+	assert.NotEmpty(t, run.ID)
+	assert.Equal(t, model.TriggerManual, run.Trigger)
+	assert.False(t, run.Finished.Before(run.Started))
+	assert.ElementsMatch(t, []string{"fakecollect:b", "fakecollect:c", "fakecollect:d"}, run.Added)
+	assert.ElementsMatch(t, []string{"fakecollect:a", "fakecollect:c"}, run.Skipped, "stored and duplicated in other source")
+	assert.Empty(t, run.Errors)
+
+	if assert.Len(t, run.Sources, 3) {
+		assert.Equal(t, 3, run.Sources[0].Events)
+		assert.Equal(t, 1, run.Sources[0].ParseErrors)
+		assert.Equal(t, "https://teatro.example.com", run.Sources[1].Url)
+		assert.Equal(t, 0, run.Sources[2].Events)
+	}
+
+	assert.Len(t, *s.Event().Get(), 4)
+	assert.Len(t, s.SourceRun().Runs("https://agenda.example.com", 10), 1)
+
+	saved, ok := s.CollectionRun().GetById(run.ID)
+	if assert.True(t, ok) {
+		assert.Equal(t, run.Added, saved.Added)
+	}
+
+	ev, ok := s.Event().GetById("fakecollect:d")
+	if assert.True(t, ok) {
+		assert.Equal(t, "fakecollect", ev.SourceName)
+		assert.Equal(t, "d", ev.SourceId)
+		assert.Equal(t, "https://teatro.example.com", ev.Source)
+		assert.Equal(t, "fakecollect", ev.Provenance["title"].Source)
+		assert.Equal(t, run.Started, ev.Provenance["title"].FetchedAt)
+	}
+}
+
+func TestCollectAndStore_merged(t *testing.T) {
+	s := teststore.New()
+	s.Event().Add(&model.Event{ID: "fakecollect:x", Title: "Event x", Merged: []string{"fakecollect:b"}})
+
+	sources := []model.Source{
+		{Name: "fakecollect", Url: "https://agenda.example.com", Options: map[string]string{"ids": "ab"}},
+	}
+
+	run := CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+
+	assert.Equal(t, []string{"fakecollect:a"}, run.Added)
+	assert.Equal(t, []string{"fakecollect:b"}, run.Skipped, "merged into stored event")
+	_, ok := s.Event().GetById("fakecollect:b")
+	assert.False(t, ok)
+}
@@ -0,0 +1,186 @@
+package event
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// streetScore min similarity of the address street to the gazetteer name with typos or other spelling
+const streetScore = 0.8
+
+var (
+	postcodeRegex = regexp.MustCompile(`\b\d{4}-\d{3}\b`) // "4000-385"
+	numberRegex   = regexp.MustCompile(`^\d+`)
+)
+
+// geocoder of addresses by the local gazetteer of the store, results are cached in the store
+type geocoder struct {
+	s     store.StoreInterface
+	names map[string][]model.GazetteerEntry // entries by normalized names, nil until the first not cached address
+}
+
+func newGeocoder(s store.StoreInterface) *geocoder {
+	return &geocoder{s: s}
+}
+
+// Geocode coordinates of the address by the local gazetteer, without online services
+func Geocode(s store.StoreInterface, address string) model.Geocode {
+	return newGeocoder(s).geocode(address)
+}
+
+// geocode of the address: the street with the house number, the street point or the mean point of its numbers,
+// the named place. Streets are compared normalized (see model.StreetWords), with typos of names of the same type
+func (g *geocoder) geocode(address string) model.Geocode {
+	key := model.NormalizeStreet(address)
+	if key == "" {
+		return model.Geocode{}
+	}
+	if cached, ok := g.s.Gazetteer().Cached(key); ok {
+		return *cached
+	}
+
+	if g.names == nil {
+		g.names = make(map[string][]model.GazetteerEntry)
+		for _, entry := range g.s.Gazetteer().List() {
+			name := model.NormalizeStreet(entry.Name)
+			g.names[name] = append(g.names[name], entry)
+		}
+	}
+	if len(g.names) == 0 { // not imported gazetteer, nothing to cache
+		return model.Geocode{}
+	}
+
+	name, number := parseAddress(address)
+	entries, ok := g.names[name]
+	if !ok {
+		entries = g.similar(name)
+	}
+
+	res := locate(entries, number)
+	g.s.Gazetteer().Cache(key, res)
+
+	return res
+}
+
+// similar entries of the most similar name of the same type ("rua", "avenida"...), nil if none is similar enough
+func (g *geocoder) similar(name string) []model.GazetteerEntry {
+	kind, _, _ := strings.Cut(name, " ")
+
+	var (
+		best      []model.GazetteerEntry
+		bestScore = streetScore
+	)
+	for other, entries := range g.names {
+		otherKind, _, _ := strings.Cut(other, " ")
+		if otherKind != kind {
+			continue
+		}
+		if score := dice(bigrams(name), bigrams(other)); score >= bestScore {
+			best, bestScore = entries, score
+		}
+	}
+
+	return best
+}
+
+// locate the house number on the entries of the street: the same or the nearest number,
+// the street point or the mean point of its numbers
+func locate(entries []model.GazetteerEntry, number string) model.Geocode {
+	if len(entries) == 0 {
+		return model.Geocode{}
+	}
+
+	var (
+		point, nearest *model.GazetteerEntry
+		sum            model.Coordinates
+	)
+	want := numberOf(number)
+	for i, entry := range entries {
+		switch {
+		case entry.Number == "" && point == nil:
+			point = &entries[i]
+		case entry.Number != "" && want > 0 && numberOf(entry.Number) > 0 &&
+			(nearest == nil || abs(numberOf(entry.Number)-want) < abs(numberOf(nearest.Number)-want)):
+			nearest = &entries[i]
+		}
+		sum.Latitude += entry.Coordinates.Latitude
+		sum.Longitude += entry.Coordinates.Longitude
+	}
+
+	switch {
+	case nearest != nil:
+		c := nearest.Coordinates
+		return model.Geocode{Coordinates: &c, Name: nearest.Name, Number: nearest.Number}
+	case point != nil:
+		c := point.Coordinates
+		return model.Geocode{Coordinates: &c, Name: point.Name}
+	}
+
+	n := float64(len(entries))
+	mean := model.Coordinates{
+		Latitude:  math.Round(sum.Latitude/n*1e6) / 1e6,
+		Longitude: math.Round(sum.Longitude/n*1e6) / 1e6,
+	}
+
+	return model.Geocode{Coordinates: &mean, Name: entries[0].Name}
+}
+
+// parseAddress normalized street and house number of the portuguese address:
+// "R. de Passos Manuel, 137 - 4000-385 Porto" -> "rua passos manuel", "137". Without number for places
+func parseAddress(address string) (street string, number string) {
+	parts := strings.Split(postcodeRegex.ReplaceAllString(address, ""), ",")
+
+	words := model.StreetWords(parts[0])
+	if n := len(words); n > 1 && numberRegex.MatchString(words[n-1]) {
+		return strings.Join(words[:n-1], " "), numberRegex.FindString(words[n-1])
+	}
+
+	if len(parts) > 1 {
+		if next := model.StreetWords(parts[1]); len(next) > 0 {
+			number = numberRegex.FindString(next[0])
+		}
+	}
+
+	return strings.Join(words, " "), number
+}
+
+// withCoordinates collected event without coordinates geocoded by its location or place,
+// location edited or locked by an editor is kept
+func withCoordinates(ev model.Event, g *geocoder) model.Event {
+	if ev.Coordinates != nil || ev.Edited("location") || ev.Locked("location") {
+		return ev
+	}
+
+	address := ev.Location
+	if strings.TrimSpace(address) == "" {
+		address = ev.Place
+	}
+
+	if res := g.geocode(address); res.Coordinates != nil {
+		ev.Coordinates = res.Coordinates
+		if ev.LocationMap == "" {
+			ev.LocationMap = ev.Coordinates.MapUrl()
+		}
+	}
+
+	return ev
+}
+
+// numberOf house, 0 if it's not a number
+func numberOf(number string) int {
+	n, _ := strconv.Atoi(numberRegex.FindString(number))
+
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,123 @@
+package event
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func gazetteer() []model.GazetteerEntry {
+	return []model.GazetteerEntry{
+		{Name: "Rua de Passos Manuel", Coordinates: model.Coordinates{Latitude: 41.14725, Longitude: -8.6059}},
+		{Name: "Rua de Passos Manuel", Number: "137", Coordinates: model.Coordinates{Latitude: 41.146992, Longitude: -8.605417}},
+		{Name: "Rua de Passos Manuel", Number: "178", Coordinates: model.Coordinates{Latitude: 41.1476, Longitude: -8.6045}},
+		{Name: "Avenida da Boavista", Number: "600", Coordinates: model.Coordinates{Latitude: 41.1587, Longitude: -8.6303}},
+		{Name: "Avenida da Boavista", Number: "610", Coordinates: model.Coordinates{Latitude: 41.1589, Longitude: -8.6307}},
+		{Name: "Travessa de Passos Manuel", Coordinates: model.Coordinates{Latitude: 41.1469, Longitude: -8.6048}},
+		{Name: "Teatro Rivoli", Coordinates: model.Coordinates{Latitude: 41.1484, Longitude: -8.6105}},
+	}
+}
+
+func Test_parseAddress(t *testing.T) {
+	tests := []struct {
+		address    string
+		wantStreet string
+		wantNumber string
+	}{
+		{address: "Rua de Passos Manuel 137, 4000-385 Porto", wantStreet: "rua passos manuel", wantNumber: "137"},
+		{address: "R. de Passos Manuel, 137 - 4000-385 Porto", wantStreet: "rua passos manuel", wantNumber: "137"},
+		{address: "Av. da Boavista, 604-610", wantStreet: "avenida boavista", wantNumber: "604"},
+		{address: "Rua 31 de Janeiro", wantStreet: "rua 31 janeiro"},
+		{address: "Teatro Rivoli, Porto", wantStreet: "teatro rivoli"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			street, number := parseAddress(tt.address)
+			assert.Equal(t, tt.wantStreet, street)
+			assert.Equal(t, tt.wantNumber, number)
+		})
+	}
+}
+
+func TestGeocode(t *testing.T) {
+	s := teststore.New()
+	assert.Nil(t, Geocode(s, "Rua de Passos Manuel 137").Coordinates, "without gazetteer")
+	_, cached := s.Gazetteer().Cached("rua passos manuel 137")
+	assert.False(t, cached, "not cached without gazetteer")
+
+	s.Gazetteer().Import(gazetteer())
+
+	tests := []struct {
+		name       string
+		address    string
+		want       *model.Coordinates
+		wantNumber string
+	}{
+		{
+			name:       "house number",
+			address:    "R. de Passos Manuel, n.º 137, 4000-385 Porto",
+			want:       &model.Coordinates{Latitude: 41.146992, Longitude: -8.605417},
+			wantNumber: "137",
+		},
+		{
+			name:       "nearest number",
+			address:    "Av. da Boavista, 604-610",
+			want:       &model.Coordinates{Latitude: 41.1587, Longitude: -8.6303},
+			wantNumber: "600",
+		},
+		{name: "street point", address: "Rua de Passos Manuel", want: &model.Coordinates{Latitude: 41.14725, Longitude: -8.6059}},
+		{name: "mean point of numbers", address: "Avenida da Boavista", want: &model.Coordinates{Latitude: 41.1588, Longitude: -8.6305}},
+		{name: "typo", address: "Rua Passos Manoel 137", want: &model.Coordinates{Latitude: 41.146992, Longitude: -8.605417}, wantNumber: "137"},
+		{name: "other street type", address: "Avenida Passos Manuel"},
+		{name: "named place", address: "Teatro Rivoli", want: &model.Coordinates{Latitude: 41.1484, Longitude: -8.6105}},
+		{name: "unknown", address: "Rua Nova, 12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Geocode(s, tt.address)
+			if assert.Equal(t, tt.want, g.Coordinates) && tt.want != nil {
+				assert.Equal(t, tt.wantNumber, g.Number)
+			}
+		})
+	}
+
+	cachedGeocode, ok := s.Gazetteer().Cached("rua nova 12")
+	if assert.True(t, ok, "not found address is cached") {
+		assert.Nil(t, cachedGeocode.Coordinates)
+	}
+
+	s.Gazetteer().Cache("teatro rivoli", model.Geocode{Coordinates: &model.Coordinates{Latitude: 1, Longitude: 2}})
+	assert.Equal(t, &model.Coordinates{Latitude: 1, Longitude: 2}, Geocode(s, "Teatro Rivoli").Coordinates, "from the cache")
+}
+
+func TestCollectAndStore_geocode(t *testing.T) {
+	s := teststore.New()
+	s.Gazetteer().Import(gazetteer())
+	sources := []model.Source{
+		{Name: "fakecollect", Url: "https://agenda.example.com", Options: map[string]string{"ids": "a", "place": "Teatro Rivoli"}},
+	}
+
+	CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+	ev, ok := s.Event().GetById("fakecollect:a")
+	if assert.True(t, ok) {
+		assert.Equal(t, &model.Coordinates{Latitude: 41.1484, Longitude: -8.6105}, ev.Coordinates)
+		assert.Equal(t, "https://www.google.com/maps/search/?api=1&query=41.148400,-8.610500", ev.LocationMap)
+	}
+}
+
+func TestSaveVenue_geocode(t *testing.T) {
+	s := teststore.New()
+	s.Gazetteer().Import(gazetteer())
+
+	v, _, err := SaveVenue(s, model.Venue{Name: "Coliseu Porto", Address: "Rua de Passos Manuel 137, Porto"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, &model.Coordinates{Latitude: 41.146992, Longitude: -8.605417}, v.Coordinates)
+	}
+
+	v, _, err = SaveVenue(s, model.Venue{Name: "Maus Hábitos", Address: "Rua de Passos Manuel 178", Coordinates: &model.Coordinates{Latitude: 1, Longitude: 2}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, &model.Coordinates{Latitude: 1, Longitude: 2}, v.Coordinates, "coordinates of the editor are kept")
+	}
+}
@@ -0,0 +1,91 @@
+package event
+
+import (
+	"errors"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	"maps"
+	"slices"
+	"time"
+)
+
+// collectedProvenance of not empty fields of the event collected from its source at the time
+func collectedProvenance(ev model.Event, at time.Time) map[string]model.FieldProvenance {
+	list := make(map[string]model.FieldProvenance)
+	for field, v := range sourceFields(ev) {
+		if v != "" {
+			list[field] = model.FieldProvenance{Source: ev.SourceName, FetchedAt: at}
+		}
+	}
+
+	return list
+}
+
+// withProvenance of the field from the other event, the lock of the field is kept
+func withProvenance(ev *model.Event, from model.Event, fields ...string) {
+	ev.Provenance = maps.Clone(ev.Provenance)
+	if ev.Provenance == nil {
+		ev.Provenance = make(map[string]model.FieldProvenance)
+	}
+
+	for _, field := range fields {
+		p := from.Provenance[field]
+		p.Locked = p.Locked || ev.Locked(field)
+		ev.Provenance[field] = p
+	}
+}
+
+// SaveEdited event by an editor: changed fields are edited by the editor, they are not changed by collections.
+// Provenance and values of the source are of the stored event, locks are changed by LockField only
+func SaveEdited(s store.StoreInterface, ev model.Event, editor string, now time.Time) (*model.Event, error) {
+	stored, ok := s.Event().GetById(ev.ID)
+	if !ok {
+		return nil, errors.New("not found event " + ev.ID)
+	}
+
+	ev.Collected, ev.Provenance = stored.Collected, maps.Clone(stored.Provenance)
+	if ev.Provenance == nil {
+		ev.Provenance = make(map[string]model.FieldProvenance)
+	}
+
+	old, edited := sourceFields(*stored), sourceFields(ev)
+	for _, field := range model.ChangeFields {
+		if old[field] != edited[field] {
+			p := ev.Provenance[field]
+			p.EditedBy, p.EditedAt = editor, now
+			ev.Provenance[field] = p
+		}
+	}
+
+	if !s.Event().Save(&ev) {
+		return nil, errors.New("failed save event " + ev.ID)
+	}
+
+	return &ev, nil
+}
+
+// LockField of the stored event, locked value is never changed by collections
+func LockField(s store.StoreInterface, id string, field string, locked bool) (*model.Event, error) {
+	if !slices.Contains(model.ChangeFields, field) {
+		return nil, errors.New("unknown field " + field)
+	}
+
+	ev, ok := s.Event().GetById(id)
+	if !ok {
+		return nil, errors.New("not found event " + id)
+	}
+
+	ev.Provenance = maps.Clone(ev.Provenance)
+	if ev.Provenance == nil {
+		ev.Provenance = make(map[string]model.FieldProvenance)
+	}
+	p := ev.Provenance[field]
+	p.Locked = locked
+	ev.Provenance[field] = p
+
+	if !s.Event().Save(ev) {
+		return nil, errors.New("failed save event " + ev.ID)
+	}
+
+	return ev, nil
+}
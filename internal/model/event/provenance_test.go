@@ -0,0 +1,73 @@
+package event
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_collectedProvenance(t *testing.T) {
+	at := time.Date(2030, time.January, 5, 10, 0, 0, 0, time.UTC)
+	ev := model.Event{Title: "Orfélia", Place: "Maus Hábitos", SourceName: "porto"}
+
+	assert.Equal(t, map[string]model.FieldProvenance{
+		"title": {Source: "porto", FetchedAt: at},
+		"place": {Source: "porto", FetchedAt: at},
+	}, collectedProvenance(ev, at))
+}
+
+func TestSaveEdited(t *testing.T) {
+	fetched := time.Date(2030, time.January, 5, 10, 0, 0, 0, time.UTC)
+	now := fetched.Add(time.Hour)
+
+	stored := model.Event{ID: "porto:36013", Title: "Orfélia", Description: "Concerto", SourceName: "porto"}
+	stored.Collected = sourceFields(stored)
+	stored.Provenance = collectedProvenance(stored, fetched)
+	stored.Provenance["description"] = model.FieldProvenance{Source: "porto", FetchedAt: fetched, Locked: true}
+
+	s := teststore.New()
+	s.Event().Add(&stored)
+
+	edited := stored
+	edited.Title, edited.Collected, edited.Provenance = "Orfélia ao vivo", nil, nil
+
+	saved, err := SaveEdited(s, edited, "ana", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Collected, saved.Collected, "values of the source are kept")
+	assert.Equal(t, model.FieldProvenance{Source: "porto", FetchedAt: fetched, EditedBy: "ana", EditedAt: now}, saved.Provenance["title"])
+	assert.Equal(t, stored.Provenance["description"], saved.Provenance["description"], "not changed field")
+	assert.True(t, saved.Edited("title"))
+	assert.False(t, saved.Edited("description"))
+	assert.False(t, stored.Edited("title"), "provenance of the stored event is not modified")
+
+	ev, _ := s.Event().GetById(stored.ID)
+	assert.Equal(t, "Orfélia ao vivo", ev.Title)
+
+	_, err = SaveEdited(s, model.Event{ID: "porto:1"}, "ana", now)
+	assert.Error(t, err)
+}
+
+func TestLockField(t *testing.T) {
+	s := teststore.New()
+	s.Event().Add(&model.Event{ID: "porto:36013", Title: "Orfélia"})
+
+	ev, err := LockField(s, "porto:36013", "title", true)
+	assert.NoError(t, err)
+	assert.True(t, ev.Locked("title"))
+
+	stored, _ := s.Event().GetById("porto:36013")
+	assert.True(t, stored.Locked("title"))
+	assert.False(t, stored.Locked("description"))
+
+	ev, err = LockField(s, "porto:36013", "title", false)
+	assert.NoError(t, err)
+	assert.False(t, ev.Locked("title"))
+
+	_, err = LockField(s, "porto:36013", "category", true)
+	assert.Error(t, err, "unknown field")
+	_, err = LockField(s, "porto:1", "title", true)
+	assert.Error(t, err, "not found")
+}
@@ -0,0 +1,201 @@
+package event
+
+import (
+	"errors"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// venueRadius max distance in meters between coordinates of the event and the venue of the same place
+const venueRadius = 75
+
+// PlaceCount place of stored events not matched to any venue, candidate for a new venue or alias
+type PlaceCount struct {
+	Place    string `json:"place"`
+	Location string `json:"location"`
+	Events   int    `json:"events"`
+}
+
+// matchVenue of the event place: the same name or alias of the venue, or the place starting with it
+// ("Coliseu Porto Ageas - Rua de Passos Manuel 137"), the longest name wins. Without names the nearest
+// venue by coordinates
+func matchVenue(venues []model.Venue, ev model.Event) (model.Venue, bool) {
+	place := model.NormalizeVenueName(ev.Place)
+
+	best, bestLen := -1, 0
+	for i, v := range venues {
+		for _, name := range v.Names() {
+			if place == name {
+				return v, true
+			}
+			if strings.HasPrefix(place, name+" ") && len(name) > bestLen {
+				best, bestLen = i, len(name)
+			}
+		}
+	}
+	if best >= 0 {
+		return venues[best], true
+	}
+
+	if ev.Coordinates == nil {
+		return model.Venue{}, false
+	}
+	nearest := 0.0
+	for i, v := range venues {
+		if v.Coordinates == nil {
+			continue
+		}
+		if d := ev.Coordinates.Distance(*v.Coordinates); d <= venueRadius && (best < 0 || d < nearest) {
+			best, nearest = i, d
+		}
+	}
+	if best >= 0 {
+		return venues[best], true
+	}
+
+	return model.Venue{}, false
+}
+
+// applyVenue name, address and coordinates of the venue to the event, with the map link by the coordinates.
+// Place edited or locked by an editor is kept, the same for the location. False if the event is not changed
+func applyVenue(ev *model.Event, v model.Venue) bool {
+	if ev.Edited("place") || ev.Locked("place") {
+		return false
+	}
+
+	before := *ev
+	ev.Venue, ev.Place = v.ID, v.Name
+	if !ev.Edited("location") && !ev.Locked("location") {
+		if v.Address != "" {
+			ev.Location = v.Address
+		}
+		if v.Coordinates != nil {
+			c := *v.Coordinates
+			ev.Coordinates = &c
+		}
+		if ev.Coordinates != nil {
+			ev.LocationMap = ev.Coordinates.MapUrl()
+		}
+	}
+
+	return ev.Venue != before.Venue || ev.Place != before.Place || ev.Location != before.Location ||
+		ev.LocationMap != before.LocationMap
+}
+
+// withVenue collected event with the names of its venue, the same on every collection
+func withVenue(ev model.Event, venues []model.Venue) model.Event {
+	if v, ok := matchVenue(venues, ev); ok {
+		applyVenue(&ev, v)
+	}
+
+	return ev
+}
+
+// SaveVenue new or changed venue, id of the new one is by its name, the address without coordinates is geocoded.
+// Stored events of the venue or matched to it get its names, returns count of changed events
+func SaveVenue(s store.StoreInterface, v model.Venue) (*model.Venue, int, error) {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.ID == "" {
+		v.ID = model.VenueId(v.Name)
+	}
+	if v.Name == "" || v.ID == "" {
+		return nil, 0, errors.New("venue without name")
+	}
+
+	aliases := v.Aliases
+	v.Aliases = nil
+	names := []string{model.NormalizeVenueName(v.Name)}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if n := model.NormalizeVenueName(alias); n != "" && !slices.Contains(names, n) {
+			names = append(names, n)
+			v.Aliases = append(v.Aliases, alias)
+		}
+	}
+
+	if v.Coordinates == nil && v.Address != "" {
+		v.Coordinates = Geocode(s, v.Address).Coordinates
+	}
+
+	if !s.Venue().Save(v) {
+		return nil, 0, errors.New("failed save venue " + v.ID)
+	}
+
+	n := 0
+	for _, ev := range *s.Event().Get() {
+		matched := ev.Venue == v.ID
+		if ev.Venue == "" {
+			_, matched = matchVenue([]model.Venue{v}, ev)
+		}
+		if !matched || ev.Category == store.CategoryBlocked {
+			continue
+		}
+
+		before := sourceFields(ev)
+		if !applyVenue(&ev, v) {
+			continue
+		}
+		if ev.Collected != nil { // names of the venue are the values of the source, they are not changes
+			ev.Collected = maps.Clone(ev.Collected)
+			after := sourceFields(ev)
+			for _, field := range []string{"place", "location"} {
+				if before[field] != after[field] {
+					ev.Collected[field] = after[field]
+				}
+			}
+		}
+		if s.Event().Save(&ev) {
+			n++
+		}
+	}
+
+	return &v, n, nil
+}
+
+// DeleteVenue from the registry, stored events keep names of the venue
+func DeleteVenue(s store.StoreInterface, id string) error {
+	if !s.Venue().Delete(id) {
+		return errors.New("failed delete venue " + id)
+	}
+
+	for _, ev := range *s.Event().Get() {
+		if ev.Venue == id {
+			ev.Venue = ""
+			s.Event().Save(&ev)
+		}
+	}
+
+	return nil
+}
+
+// UnmatchedPlaces of stored events without venue, the most frequent first
+func UnmatchedPlaces(s store.StoreInterface) []PlaceCount {
+	counts := make(map[string]*PlaceCount)
+	for _, ev := range *s.Event().Get() {
+		if ev.Venue != "" || strings.TrimSpace(ev.Place) == "" {
+			continue
+		}
+		key := model.NormalizeVenueName(ev.Place)
+		if counts[key] == nil {
+			counts[key] = &PlaceCount{Place: ev.Place, Location: ev.Location}
+		}
+		counts[key].Events++
+	}
+
+	list := make([]PlaceCount, 0, len(counts))
+	for _, p := range counts {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Events != list[j].Events {
+			return list[i].Events > list[j].Events
+		}
+		return list[i].Place < list[j].Place
+	})
+
+	return list
+}
@@ -0,0 +1,132 @@
+package event
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func venues() []model.Venue {
+	return []model.Venue{
+		{
+			ID:          "coliseu-porto",
+			Name:        "Coliseu Porto",
+			Aliases:     []string{"Coliseu Porto Ageas", "Coliseu do Porto"},
+			Address:     "Rua de Passos Manuel 137, Porto",
+			Coordinates: &model.Coordinates{Latitude: 41.146992, Longitude: -8.605417},
+		},
+		{ID: "maus-habitos", Name: "Maus Hábitos", Address: "Rua de Passos Manuel 178, Porto"},
+		{ID: "casa-da-musica", Name: "Casa da Música", Coordinates: &model.Coordinates{Latitude: 41.158889, Longitude: -8.630556}},
+	}
+}
+
+func Test_matchVenue(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   model.Event
+		want string // venue id, empty if not matched
+	}{
+		{name: "name", ev: model.Event{Place: "Coliseu Porto"}, want: "coliseu-porto"},
+		{name: "alias, case and accents", ev: model.Event{Place: "COLISEU DO PORTO"}, want: "coliseu-porto"},
+		{name: "punctuation", ev: model.Event{Place: "Maus Habitos!"}, want: "maus-habitos"},
+		{name: "place with address, the longest alias", ev: model.Event{Place: "Coliseu Porto Ageas - Rua de Passos Manuel 137"}, want: "coliseu-porto"},
+		{name: "not a word prefix", ev: model.Event{Place: "Maus Habitosx"}},
+		{
+			name: "coordinates near the venue",
+			ev:   model.Event{Place: "Sala Suggia", Coordinates: &model.Coordinates{Latitude: 41.15910, Longitude: -8.63070}},
+			want: "casa-da-musica",
+		},
+		{
+			name: "coordinates far",
+			ev:   model.Event{Place: "Serralves", Coordinates: &model.Coordinates{Latitude: 41.159722, Longitude: -8.659722}},
+		},
+		{name: "unknown", ev: model.Event{Place: "Teatro Rivoli"}},
+		{name: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := matchVenue(venues(), tt.ev)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, v.ID)
+		})
+	}
+}
+
+func Test_applyVenue(t *testing.T) {
+	ev := model.Event{Place: "Coliseu do Porto - Passos Manuel", Location: "Porto"}
+	assert.True(t, applyVenue(&ev, venues()[0]))
+	assert.Equal(t, "coliseu-porto", ev.Venue)
+	assert.Equal(t, "Coliseu Porto", ev.Place)
+	assert.Equal(t, "Rua de Passos Manuel 137, Porto", ev.Location)
+	assert.Equal(t, "https://www.google.com/maps/search/?api=1&query=41.146992,-8.605417", ev.LocationMap)
+	assert.False(t, applyVenue(&ev, venues()[0]), "already applied")
+
+	edited := model.Event{Place: "Coliseu", Provenance: map[string]model.FieldProvenance{"place": {EditedBy: "ana"}}}
+	assert.False(t, applyVenue(&edited, venues()[0]))
+	assert.Equal(t, "Coliseu", edited.Place)
+
+	locked := model.Event{Place: "Coliseu", Location: "Porto", Provenance: map[string]model.FieldProvenance{"location": {Locked: true}}}
+	assert.True(t, applyVenue(&locked, venues()[0]))
+	assert.Equal(t, "Coliseu Porto", locked.Place)
+	assert.Equal(t, "Porto", locked.Location, "locked location is kept")
+	assert.Empty(t, locked.LocationMap)
+}
+
+func TestSaveVenue(t *testing.T) {
+	s := teststore.New()
+	s.Event().Add(&model.Event{ID: "porto:1", Title: "Concert", Place: "Maus Habitos - Espaço de Intervenção Cultural",
+		Collected: map[string]string{"place": "Maus Habitos - Espaço de Intervenção Cultural"}})
+	s.Event().Add(&model.Event{ID: "porto:2", Title: "Show", Place: "Teatro Rivoli"})
+
+	_, _, err := SaveVenue(s, model.Venue{Name: " "})
+	assert.Error(t, err, "without name")
+
+	v, n, err := SaveVenue(s, model.Venue{Name: " Maus Hábitos ", Aliases: []string{"maus habitos", " Maus Hábitos Porto", ""}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "maus-habitos", v.ID)
+		assert.Equal(t, "Maus Hábitos", v.Name)
+		assert.Equal(t, []string{"Maus Hábitos Porto"}, v.Aliases, "aliases without the name and duplicates")
+		assert.Equal(t, 1, n)
+	}
+
+	ev, _ := s.Event().GetById("porto:1")
+	assert.Equal(t, "maus-habitos", ev.Venue)
+	assert.Equal(t, "Maus Hábitos", ev.Place)
+	assert.Equal(t, "Maus Hábitos", ev.Collected["place"], "not a change in the source")
+
+	_, n, err = SaveVenue(s, model.Venue{ID: "maus-habitos", Name: "Maus Hábitos Porto"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, n, "renamed venue")
+	}
+	ev, _ = s.Event().GetById("porto:1")
+	assert.Equal(t, "Maus Hábitos Porto", ev.Place)
+
+	assert.Equal(t, []PlaceCount{{Place: "Teatro Rivoli", Events: 1}}, UnmatchedPlaces(s))
+
+	assert.NoError(t, DeleteVenue(s, "maus-habitos"))
+	ev, _ = s.Event().GetById("porto:1")
+	assert.Empty(t, ev.Venue)
+	assert.Equal(t, "Maus Hábitos Porto", ev.Place, "names are kept")
+	assert.Error(t, DeleteVenue(s, "maus-habitos"))
+}
+
+func TestCollectAndStore_venues(t *testing.T) {
+	s := teststore.New()
+	s.Venue().Save(venues()[0])
+	sources := []model.Source{
+		{Name: "fakecollect", Url: "https://agenda.example.com", Options: map[string]string{"ids": "a", "place": "Coliseu do Porto"}},
+	}
+
+	CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+	ev, ok := s.Event().GetById("fakecollect:a")
+	if assert.True(t, ok) {
+		assert.Equal(t, "coliseu-porto", ev.Venue)
+		assert.Equal(t, "Coliseu Porto", ev.Place)
+		assert.NotEmpty(t, ev.LocationMap)
+	}
+
+	run := CollectAndStore(context.Background(), s, sources, model.TriggerManual)
+	assert.Empty(t, run.Updated, "venue names are not changes")
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// eventIdSeparator between the source name and the id of the event in the source
+const eventIdSeparator = ":"
+
+// EventId stable id of the event of the source, unique between sources: "porto:36013".
+// Events without own id in the source get a hash of their source, url, title and date: "porto:3f2a0c9e51b7d4a8".
+// Id without source name for events of unknown source
+func EventId(sourceName string, sourceId string, ev Event) string {
+	if sourceId == "" {
+		sourceId = contentId(sourceName, ev)
+	}
+	if sourceName == "" {
+		return sourceId
+	}
+
+	return sourceName + eventIdSeparator + sourceId
+}
+
+// SetSourceId namespaced ID of the event by its own id in the source, the source id and name are kept on the event
+func (e *Event) SetSourceId(sourceName string, sourceId string) {
+	e.SourceName, e.SourceId = sourceName, sourceId
+	e.ID = EventId(sourceName, sourceId, *e)
+}
+
+// contentId hash of the source name, the event url (the place for events without url), title and start date.
+// Sessions of the same event page get own ids, a rescheduled event gets a new one
+func contentId(sourceName string, ev Event) string {
+	where := ev.Url
+	if where == "" {
+		where = NormalizeVenueName(ev.Place)
+	}
+
+	date := ev.DateText
+	if !ev.Start.IsZero() {
+		date = ev.Start.UTC().Format(time.RFC3339)
+	}
+
+	sum := sha1.Sum([]byte(strings.Join([]string{sourceName, where, strings.TrimSpace(ev.Title), date}, "\n")))
+
+	return hex.EncodeToString(sum[:8])
+}
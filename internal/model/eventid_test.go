@@ -0,0 +1,65 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEventId(t *testing.T) {
+	ev := Event{Url: "https://agendaculturalporto.org/orfelia", Title: "Orfélia"}
+	ev.SetDates(time.Date(2024, time.January, 6, 21, 0, 0, 0, Lisbon), time.Time{}, false)
+
+	assert.Equal(t, "porto:36013", EventId("porto", "36013", ev))
+	assert.Equal(t, "36013", EventId("", "36013", ev), "unknown source")
+
+	id := EventId("agendaculturalporto", "", ev)
+	assert.Regexp(t, `^agendaculturalporto:[0-9a-f]{16}$`, id, "hash of the event without id")
+	assert.Equal(t, id, EventId("agendaculturalporto", "", ev), "stable")
+
+	rescheduled := ev
+	rescheduled.SetDates(ev.Start.AddDate(0, 0, 1), time.Time{}, false)
+	assert.NotEqual(t, id, EventId("agendaculturalporto", "", rescheduled), "other day")
+
+	ev.Description = "edited"
+	assert.Equal(t, id, EventId("agendaculturalporto", "", ev), "only source, url, title and date")
+
+	assert.NotEqual(t, contentId("agendaculturalporto", ev), contentId("porto", ev), "other source")
+
+	other := ev
+	other.Title = "Orfélia, segunda sessão"
+	assert.NotEqual(t, id, EventId("agendaculturalporto", "", other), "other title")
+
+	noUrl, otherPlace := ev, ev
+	noUrl.Url, noUrl.Place = "", "Maus Hábitos"
+	otherPlace.Url, otherPlace.Place = "", "Hot Clube"
+	assert.NotEqual(t, EventId("agendaculturalporto", "", noUrl), EventId("agendaculturalporto", "", otherPlace), "place without url")
+}
+
+func TestEventId_sessions(t *testing.T) {
+	ev := Event{Url: "https://www.teatromunicipaldoporto.pt/en/programa/hamlet/", Title: "Hamlet"}
+	start := time.Date(2024, time.January, 12, 19, 30, 0, 0, Lisbon)
+
+	ids := make(map[string]bool)
+	for _, s := range []time.Time{start, start.Add(2 * time.Hour), start.AddDate(0, 0, 1)} {
+		session := ev
+		session.SetDates(s, time.Time{}, false)
+		ids[EventId("teatromunicipaldoporto", "", session)] = true
+	}
+	assert.Len(t, ids, 3, "sessions of the same page and title don't collide")
+
+	byText := ev
+	byText.DateText = "12 Jan"
+	otherText := ev
+	otherText.DateText = "13 Jan"
+	assert.NotEqual(t, EventId("teatromunicipaldoporto", "", byText), EventId("teatromunicipaldoporto", "", otherText), "date text without parsed dates")
+}
+
+func TestEvent_SetSourceId(t *testing.T) {
+	ev := Event{ID: "36013", Title: "Orfélia"}
+	ev.SetSourceId("porto", ev.ID)
+
+	assert.Equal(t, "porto:36013", ev.ID)
+	assert.Equal(t, "porto", ev.SourceName)
+	assert.Equal(t, "36013", ev.SourceId)
+}
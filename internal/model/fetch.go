@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type (
+	// FetchConfig http client settings of the source, [source.fetch] in event-sources.toml. Zero values - defaults
+	FetchConfig struct {
+		Timeout        uint    `toml:"timeout"`         // one request timeout in seconds, default 30
+		Retries        int     `toml:"retries"`         // retries on network errors, 5xx and 429 responses, default 2, negative - without retries
+		RetryBackoff   string  `toml:"retry_backoff"`   // delay before the first retry, doubled for every next one, default "1s"
+		RateLimit      float64 `toml:"rate_limit"`      // max requests per second to one host, default without limit, the lowest of sources of the host
+		MaxConnections uint    `toml:"max_connections"` // max parallel requests to one host, default 4, the lowest of sources of the host
+		UserAgent      string  `toml:"user_agent"`      // default "porto-events (+https://github.com/oleksiy-os/porto-events)"
+		Proxy          string  `toml:"proxy"`           // http, https or socks5 proxy url, default from HTTP_PROXY/HTTPS_PROXY env
+		Robots         bool    `toml:"robots"`          // skip urls disallowed by robots.txt of the host
+		CacheTtl       uint    `toml:"cache_ttl"`       // hours, cache freshness of responses without cache headers, default [http_cache] ttl
+		NoCache        bool    `toml:"no_cache"`        // don't use [http_cache] for the source
+	}
+)
+
+// Validate check values which can't be used as is
+func (c FetchConfig) Validate() error {
+	var errs []error
+
+	if c.RetryBackoff != "" {
+		if d, err := time.ParseDuration(c.RetryBackoff); err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("wrong retry_backoff %q, expected duration like \"500ms\" or \"2s\"", c.RetryBackoff))
+		}
+	}
+
+	if c.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("wrong rate_limit %v, should be positive", c.RateLimit))
+	}
+
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			errs = append(errs, fmt.Errorf("wrong proxy %q", c.Proxy))
+		}
+	}
+
+	return errors.Join(errs...)
+}
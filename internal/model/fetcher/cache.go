@@ -0,0 +1,260 @@
+package fetcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// CacheConfig on-disk http cache of the sources, [http_cache] in config.toml
+	CacheConfig struct {
+		Dir string `toml:"dir"` // cache directory, empty - cache disabled
+		Ttl uint   `toml:"ttl"` // hours, freshness of responses without Cache-Control/Expires headers, default 6
+	}
+
+	cache struct {
+		dir string
+		ttl time.Duration
+	}
+
+	cacheEntry struct {
+		Url     string      `json:"url"`
+		Status  int         `json:"status"`
+		Header  http.Header `json:"header"`
+		Body    []byte      `json:"body"`
+		Expires time.Time   `json:"expires"` // after it the entry is revalidated by ETag/Last-Modified
+	}
+)
+
+const (
+	// CacheHeader added to responses from the cache, values: CacheHit, CacheRevalidated
+	CacheHeader      = "X-Porto-Events-Cache"
+	CacheHit         = "hit"         // fresh cached response, no request was sent
+	CacheRevalidated = "revalidated" // server answered 304 Not Modified
+
+	defaultCacheTtl   = 6 * time.Hour
+	cacheMaxBodyBytes = 10 * 1024 * 1024
+)
+
+var httpCache atomic.Pointer[cache]
+
+// SetCache enable on-disk cache for all clients. Empty dir disables the cache
+func SetCache(config CacheConfig) error {
+	if config.Dir == "" {
+		httpCache.Store(nil)
+		return nil
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return err
+	}
+
+	c := &cache{dir: config.Dir, ttl: defaultCacheTtl}
+	if config.Ttl > 0 {
+		c.ttl = time.Duration(config.Ttl) * time.Hour
+	}
+	httpCache.Store(c)
+
+	return nil
+}
+
+// cached response of GET request from the cache: fresh entry is returned as is,
+// for stale entry conditional headers are added to the request
+func (c *Client) cached(req *http.Request) (fresh *http.Response, entry *cacheEntry) {
+	store := httpCache.Load()
+	if store == nil || c.noCache || req.Method != http.MethodGet {
+		return nil, nil
+	}
+
+	entry = store.load(req.URL.String())
+	if entry == nil {
+		return nil, nil
+	}
+
+	if time.Now().Before(entry.Expires) {
+		return entry.response(req, CacheHit), entry
+	}
+
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if modified := entry.Header.Get("Last-Modified"); modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+
+	return nil, entry
+}
+
+// cache response of the request: 304 refreshes the entry and returns cached response, 200 is stored
+func (c *Client) cache(req *http.Request, res *http.Response, entry *cacheEntry) *http.Response {
+	store := httpCache.Load()
+	if store == nil || c.noCache || req.Method != http.MethodGet {
+		return res
+	}
+
+	ttl := store.ttl
+	if c.cacheTtl > 0 {
+		ttl = c.cacheTtl
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && entry != nil:
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+
+		for key, values := range res.Header {
+			entry.Header[key] = values
+		}
+		entry.Expires, _ = freshness(entry.Header, time.Now(), ttl)
+		store.save(entry)
+
+		return entry.response(req, CacheRevalidated)
+	case res.StatusCode != http.StatusOK:
+		return res
+	}
+
+	expires, ok := freshness(res.Header, time.Now(), ttl)
+	if !ok {
+		return res
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, cacheMaxBodyBytes+1))
+	if err != nil || len(body) > cacheMaxBodyBytes {
+		res.Body = &multiReadCloser{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+		return res
+	}
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	store.save(&cacheEntry{
+		Url:     req.URL.String(),
+		Status:  res.StatusCode,
+		Header:  res.Header.Clone(),
+		Body:    body,
+		Expires: expires,
+	})
+
+	return res
+}
+
+// freshness expiration time of the response by Cache-Control (max-age, no-cache, no-store) and Expires headers,
+// ttl is used without them. False - response should not be stored
+func freshness(h http.Header, now time.Time, ttl time.Duration) (time.Time, bool) {
+	if h.Get("Vary") == "*" {
+		return time.Time{}, false
+	}
+
+	for _, directive := range strings.Split(strings.ToLower(h.Get("Cache-Control")), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch name {
+		case "no-store":
+			return time.Time{}, false
+		case "no-cache":
+			return now, true // revalidate every time
+		case "max-age":
+			if sec, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				age, _ := strconv.Atoi(h.Get("Age"))
+				return now.Add(time.Duration(sec-age) * time.Second), true
+			}
+		}
+	}
+
+	if expires := h.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return now, true // invalid Expires means already expired
+		}
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			return now.Add(t.Sub(date)), true // server clock independent
+		}
+		return t, true
+	}
+
+	return now.Add(ttl), true
+}
+
+func (e *cacheEntry) response(req *http.Request, cacheStatus string) *http.Response {
+	header := e.Header.Clone()
+	header.Set(CacheHeader, cacheStatus)
+
+	return &http.Response{
+		Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// path of the url entry file: dir/ab/abcdef...json
+func (s *cache) path(rawUrl string) string {
+	sum := sha256.Sum256([]byte(rawUrl))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(s.dir, name[:2], name+".json")
+}
+
+func (s *cache) load(rawUrl string) *cacheEntry {
+	data, err := os.ReadFile(s.path(rawUrl))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil || entry.Url != rawUrl {
+		return nil
+	}
+
+	return &entry
+}
+
+// save entry to temp file and rename it, so readers never see partially written entry
+func (s *cache) save(entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	path := s.path(entry.Url)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Error("http cache| ", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		log.Error("http cache| ", err)
+		return
+	}
+
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		log.Error("http cache| ", err)
+	}
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
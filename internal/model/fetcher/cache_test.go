@@ -0,0 +1,216 @@
+package fetcher
+
+import (
+	"context"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setTestCache(t *testing.T) {
+	if err := SetCache(CacheConfig{Dir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetCache(CacheConfig{}) })
+}
+
+// get body and cache status
+func get(t *testing.T, c *Client, rawUrl string) (string, string) {
+	res, err := c.Get(context.Background(), rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	return string(body), res.Header.Get(CacheHeader)
+}
+
+func TestClient_Get_cache(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       m.FetchConfig
+		handler      func(w http.ResponseWriter, r *http.Request)
+		wantCache    string // cache status of the second response
+		wantRequests int32
+	}{
+		{
+			name: "fresh by max-age",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "public, max-age=600")
+			},
+			wantCache:    CacheHit,
+			wantRequests: 1,
+		},
+		{
+			name:         "fresh by default ttl",
+			handler:      func(w http.ResponseWriter, r *http.Request) {},
+			wantCache:    CacheHit,
+			wantRequests: 1,
+		},
+		{
+			name: "revalidated by ETag",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+				}
+			},
+			wantCache:    CacheRevalidated,
+			wantRequests: 2,
+		},
+		{
+			name: "revalidated by Last-Modified",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=0")
+				w.Header().Set("Last-Modified", "Mon, 06 Jan 2030 10:00:00 GMT")
+				if r.Header.Get("If-Modified-Since") == "Mon, 06 Jan 2030 10:00:00 GMT" {
+					w.WriteHeader(http.StatusNotModified)
+				}
+			},
+			wantCache:    CacheRevalidated,
+			wantRequests: 2,
+		},
+		{
+			name: "no-store",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-store")
+			},
+			wantRequests: 2,
+		},
+		{
+			name:         "source without cache",
+			config:       m.FetchConfig{NoCache: true},
+			handler:      func(w http.ResponseWriter, r *http.Request) {},
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestCache(t)
+
+			var requests atomic.Int32
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				tt.handler(w, r)
+				_, _ = w.Write([]byte("<html>agenda</html>")) // ignored for 304
+			}))
+			defer svr.Close()
+
+			c := New(tt.config)
+
+			body, cacheStatus := get(t, c, svr.URL+"/agenda")
+			assert.Equal(t, "<html>agenda</html>", body)
+			assert.Empty(t, cacheStatus, "first response from the server")
+
+			body, cacheStatus = get(t, c, svr.URL+"/agenda")
+			assert.Equal(t, "<html>agenda</html>", body)
+			assert.Equal(t, tt.wantCache, cacheStatus)
+			assert.Equal(t, tt.wantRequests, requests.Load(), "requests to the server")
+		})
+	}
+}
+
+func TestClient_Get_cacheChangedPage(t *testing.T) {
+	setTestCache(t)
+
+	version := "v1"
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", version)
+		if r.Header.Get("If-None-Match") == version {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("page " + version))
+	}))
+	defer svr.Close()
+
+	c := New(m.FetchConfig{})
+
+	body, _ := get(t, c, svr.URL)
+	assert.Equal(t, "page v1", body)
+
+	version = "v2"
+	body, cacheStatus := get(t, c, svr.URL)
+	assert.Equal(t, "page v2", body)
+	assert.Empty(t, cacheStatus, "changed page from the server")
+
+	body, cacheStatus = get(t, c, svr.URL)
+	assert.Equal(t, "page v2", body, "new version is cached")
+	assert.Equal(t, CacheRevalidated, cacheStatus)
+}
+
+func Test_freshness(t *testing.T) {
+	now := time.Date(2030, time.January, 6, 10, 0, 0, 0, time.UTC)
+	ttl := 6 * time.Hour
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		want      time.Time
+		wantStore bool
+	}{
+		{
+			name:      "without headers, ttl",
+			header:    http.Header{},
+			want:      now.Add(ttl),
+			wantStore: true,
+		},
+		{
+			name:      "max-age minus age",
+			header:    http.Header{"Cache-Control": {"public, max-age=3600"}, "Age": {"600"}},
+			want:      now.Add(50 * time.Minute),
+			wantStore: true,
+		},
+		{
+			name:      "no-cache, revalidate every time",
+			header:    http.Header{"Cache-Control": {"no-cache"}},
+			want:      now,
+			wantStore: true,
+		},
+		{
+			name: "expires relative to server date",
+			header: http.Header{
+				"Date":    {"Mon, 01 Jan 2029 10:00:00 GMT"},
+				"Expires": {"Mon, 01 Jan 2029 12:00:00 GMT"},
+			},
+			want:      now.Add(2 * time.Hour),
+			wantStore: true,
+		},
+		{
+			name:      "wrong expires",
+			header:    http.Header{"Expires": {"0"}},
+			want:      now,
+			wantStore: true,
+		},
+		{
+			name:   "no-store",
+			header: http.Header{"Cache-Control": {"private, no-store"}},
+		},
+		{
+			name:   "vary by everything",
+			header: http.Header{"Vary": {"*"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, store := freshness(tt.header, now, ttl)
+			assert.Equal(t, tt.wantStore, store)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// Mode of the sources http exchanges: live requests, recording to cassettes or replay from them
+	Mode int
+
+	// Cassette http exchanges of the source, versioned JSON fixture file
+	Cassette struct {
+		Version   int        `json:"version"`
+		Recorded  time.Time  `json:"recorded"`
+		Exchanges []Exchange `json:"exchanges"`
+
+		path string
+		mode Mode
+		mu   sync.Mutex
+	}
+
+	// Exchange one request and its response
+	Exchange struct {
+		Method     string      `json:"method"`
+		Url        string      `json:"url"`
+		Status     int         `json:"status"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body,omitempty"`
+		BodyBase64 string      `json:"body_base64,omitempty"` // not UTF-8 body
+	}
+
+	cassetteKey struct{}
+)
+
+const (
+	ModeLive Mode = iota
+	ModeRecord
+	ModeReplay
+)
+
+// CassetteVersion of the file format, cassettes of other versions should be recorded again
+const CassetteVersion = 1
+
+var ErrNotRecorded = errors.New("request is not recorded in the cassette")
+
+var (
+	cassettesMode Mode
+	cassettesDir  string
+)
+
+// SetCassettes record or replay mode for all sources, cassettes are in dir, one per source
+func SetCassettes(mode Mode, dir string) {
+	cassettesMode, cassettesDir = mode, dir
+}
+
+// WithSourceCassette ctx with the source cassette if record or replay mode is set by SetCassettes.
+// done saves recorded cassette
+func WithSourceCassette(ctx context.Context, name string, sourceUrl string) (_ context.Context, done func() error, err error) {
+	done = func() error { return nil }
+
+	if cassettesMode == ModeLive {
+		return ctx, done, nil
+	}
+
+	sum := sha256.Sum256([]byte(sourceUrl))
+	path := filepath.Join(cassettesDir, name+"-"+hex.EncodeToString(sum[:4])+".json")
+
+	var c *Cassette
+	switch cassettesMode {
+	case ModeRecord:
+		c = NewCassette(path)
+		done = c.Save
+	case ModeReplay:
+		if c, err = LoadCassette(path); err != nil {
+			return ctx, done, err
+		}
+	}
+
+	return WithCassette(ctx, c), done, nil
+}
+
+// NewCassette empty cassette for recording to path
+func NewCassette(path string) *Cassette {
+	return &Cassette{Version: CassetteVersion, path: path, mode: ModeRecord}
+}
+
+// LoadCassette cassette for replay
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{path: path, mode: ModeReplay}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	if c.Version != CassetteVersion {
+		return nil, fmt.Errorf("cassette %s: version %d, expected %d, record it again", path, c.Version, CassetteVersion)
+	}
+
+	return c, nil
+}
+
+// WithCassette requests of all clients with ctx are recorded to or replayed from c
+func WithCassette(ctx context.Context, c *Cassette) context.Context {
+	return context.WithValue(ctx, cassetteKey{}, c)
+}
+
+func cassetteFrom(ctx context.Context) *Cassette {
+	c, _ := ctx.Value(cassetteKey{}).(*Cassette)
+
+	return c
+}
+
+// Save recorded exchanges sorted by url, so the file diff shows only changes of the site
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.SliceStable(c.Exchanges, func(i, j int) bool {
+		return c.Exchanges[i].Method+" "+c.Exchanges[i].Url < c.Exchanges[j].Method+" "+c.Exchanges[j].Url
+	})
+	c.Recorded = time.Now().UTC().Truncate(time.Second)
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// record response, body is read and replaced
+func (c *Cassette) record(req *http.Request, res *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	ex := Exchange{Method: req.Method, Url: req.URL.String(), Status: res.StatusCode, Header: res.Header.Clone()}
+	ex.Header.Del("Set-Cookie")
+	ex.Header.Del(CacheHeader)
+	if utf8.Valid(body) {
+		ex.Body = string(body)
+	} else {
+		ex.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.Exchanges { // the last response of the same request wins
+		if c.Exchanges[i].Method == ex.Method && c.Exchanges[i].Url == ex.Url {
+			c.Exchanges[i] = ex
+			return res, nil
+		}
+	}
+	c.Exchanges = append(c.Exchanges, ex)
+
+	return res, nil
+}
+
+// replay recorded response: the same url, or the same path with the most equal query values,
+// so dynamic params like "startDate=<today>" don't break the replay
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	var (
+		found *Exchange
+		score = -1
+	)
+
+	for i := range c.Exchanges {
+		ex := &c.Exchanges[i]
+		if ex.Method != req.Method {
+			continue
+		}
+		if ex.Url == req.URL.String() {
+			found = ex
+			break
+		}
+
+		u, err := url.Parse(ex.Url)
+		if err != nil || u.Scheme != req.URL.Scheme || u.Host != req.URL.Host || u.Path != req.URL.Path {
+			continue
+		}
+		if s := queryScore(u.Query(), req.URL.Query()); s > score {
+			found, score = ex, s
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL.String())
+	}
+
+	body := []byte(found.Body)
+	if found.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(found.BodyBase64); err != nil {
+			return nil, err
+		}
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(found.Status) + " " + http.StatusText(found.Status),
+		StatusCode:    found.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        found.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// queryScore equal values count, -1 if the queries have different params
+func queryScore(recorded url.Values, query url.Values) int {
+	if len(recorded) != len(query) {
+		return -1
+	}
+
+	score := 0
+	for key, values := range query {
+		rv, ok := recorded[key]
+		if !ok {
+			return -1
+		}
+		if fmt.Sprint(rv) == fmt.Sprint(values) {
+			score++
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,162 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCassette_recordReplay(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"page":"` + r.URL.Query().Get("page") + `"}`))
+	}))
+
+	path := filepath.Join(t.TempDir(), "source.json")
+	c := New(m.FetchConfig{})
+
+	// record
+	recording := NewCassette(path)
+	ctx := WithCassette(context.Background(), recording)
+	for _, u := range []string{
+		svr.URL + "/api?page=2&startDate=2030-01-01",
+		svr.URL + "/api?page=1&startDate=2030-01-01",
+		svr.URL + "/missing",
+	} {
+		res, err := c.Get(ctx, u)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+	if err := recording.Save(); err != nil {
+		t.Fatal(err)
+	}
+	svr.Close()
+
+	// replay without the server
+	replaying, err := LoadCassette(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, replaying.Exchanges, 3)
+	assert.Equal(t, svr.URL+"/api?page=1&startDate=2030-01-01", replaying.Exchanges[0].Url, "sorted by url")
+	assert.Empty(t, replaying.Exchanges[0].Header.Get("Set-Cookie"), "cookies are not recorded")
+
+	ctx = WithCassette(context.Background(), replaying)
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		{
+			name:       "the same url",
+			url:        svr.URL + "/api?page=2&startDate=2030-01-01",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"page":"2"}`,
+		},
+		{
+			name:       "the closest query, dynamic date",
+			url:        svr.URL + "/api?startDate=2031-05-05&page=1",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"page":"1"}`,
+		},
+		{
+			name:       "recorded error status",
+			url:        svr.URL + "/missing",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "not recorded path",
+			url:     svr.URL + "/other",
+			wantErr: ErrNotRecorded,
+		},
+		{
+			name:    "not recorded query params",
+			url:     svr.URL + "/api?page=1",
+			wantErr: ErrNotRecorded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get(ctx, tt.url)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			//goland:noinspection GoUnhandledErrorResult
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func TestLoadCassette_version(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.json")
+	if err := os.WriteFile(path, []byte(`{"version": 0, "exchanges": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadCassette(path)
+	assert.ErrorContains(t, err, "record it again")
+}
+
+func TestWithSourceCassette(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(func() { SetCassettes(ModeLive, "") })
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("agenda"))
+	}))
+	c := New(m.FetchConfig{})
+
+	SetCassettes(ModeRecord, dir)
+	ctx, done, err := WithSourceCassette(context.Background(), "agenda", svr.URL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	res, err := c.Get(ctx, svr.URL)
+	if assert.NoError(t, err) {
+		_ = res.Body.Close()
+	}
+	assert.NoError(t, done())
+	svr.Close()
+
+	SetCassettes(ModeReplay, dir)
+	ctx, _, err = WithSourceCassette(context.Background(), "agenda", svr.URL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	res, err = c.Get(ctx, svr.URL)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		assert.Equal(t, "agenda", string(body))
+	}
+
+	_, _, err = WithSourceCassette(context.Background(), "agenda", svr.URL+"/other")
+	assert.Error(t, err, "not recorded source")
+}
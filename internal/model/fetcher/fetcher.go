@@ -0,0 +1,266 @@
+// Package fetcher shared http client of the sources: timeouts, retries with exponential backoff,
+// per-host rate limit and parallel requests limit, User-Agent, proxy, robots.txt check and on-disk cache
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	Client struct {
+		http      *http.Client
+		userAgent string
+		retries   int
+		backoff   time.Duration
+		limits    limits // of requests to one host
+		robots    bool
+		noCache   bool
+		cacheTtl  time.Duration // 0 - ttl of the cache config
+	}
+)
+
+const (
+	DefaultUserAgent      = "porto-events (+https://github.com/oleksiy-os/porto-events)"
+	defaultTimeout        = 30 * time.Second
+	defaultRetries        = 2
+	defaultBackoff        = time.Second
+	defaultMaxConnections = 4
+	maxBackoff            = 30 * time.Second
+)
+
+var ErrRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[string]*http.Transport) // by proxy url, connections are reused by all clients
+)
+
+// New client by source fetch config. Config is expected to be checked by model.FetchConfig.Validate,
+// wrong values are replaced by defaults
+func New(config m.FetchConfig) *Client {
+	c := &Client{
+		http: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: transport(config.Proxy),
+		},
+		userAgent: DefaultUserAgent,
+		retries:   defaultRetries,
+		backoff:   defaultBackoff,
+		limits:    configLimits(config),
+		robots:    config.Robots,
+		noCache:   config.NoCache,
+		cacheTtl:  time.Duration(config.CacheTtl) * time.Hour,
+	}
+
+	if config.Timeout > 0 {
+		c.http.Timeout = time.Duration(config.Timeout) * time.Second
+	}
+	if config.Retries != 0 {
+		c.retries = max(config.Retries, 0)
+	}
+	if d, err := time.ParseDuration(config.RetryBackoff); err == nil && d >= 0 {
+		c.backoff = d
+	}
+	if config.UserAgent != "" {
+		c.userAgent = config.UserAgent
+	}
+
+	return c
+}
+
+// transport shared by clients with the same proxy
+func transport(proxy string) *http.Transport {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if t, ok := transports[proxy]; ok {
+		return t
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone() // proxy from environment
+	if proxy != "" {
+		if u, err := url.Parse(proxy); err == nil {
+			t.Proxy = http.ProxyURL(u)
+		} else {
+			log.Error("fetcher| wrong proxy, environment proxy will be used ", proxy, err)
+		}
+	}
+	transports[proxy] = t
+
+	return t
+}
+
+// Get request with ctx
+func (c *Client) Get(ctx context.Context, rawUrl string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
+
+// Do send request with client User-Agent (if request has no own), robots.txt check and host limits.
+// Network errors, 5xx and 429 responses are retried with exponential backoff, the last result is returned.
+// GET responses are cached if the cache is enabled by SetCache, see CacheHeader.
+// With cassette in the request context (see WithCassette) responses are recorded or replayed, cache is not used.
+// Status of the response is counted in the source run stats of the request context, see model.WithRunStats
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.do(req)
+	if res != nil {
+		m.RunStatsFrom(req.Context()).HttpStatus(res.StatusCode)
+	}
+
+	return res, err
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	cassette := cassetteFrom(req.Context())
+	if cassette != nil && cassette.mode == ModeReplay {
+		return cassette.replay(req)
+	}
+
+	if c.robots {
+		allowed, err := c.allowed(req)
+		if err != nil {
+			return nil, fmt.Errorf("robots.txt: %w", err)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: %s", ErrRobotsDisallowed, req.URL.String())
+		}
+	}
+
+	var entry *cacheEntry
+	if cassette == nil {
+		var fresh *http.Response
+		if fresh, entry = c.cached(req); fresh != nil {
+			return fresh, nil
+		}
+	}
+
+	retries := c.retries
+	if req.Body != nil && req.GetBody == nil {
+		retries = 0 // body can't be sent again
+	}
+
+	h := limiter(req.URL.Host, c.limits)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		res, err := c.send(h, req)
+		if attempt >= retries || req.Context().Err() != nil || !retryable(res, err) {
+			if err != nil {
+				return nil, err
+			}
+			if cassette != nil {
+				return cassette.record(req, res)
+			}
+			return c.cache(req, res, entry), nil
+		}
+
+		wait := c.wait(attempt, res)
+		if res != nil {
+			log.Warnf("fetcher| %s %s, retry in %s", req.URL.String(), res.Status, wait)
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		} else {
+			log.Warnf("fetcher| %s %s, retry in %s", req.URL.String(), err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// send one request within host limits, host connection is released when response body is closed
+func (c *Client) send(h *hostLimiter, req *http.Request) (*http.Response, error) {
+	if err := h.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		h.release()
+		return nil, err
+	}
+
+	res.Body = &releaseBody{ReadCloser: res.Body, release: h.release}
+
+	return res, nil
+}
+
+// wait before the retry: exponential backoff or Retry-After seconds of the response, not more than maxBackoff
+func (c *Client) wait(attempt int, res *http.Response) time.Duration {
+	wait := c.backoff << attempt
+	if wait > maxBackoff || wait < 0 {
+		wait = maxBackoff
+	}
+
+	if res != nil {
+		if sec, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && sec > 0 {
+			wait = min(time.Duration(sec)*time.Second, maxBackoff)
+		}
+	}
+
+	return wait
+}
+
+// retryable network errors (except not found host), 5xx and 429 responses
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		var (
+			urlErr *url.Error // implements net.Error itself, the cause is checked
+			netErr net.Error
+			dnsErr *net.DNSError
+		)
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false
+		}
+
+		return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+
+	return res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
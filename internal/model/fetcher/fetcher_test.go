@@ -0,0 +1,349 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Get_retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       m.FetchConfig
+		statuses     []int
+		wantStatus   int
+		wantRequests int32
+	}{
+		{
+			name:         "server error, then ok",
+			config:       m.FetchConfig{RetryBackoff: "1ms"},
+			statuses:     []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantRequests: 3,
+		},
+		{
+			name:         "too many requests",
+			config:       m.FetchConfig{RetryBackoff: "1ms"},
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:         "retries are over, last response",
+			config:       m.FetchConfig{RetryBackoff: "1ms", Retries: 1},
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			wantStatus:   http.StatusBadGateway,
+			wantRequests: 2,
+		},
+		{
+			name:         "without retries",
+			config:       m.FetchConfig{Retries: -1},
+			statuses:     []int{http.StatusInternalServerError, http.StatusOK},
+			wantStatus:   http.StatusInternalServerError,
+			wantRequests: 1,
+		},
+		{
+			name:         "client error is not retried",
+			config:       m.FetchConfig{RetryBackoff: "1ms"},
+			statuses:     []int{http.StatusNotFound, http.StatusOK},
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := requests.Add(1) - 1
+				w.WriteHeader(tt.statuses[i])
+			}))
+			defer svr.Close()
+
+			res, err := New(tt.config).Get(context.Background(), svr.URL)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_ = res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+func TestClient_Get_networkError(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	svrUrl := svr.URL
+	svr.Close() // connection refused
+
+	start := time.Now()
+	_, err := New(m.FetchConfig{RetryBackoff: "20ms"}).Get(context.Background(), svrUrl)
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond, "retried twice: 20ms + 40ms")
+
+	start = time.Now()
+	_, err = New(m.FetchConfig{RetryBackoff: "1s"}).Get(context.Background(), "wrongUrl")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second, "not network error, without retries")
+}
+
+func TestClient_Get_cancelDuringBackoff(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer svr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := New(m.FetchConfig{RetryBackoff: "10s"}).Get(ctx, svr.URL)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestClient_Get_userAgent(t *testing.T) {
+	var got string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+	}))
+	defer svr.Close()
+
+	res, err := New(m.FetchConfig{}).Get(context.Background(), svr.URL)
+	if assert.NoError(t, err) {
+		_ = res.Body.Close()
+		assert.Equal(t, DefaultUserAgent, got)
+	}
+
+	res, err = New(m.FetchConfig{UserAgent: "agenda-bot/2.0"}).Get(context.Background(), svr.URL)
+	if assert.NoError(t, err) {
+		_ = res.Body.Close()
+		assert.Equal(t, "agenda-bot/2.0", got)
+	}
+}
+
+func TestClient_Get_runStats(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	stats := m.NewRunStats(m.Source{Name: "agenda"})
+	ctx := m.WithRunStats(context.Background(), stats)
+	c := New(m.FetchConfig{Retries: -1})
+
+	for _, path := range []string{"/", "/event", "/missing"} {
+		res, err := c.Get(ctx, svr.URL+path)
+		if assert.NoError(t, err) {
+			_ = res.Body.Close()
+		}
+	}
+
+	assert.Equal(t, map[int]int{200: 2, 404: 1}, stats.Finish(0, nil).HttpStatus)
+}
+
+func TestClient_Get_maxConnections(t *testing.T) {
+	var (
+		active, maxActive atomic.Int32
+		wg                sync.WaitGroup
+	)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			old := maxActive.Load()
+			if n <= old || maxActive.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer svr.Close()
+
+	c := New(m.FetchConfig{MaxConnections: 2})
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := c.Get(context.Background(), svr.URL)
+			if assert.NoError(t, err) {
+				_, _ = io.Copy(io.Discard, res.Body)
+				_ = res.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxActive.Load(), int32(2))
+}
+
+func TestClient_Get_hostLimits(t *testing.T) {
+	var (
+		active, maxActive atomic.Int32
+		wg                sync.WaitGroup
+	)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			old := maxActive.Load()
+			if n <= old || maxActive.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer svr.Close()
+
+	// sources of the same host with different limits, the strictest ones are used by both
+	configs := []m.FetchConfig{{MaxConnections: 4}, {MaxConnections: 1, RateLimit: 50}}
+	SetSources([]m.Source{{Url: svr.URL + "/agenda", Fetch: configs[0]}, {Url: svr.URL + "/programa", Fetch: configs[1]}})
+	defer SetSources(nil)
+	clients := []*Client{New(configs[0]), New(configs[1])}
+	for _, c := range clients {
+		res, err := c.Get(context.Background(), svr.URL)
+		if assert.NoError(t, err) {
+			_ = res.Body.Close()
+		}
+	}
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			res, err := c.Get(context.Background(), svr.URL)
+			if assert.NoError(t, err) {
+				_, _ = io.Copy(io.Discard, res.Body)
+				_ = res.Body.Close()
+			}
+		}(clients[i%2])
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), maxActive.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "rate limit of the host")
+}
+
+func TestSetSources(t *testing.T) {
+	defer SetSources(nil)
+
+	host := "events.example.pt"
+	SetSources([]m.Source{
+		{Url: "https://" + host + "/agenda", Fetch: m.FetchConfig{RateLimit: 2, MaxConnections: 3}},
+		{Url: "https://" + host + "/programa", Fetch: m.FetchConfig{RateLimit: 1}},
+		{Url: "https://other.example.pt/agenda"},
+	})
+	h := limiter(host, configLimits(m.FetchConfig{}))
+	assert.Equal(t, time.Second, h.interval, "the lowest rate limit of the host")
+	assert.Equal(t, 3, cap(h.conns), "the lowest parallel requests of the host")
+	assert.Same(t, h, limiter(host, configLimits(m.FetchConfig{MaxConnections: 8})), "shared by all sources of the host")
+	assert.Equal(t, time.Duration(0), limiter("other.example.pt", configLimits(m.FetchConfig{})).interval)
+
+	// limits are loosened in the config, the next collection uses them
+	SetSources([]m.Source{{Url: "https://" + host + "/agenda", Fetch: m.FetchConfig{RateLimit: 4}}})
+	h = limiter(host, configLimits(m.FetchConfig{RateLimit: 4}))
+	assert.Equal(t, 250*time.Millisecond, h.interval)
+	assert.Equal(t, defaultMaxConnections, cap(h.conns))
+}
+
+func TestClient_Get_rateLimit(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer svr.Close()
+
+	c := New(m.FetchConfig{RateLimit: 20}) // 50ms between requests
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		res, err := c.Get(context.Background(), svr.URL)
+		if assert.NoError(t, err) {
+			_ = res.Body.Close()
+		}
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestClient_Get_proxy(t *testing.T) {
+	var got string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.String() // absolute url in the proxy request
+	}))
+	defer proxy.Close()
+
+	res, err := New(m.FetchConfig{Proxy: proxy.URL}).Get(context.Background(), "http://events.example.pt/agenda")
+	if assert.NoError(t, err) {
+		_ = res.Body.Close()
+		assert.Equal(t, "http://events.example.pt/agenda", got)
+	}
+}
+
+func TestClient_Get_robots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests.Add(1)
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+		}
+	}))
+	defer svr.Close()
+
+	c := New(m.FetchConfig{Robots: true})
+
+	res, err := c.Get(context.Background(), svr.URL+"/agenda")
+	if assert.NoError(t, err) {
+		_ = res.Body.Close()
+	}
+
+	_, err = c.Get(context.Background(), svr.URL+"/private/events")
+	assert.True(t, errors.Is(err, ErrRobotsDisallowed), err)
+	assert.Equal(t, int32(1), robotsRequests.Load(), "robots.txt is cached")
+}
+
+func Test_parseRobots(t *testing.T) {
+	robotsTxt := `# comment
+User-agent: *
+Disallow: /api/
+Allow: /api/graphql$
+Disallow: /*.pdf$
+
+User-agent: other-bot
+User-agent: porto-events
+Disallow: /agenda/private
+Allow: /agenda/private/open
+
+Sitemap: https://example.pt/sitemap.xml
+`
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{name: "common group, disallowed", userAgent: "bot/1.0", path: "/api/events", want: false},
+		{name: "common group, longer allow wins", userAgent: "bot/1.0", path: "/api/graphql", want: true},
+		{name: "common group, end anchor", userAgent: "bot/1.0", path: "/api/graphql?page=2", want: false},
+		{name: "common group, wildcard", userAgent: "bot/1.0", path: "/files/agenda.pdf", want: false},
+		{name: "common group, not matched", userAgent: "bot/1.0", path: "/agenda/private", want: true},
+		{name: "own group replaces common", userAgent: DefaultUserAgent, path: "/api/events", want: true},
+		{name: "own group, disallowed", userAgent: "Porto-Events/1.1", path: "/agenda/private/1", want: false},
+		{name: "own group, allowed", userAgent: DefaultUserAgent, path: "/agenda/private/open", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := parseRobots(strings.NewReader(robotsTxt), tt.userAgent)
+			assert.Equal(t, tt.want, r.allowed(tt.path))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package fetcher
+
+import (
+	"context"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type (
+	// hostLimiter parallel requests and rate limit of one host with the same limits
+	hostLimiter struct {
+		conns    chan struct{} // slots of parallel requests
+		interval time.Duration // min time between requests, 0 - without limit
+
+		mu   sync.Mutex
+		next time.Time // time slot of the next request
+	}
+
+	// limits of requests to a host
+	limits struct {
+		rateLimit float64 // 0 - without limit
+		maxConns  int
+	}
+
+	limiterKey struct {
+		host string
+		limits
+	}
+)
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[limiterKey]*hostLimiter) // by host and its limits, shared by all clients of the host
+	hostLimits = make(map[string]limits)           // the strictest limits of sources of the host, see SetSources
+)
+
+// SetSources limits of hosts of the sources: the strictest limits of the sources of the same host are used by all
+// of them. Called on every collection, so changed limits of the sources config are used by the next collection
+func SetSources(sources []m.Source) {
+	hosts := make(map[string]limits)
+	for _, s := range sources {
+		u, err := url.Parse(s.Url)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		l := configLimits(s.Fetch)
+		if h, ok := hosts[u.Host]; ok {
+			l = l.stricter(h)
+		}
+		hosts[u.Host] = l
+	}
+
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	hostLimits = hosts
+	limiters = make(map[limiterKey]*hostLimiter) // requests in progress keep their limiters
+}
+
+// configLimits of the fetch config with defaults
+func configLimits(config m.FetchConfig) limits {
+	l := limits{rateLimit: max(config.RateLimit, 0), maxConns: defaultMaxConnections}
+	if config.MaxConnections > 0 {
+		l.maxConns = int(config.MaxConnections)
+	}
+
+	return l
+}
+
+// stricter limits of l and other: the lowest rate limit and parallel requests
+func (l limits) stricter(other limits) limits {
+	if other.rateLimit > 0 && (l.rateLimit == 0 || other.rateLimit < l.rateLimit) {
+		l.rateLimit = other.rateLimit
+	}
+	l.maxConns = min(l.maxConns, other.maxConns)
+
+	return l
+}
+
+// limiter of the host with the client limits, or with the stricter limits of sources of the host
+func limiter(host string, l limits) *hostLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	if h, ok := hostLimits[host]; ok {
+		l = l.stricter(h)
+	}
+
+	key := limiterKey{host: host, limits: l}
+	h, ok := limiters[key]
+	if !ok {
+		h = &hostLimiter{conns: make(chan struct{}, l.maxConns)}
+		if l.rateLimit > 0 {
+			h.interval = time.Duration(float64(time.Second) / l.rateLimit)
+		}
+		limiters[key] = h
+	}
+
+	return h
+}
+
+// acquire connection slot and wait for the rate limit time slot
+func (h *hostLimiter) acquire(ctx context.Context) error {
+	select {
+	case h.conns <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if h.interval == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	slot := h.next
+	if now := time.Now(); slot.Before(now) {
+		slot = now
+	}
+	h.next = slot.Add(h.interval)
+	h.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		h.release()
+		return ctx.Err()
+	}
+}
+
+func (h *hostLimiter) release() {
+	<-h.conns
+}
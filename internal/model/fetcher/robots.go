@@ -0,0 +1,195 @@
+package fetcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// robots rules of robots.txt group for our User-Agent
+	robots struct {
+		rules []robotsRule
+	}
+
+	robotsRule struct {
+		pattern *regexp.Regexp
+		length  int // pattern length, the longest matched rule wins
+		allow   bool
+	}
+
+	robotsEntry struct {
+		mu      sync.Mutex
+		robots  *robots
+		expires time.Time
+	}
+)
+
+const (
+	robotsTTL      = 24 * time.Hour
+	robotsMaxBytes = 500 * 1024
+)
+
+var (
+	robotsCacheMu sync.Mutex
+	robotsCache   = make(map[string]*robotsEntry) // by scheme://host and User-Agent
+)
+
+// allowed request url by robots.txt of the host. Missing robots.txt (4xx) allows everything,
+// server error (5xx) disallows everything until the next check
+func (c *Client) allowed(req *http.Request) (bool, error) {
+	key := req.URL.Scheme + "://" + req.URL.Host + "|" + c.userAgent
+
+	robotsCacheMu.Lock()
+	entry, ok := robotsCache[key]
+	if !ok {
+		entry = &robotsEntry{}
+		robotsCache[key] = entry
+	}
+	robotsCacheMu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.robots == nil || time.Now().After(entry.expires) {
+		r, err := c.loadRobots(req)
+		if err != nil {
+			return false, err
+		}
+		if r == nil {
+			return false, nil // server error, not cached
+		}
+		entry.robots, entry.expires = r, time.Now().Add(robotsTTL)
+	}
+
+	return entry.robots.allowed(req.URL.RequestURI()), nil
+}
+
+// loadRobots robots.txt of the request host, nil on server error
+func (c *Client) loadRobots(req *http.Request) (*robots, error) {
+	robotsUrl := req.URL.Scheme + "://" + req.URL.Host + "/robots.txt"
+
+	r, err := http.NewRequestWithContext(req.Context(), http.MethodGet, robotsUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("User-Agent", c.userAgent)
+
+	res, err := c.send(limiter(req.URL.Host, c.limits), r)
+	if err != nil {
+		return nil, err
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= http.StatusInternalServerError:
+		return nil, nil
+	case res.StatusCode >= http.StatusBadRequest:
+		return &robots{}, nil
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("wrong response status %s", res.Status)
+	}
+
+	return parseRobots(io.LimitReader(res.Body, robotsMaxBytes), c.userAgent), nil
+}
+
+// parseRobots rules of the group for userAgent product name, or of the "*" group if there is no such group
+func parseRobots(body io.Reader, userAgent string) *robots {
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexAny(agent, "/ "); i != -1 {
+		agent = agent[:i]
+	}
+
+	var (
+		own, common        robots
+		hasOwn             bool
+		groupOwn, groupAll bool
+		lastAgent          bool // previous line was "user-agent", the same group continues
+		scanner            = bufio.NewScanner(body)
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !lastAgent { // new group
+				groupOwn, groupAll = false, false
+			}
+			lastAgent = true
+
+			name := strings.ToLower(value)
+			switch {
+			case name == "*":
+				groupAll = true
+			case name == agent:
+				groupOwn, hasOwn = true, true
+			}
+		case "allow", "disallow":
+			lastAgent = false
+			if value == "" {
+				continue // empty disallow allows everything
+			}
+
+			rule := robotsRule{pattern: robotsPattern(value), length: len(value), allow: key == "allow"}
+			if groupOwn {
+				own.rules = append(own.rules, rule)
+			}
+			if groupAll {
+				common.rules = append(common.rules, rule)
+			}
+		}
+	}
+
+	if hasOwn {
+		return &own
+	}
+
+	return &common
+}
+
+// robotsPattern path prefix with "*" wildcards and optional "$" end
+func robotsPattern(path string) *regexp.Regexp {
+	end := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, ".*")
+	if end {
+		expr += "$"
+	}
+
+	return regexp.MustCompile(expr)
+}
+
+// allowed path by the longest matched rule, "allow" wins on the same length
+func (r *robots) allowed(path string) bool {
+	allow, length := true, -1
+
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > length || (rule.length == length && rule.allow) {
+			allow, length = rule.allow, rule.length
+		}
+	}
+
+	return allow
+}
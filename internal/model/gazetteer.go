@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type (
+	// GazetteerEntry point of the local gazetteer: a street, a building of the street by its house number
+	// or a named place. Imported from CSV, see ReadGazetteerCsv
+	GazetteerEntry struct {
+		Name        string      `json:"name"`             // "Rua de Passos Manuel"
+		Number      string      `json:"number,omitempty"` // house number, empty for the point of the street or place
+		Coordinates Coordinates `json:"coordinates"`
+	}
+
+	// Geocode of the address by the gazetteer, cached by the address
+	Geocode struct {
+		Coordinates *Coordinates `json:"coordinates,omitempty"` // nil if the address is not found
+		Name        string       `json:"name,omitempty"`        // matched name of the gazetteer
+		Number      string       `json:"number,omitempty"`      // matched house number, empty for the street point
+	}
+)
+
+// streetAbbreviations of portuguese addresses: "R. de Passos Manuel" is "Rua de Passos Manuel"
+var streetAbbreviations = map[string]string{
+	"r": "rua", "av": "avenida", "avd": "avenida", "avda": "avenida", "pc": "praca", "pca": "praca",
+	"pr": "praca", "prc": "praca", "tv": "travessa", "trav": "travessa", "lg": "largo", "lgo": "largo",
+	"al": "alameda", "estr": "estrada", "est": "estrada", "cc": "calcada", "calc": "calcada", "bc": "beco",
+	"bco": "beco", "cam": "caminho", "qta": "quinta", "d": "dom", "dr": "doutor", "eng": "engenheiro",
+	"prof": "professor", "gen": "general", "cap": "capitao", "s": "sao", "sta": "santa", "sto": "santo",
+}
+
+// streetStopWords dropped from street names: "Rua de Passos Manuel" is "rua passos manuel", "n.º" of house numbers
+var streetStopWords = map[string]bool{
+	"de": true, "da": true, "do": true, "das": true, "dos": true, "e": true, "n": true, "nº": true, "º": true, "no": true,
+}
+
+// StreetWords of the portuguese street name or address for comparison: lower case words without accents,
+// punctuation and stop words, with expanded abbreviations. "R. de Passos Manuel, n.º 137" -> "rua passos manuel 137"
+func StreetWords(name string) []string {
+	var list []string
+	for _, w := range strings.FieldsFunc(Unaccent.Replace(strings.ToLower(name)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != 'º'
+	}) {
+		if v, ok := streetAbbreviations[w]; ok {
+			w = v
+		}
+		if !streetStopWords[w] {
+			list = append(list, w)
+		}
+	}
+
+	return list
+}
+
+// NormalizeStreet name for comparison, see StreetWords
+func NormalizeStreet(name string) string {
+	return strings.Join(StreetWords(name), " ")
+}
+
+// ReadGazetteerCsv entries of the gazetteer from CSV "name,number,latitude,longitude", with optional header.
+// Number is empty for points of streets and named places. OpenStreetMap extracts are exported to it with
+// "addr:street", "addr:housenumber" and coordinates of nodes
+func ReadGazetteerCsv(r io.Reader) ([]GazetteerEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var list []GazetteerEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return list, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lat, errLat := strconv.ParseFloat(record[2], 64)
+		lon, errLon := strconv.ParseFloat(record[3], 64)
+		switch {
+		case (errLat != nil || errLon != nil) && line == 1: // header
+			continue
+		case errLat != nil || errLon != nil:
+			return nil, fmt.Errorf("line %d: wrong coordinates %q, %q", line, record[2], record[3])
+		case strings.TrimSpace(record[0]) == "":
+			return nil, fmt.Errorf("line %d: without name", line)
+		}
+
+		list = append(list, GazetteerEntry{
+			Name:        strings.TrimSpace(record[0]),
+			Number:      strings.TrimSpace(record[1]),
+			Coordinates: Coordinates{Latitude: lat, Longitude: lon},
+		})
+	}
+}
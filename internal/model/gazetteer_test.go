@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeStreet(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Rua de Passos Manuel", want: "rua passos manuel"},
+		{name: "R. de Passos Manuel, n.º 137", want: "rua passos manuel 137"},
+		{name: "Av. da Boavista", want: "avenida boavista"},
+		{name: "Pç. D. João I", want: "praca dom joao i"},
+		{name: "Rua 31 de Janeiro", want: "rua 31 janeiro"},
+		{name: "Rua Sta. Catarina", want: "rua santa catarina"},
+		{name: " , ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeStreet(tt.name))
+		})
+	}
+}
+
+func TestReadGazetteerCsv(t *testing.T) {
+	entries, err := ReadGazetteerCsv(strings.NewReader("name,number,latitude,longitude\n" +
+		"Rua de Passos Manuel,,41.14725,-8.6059\n" +
+		"Rua de Passos Manuel, 137, 41.146992, -8.605417\n"))
+	if assert.NoError(t, err) && assert.Len(t, entries, 2) {
+		assert.Equal(t, GazetteerEntry{Name: "Rua de Passos Manuel", Coordinates: Coordinates{Latitude: 41.14725, Longitude: -8.6059}}, entries[0])
+		assert.Equal(t, "137", entries[1].Number)
+	}
+
+	entries, err = ReadGazetteerCsv(strings.NewReader("Teatro Rivoli,,41.1484,-8.6105\n"))
+	if assert.NoError(t, err, "without header") {
+		assert.Len(t, entries, 1)
+	}
+
+	_, err = ReadGazetteerCsv(strings.NewReader("name,number,latitude,longitude\nRua Nova,,north,west\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = ReadGazetteerCsv(strings.NewReader(",,41.1,-8.6\n"))
+	assert.ErrorContains(t, err, "without name")
+
+	_, err = ReadGazetteerCsv(strings.NewReader("Rua Nova,41.1,-8.6\n"))
+	assert.Error(t, err, "wrong count of fields")
+}
@@ -0,0 +1,223 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Recurrence schedule of the event repeating within its dates: open weekdays with hours, except closed days
+	Recurrence struct {
+		From   time.Time   `json:"from"`             // the first day, midnight in Lisbon
+		Until  time.Time   `json:"until"`            // the last day, midnight in Lisbon
+		Days   []DayHours  `json:"days"`             // open weekdays, a weekday may have several hours ranges
+		Except []time.Time `json:"except,omitempty"` // closed days, midnight in Lisbon
+	}
+
+	// DayHours opening hours on the weekday. Without hours (both zero) the event is open all day,
+	// Close before Open - closes after midnight, Close equal to Open - starts at Open, the end is unknown
+	DayHours struct {
+		Weekday time.Weekday `json:"weekday"`
+		Open    Clock        `json:"open"`
+		Close   Clock        `json:"close"`
+	}
+
+	// Clock time of the day in minutes after midnight, "21:30" in JSON
+	Clock int
+
+	// Occurrence of the event, End is zero if unknown
+	Occurrence struct {
+		Start  time.Time `json:"start"`
+		End    time.Time `json:"end"`
+		AllDay bool      `json:"all_day"`
+	}
+
+	// EventOccurrence the event on one of its occurrences, see ExpandOccurrences
+	EventOccurrence struct {
+		Occurrence
+		Event Event `json:"event"`
+	}
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseWeekday english weekday name, full or abbreviated: "mon", "Monday"
+func ParseWeekday(name string) (time.Weekday, bool) {
+	wd, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+
+	return wd, ok
+}
+
+// ClockOf time of the day of t in Lisbon
+func ClockOf(t time.Time) Clock {
+	t = t.In(Lisbon)
+
+	return Clock(t.Hour()*60 + t.Minute())
+}
+
+// ParseClock "21:30"
+func ParseClock(v string) (Clock, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, err
+	}
+
+	return Clock(t.Hour()*60 + t.Minute()), nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", int(c)/60, int(c)%60)
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(text []byte) error {
+	clock, err := ParseClock(string(text))
+	if err != nil {
+		return err
+	}
+	*c = clock
+
+	return nil
+}
+
+// on the day (midnight in Lisbon) at the clock time
+func (c Clock) on(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(c)/60, int(c)%60, 0, 0, Lisbon)
+}
+
+// DaysText open weekdays of the schedule: "mon, tue, sat"
+func (r *Recurrence) DaysText() string {
+	var names []string
+	seen := make(map[time.Weekday]bool)
+	for _, d := range r.sortedDays() {
+		if !seen[d.Weekday] {
+			seen[d.Weekday] = true
+			names = append(names, strings.ToLower(d.Weekday.String()[:3]))
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// sortedDays from monday to sunday
+func (r *Recurrence) sortedDays() []DayHours {
+	days := append([]DayHours(nil), r.Days...)
+	sort.SliceStable(days, func(i, j int) bool {
+		return (days[i].Weekday+6)%7 < (days[j].Weekday+6)%7
+	})
+
+	return days
+}
+
+// closed on the day, midnight in Lisbon
+func (r *Recurrence) closed(day time.Time) bool {
+	for _, d := range r.Except {
+		if LisbonDate(d.In(Lisbon)).Equal(day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// occurrences of the schedule overlapping [from, to), sorted by start
+func (r *Recurrence) occurrences(from time.Time, to time.Time) []Occurrence {
+	var list []Occurrence
+
+	first := LisbonDate(from.In(Lisbon)).AddDate(0, 0, -1) // the day before may last after midnight
+	if fromDay := LisbonDate(r.From.In(Lisbon)); fromDay.After(first) {
+		first = fromDay
+	}
+	last := LisbonDate(to.In(Lisbon))
+	if untilDay := LisbonDate(r.Until.In(Lisbon)); !r.Until.IsZero() && untilDay.Before(last) {
+		last = untilDay
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if r.closed(day) {
+			continue
+		}
+		for _, hours := range r.Days {
+			if hours.Weekday != day.Weekday() {
+				continue
+			}
+			if o := hours.occurrence(day); o.overlaps(from, to) {
+				list = append(list, o)
+			}
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Start.Before(list[j].Start) })
+
+	return list
+}
+
+// occurrence of the opening hours on the day, midnight in Lisbon
+func (h DayHours) occurrence(day time.Time) Occurrence {
+	switch {
+	case h.Open == 0 && h.Close == 0:
+		return Occurrence{Start: day, End: day.AddDate(0, 0, 1), AllDay: true}
+	case h.Close == h.Open:
+		return Occurrence{Start: h.Open.on(day)}
+	case h.Close < h.Open:
+		return Occurrence{Start: h.Open.on(day), End: h.Close.on(day.AddDate(0, 0, 1))}
+	}
+
+	return Occurrence{Start: h.Open.on(day), End: h.Close.on(day)}
+}
+
+// overlaps [from, to), occurrence without end is a moment of its start
+func (o Occurrence) overlaps(from time.Time, to time.Time) bool {
+	if o.End.IsZero() {
+		return !o.Start.Before(from) && o.Start.Before(to)
+	}
+
+	return o.Start.Before(to) && o.End.After(from)
+}
+
+// Occurrences of the event overlapping [from, to): every open day of the recurrence schedule,
+// or the whole event without schedule
+func (e Event) Occurrences(from time.Time, to time.Time) []Occurrence {
+	if e.Start.IsZero() {
+		return nil
+	}
+
+	if e.Recurrence != nil {
+		return e.Recurrence.occurrences(from, to)
+	}
+
+	o := Occurrence{Start: e.Start, End: e.End, AllDay: e.AllDay}
+	if !o.Start.Before(to) || !e.EndTime().After(from) {
+		return nil
+	}
+
+	return []Occurrence{o}
+}
+
+// ExpandOccurrences events on their occurrences overlapping [from, to), sorted by start.
+// Answers "what's on": ExpandOccurrences(events, saturday18h, sunday0h)
+func ExpandOccurrences(events []Event, from time.Time, to time.Time) []EventOccurrence {
+	var list []EventOccurrence
+	for _, ev := range events {
+		for _, o := range ev.Occurrences(from, to) {
+			list = append(list, EventOccurrence{Occurrence: o, Event: ev})
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Start.Before(list[j].Start) })
+
+	return list
+}
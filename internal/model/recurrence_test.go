@@ -0,0 +1,222 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvent_Occurrences(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2030, time.March, day, hour, min, 0, 0, Lisbon) // 2030-03-01 is friday, summer time since 31 march
+	}
+
+	exhibition := Event{
+		Start: at(1, 10, 0),
+		End:   at(31, 18, 0),
+		Recurrence: &Recurrence{
+			From:  at(1, 0, 0),
+			Until: at(31, 0, 0),
+			Days: []DayHours{
+				{Weekday: time.Saturday, Open: 10 * 60, Close: 13 * 60},
+				{Weekday: time.Saturday, Open: 15 * 60, Close: 18 * 60},
+				{Weekday: time.Sunday, Open: 10 * 60, Close: 18 * 60},
+			},
+			Except: []time.Time{at(3, 0, 0)},
+		},
+	}
+	party := Event{
+		Start: at(1, 23, 0),
+		End:   at(10, 4, 0),
+		Recurrence: &Recurrence{
+			From:  at(1, 0, 0),
+			Until: at(9, 0, 0),
+			Days:  []DayHours{{Weekday: time.Friday, Open: 23 * 60, Close: 4 * 60}},
+		},
+	}
+	concerts := Event{
+		Start: at(1, 21, 0),
+		End:   at(31, 21, 0),
+		Recurrence: &Recurrence{
+			From:  at(1, 0, 0),
+			Until: at(31, 0, 0),
+			Days:  []DayHours{{Weekday: time.Friday, Open: 21 * 60, Close: 21 * 60}},
+		},
+	}
+	market := Event{
+		Start:      at(1, 0, 0),
+		End:        at(31, 0, 0),
+		AllDay:     true,
+		Recurrence: &Recurrence{From: at(1, 0, 0), Until: at(30, 0, 0), Days: []DayHours{{Weekday: time.Saturday}}},
+	}
+	var oneOff Event
+	oneOff.SetDates(at(2, 20, 0), at(2, 22, 0), false)
+
+	tests := []struct {
+		name string
+		ev   Event
+		from time.Time
+		to   time.Time
+		want []Occurrence
+	}{
+		{
+			name: "several hours ranges of the day",
+			ev:   exhibition,
+			from: at(2, 0, 0),
+			to:   at(3, 0, 0),
+			want: []Occurrence{
+				{Start: at(2, 10, 0), End: at(2, 13, 0)},
+				{Start: at(2, 15, 0), End: at(2, 18, 0)},
+			},
+		},
+		{
+			name: "closed day excepted",
+			ev:   exhibition,
+			from: at(3, 0, 0),
+			to:   at(4, 0, 0),
+		},
+		{
+			name: "partly overlapped window",
+			ev:   exhibition,
+			from: at(9, 12, 0),
+			to:   at(10, 11, 0),
+			want: []Occurrence{
+				{Start: at(9, 10, 0), End: at(9, 13, 0)},
+				{Start: at(9, 15, 0), End: at(9, 18, 0)},
+				{Start: at(10, 10, 0), End: at(10, 18, 0)},
+			},
+		},
+		{
+			name: "summer time",
+			ev:   exhibition,
+			from: at(31, 0, 0),
+			to:   at(31, 23, 0),
+			want: []Occurrence{{Start: at(31, 10, 0), End: at(31, 18, 0)}},
+		},
+		{
+			name: "after midnight of the previous day",
+			ev:   party,
+			from: at(9, 2, 0),
+			to:   at(9, 3, 0),
+			want: []Occurrence{{Start: at(8, 23, 0), End: at(9, 4, 0)}},
+		},
+		{
+			name: "after the last day",
+			ev:   party,
+			from: at(15, 0, 0),
+			to:   at(16, 0, 0),
+		},
+		{
+			name: "unknown end",
+			ev:   concerts,
+			from: at(1, 0, 0),
+			to:   at(16, 0, 0),
+			want: []Occurrence{
+				{Start: at(1, 21, 0)},
+				{Start: at(8, 21, 0)},
+				{Start: at(15, 21, 0)},
+			},
+		},
+		{
+			name: "all day",
+			ev:   market,
+			from: at(30, 12, 0),
+			to:   at(31, 12, 0),
+			want: []Occurrence{{Start: at(30, 0, 0), End: at(31, 0, 0), AllDay: true}},
+		},
+		{
+			name: "one-off event",
+			ev:   oneOff,
+			from: at(2, 21, 0),
+			to:   at(3, 0, 0),
+			want: []Occurrence{{Start: at(2, 20, 0), End: at(2, 22, 0)}},
+		},
+		{
+			name: "one-off event outside",
+			ev:   oneOff,
+			from: at(2, 22, 0),
+			to:   at(3, 0, 0),
+		},
+		{
+			name: "without dates",
+			from: at(1, 0, 0),
+			to:   at(31, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.ev.Occurrences(tt.from, tt.to)
+			if assert.Len(t, got, len(tt.want)) {
+				for i := range tt.want {
+					assert.Truef(t, tt.want[i].Start.Equal(got[i].Start), "start %s, want %s", got[i].Start, tt.want[i].Start)
+					assert.Truef(t, tt.want[i].End.Equal(got[i].End), "end %s, want %s", got[i].End, tt.want[i].End)
+					assert.Equal(t, tt.want[i].AllDay, got[i].AllDay)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandOccurrences(t *testing.T) {
+	saturday := time.Date(2030, time.March, 2, 0, 0, 0, 0, Lisbon)
+
+	var concert Event
+	concert.ID = "concert"
+	concert.SetDates(saturday.Add(21*time.Hour), time.Time{}, false)
+	exhibition := Event{
+		ID:    "exhibition",
+		Start: saturday.AddDate(0, 0, -1).Add(10 * time.Hour),
+		End:   saturday.AddDate(0, 0, 10).Add(18 * time.Hour),
+		Recurrence: &Recurrence{
+			From:  saturday.AddDate(0, 0, -1),
+			Until: saturday.AddDate(0, 0, 10),
+			Days:  []DayHours{{Weekday: time.Saturday, Open: 10 * 60, Close: 18 * 60}, {Weekday: time.Sunday, Open: 10 * 60, Close: 18 * 60}},
+		},
+	}
+
+	got := ExpandOccurrences([]Event{concert, exhibition}, saturday, saturday.AddDate(0, 0, 2))
+
+	var ids []string
+	for _, o := range got {
+		ids = append(ids, o.Event.ID+" "+o.Start.Format("Mon 15:04"))
+	}
+	assert.Equal(t, []string{"exhibition Sat 10:00", "concert Sat 21:00", "exhibition Sun 10:00"}, ids)
+	assert.Empty(t, ExpandOccurrences(nil, saturday, saturday.AddDate(0, 0, 1)))
+}
+
+func TestRecurrence_DaysText(t *testing.T) {
+	r := Recurrence{Days: []DayHours{
+		{Weekday: time.Sunday},
+		{Weekday: time.Friday, Open: 10 * 60, Close: 13 * 60},
+		{Weekday: time.Friday, Open: 15 * 60, Close: 18 * 60},
+		{Weekday: time.Monday},
+	}}
+
+	assert.Equal(t, "mon, fri, sun", r.DaysText())
+	assert.Equal(t, "", (&Recurrence{}).DaysText())
+}
+
+func TestClock_JSON(t *testing.T) {
+	hours := DayHours{Weekday: time.Friday, Open: 9*60 + 5, Close: 21*60 + 30}
+
+	data, err := json.Marshal(hours)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"weekday": 5, "open": "09:05", "close": "21:30"}`, string(data))
+
+	var got DayHours
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, hours, got)
+	assert.Error(t, json.Unmarshal([]byte(`{"open": "9h"}`), &got))
+}
+
+func TestParseWeekday(t *testing.T) {
+	for name, want := range map[string]time.Weekday{"mon": time.Monday, " Sunday ": time.Sunday, "SAT": time.Saturday} {
+		got, ok := ParseWeekday(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, ok := ParseWeekday("segunda")
+	assert.False(t, ok)
+}
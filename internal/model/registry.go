@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+type (
+	// SourceInterface implemented by every events source (web scrap OR API)
+	//
+	// LoadEvents should stop all requests and return as soon as ctx is done.
+	// Error is *SourceError if the source failed and has no events,
+	// or ItemErrors of failed events, collected events of other items are returned with it
+	SourceInterface interface {
+		LoadEvents(ctx context.Context, u *url.URL) ([]Event, error)
+	}
+
+	// SourceInfo source metadata, used for registration in the sources registry
+	SourceInfo struct {
+		Name        string            // unique name, the same as "name" in event-sources.toml
+		DisplayName string            // human-readable name
+		Homepage    string            // main page of the events resource
+		Options     map[string]string // supported options from event-sources.toml. Key: option name, value: description
+		New         func(sourceConfig Source) SourceInterface
+		Validate    func(sourceConfig Source) error // optional, source specific config check on the app start
+	}
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]SourceInfo)
+)
+
+// RegisterSource add source to the registry. Usually called from init() of the source package
+//
+// Panics if name is empty, constructor is nil or source with the same name already registered
+func RegisterSource(info SourceInfo) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if info.Name == "" {
+		panic("register source: empty name")
+	}
+	if info.New == nil {
+		panic("register source: nil constructor for " + info.Name)
+	}
+	if _, dup := registry[info.Name]; dup {
+		panic("register source: called twice for " + info.Name)
+	}
+
+	registry[info.Name] = info
+}
+
+// GetSourceInfo registered source by name
+func GetSourceInfo(name string) (SourceInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := registry[name]
+	return info, ok
+}
+
+// SourcesInfo list of all registered sources sorted by name
+func SourcesInfo() []SourceInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]SourceInfo, 0, len(registry))
+	for _, info := range registry {
+		list = append(list, info)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// NewSource create source from config using the registry
+func NewSource(sourceConfig Source) (SourceInterface, error) {
+	info, ok := GetSourceInfo(sourceConfig.Name)
+	if !ok {
+		return nil, fmt.Errorf("undefined source name %q", sourceConfig.Name)
+	}
+
+	return info.New(sourceConfig), nil
+}
+
+// ValidateSources check sources list from config: names are registered, urls are valid, options are supported,
+// priority fields are known and fetch settings are correct
+//
+// All found problems are returned joined in one error
+func ValidateSources(sources []Source) error {
+	var errs []error
+
+	for i, src := range sources {
+		info, ok := GetSourceInfo(src.Name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("source #%d: undefined source name %q", i+1, src.Name))
+			continue
+		}
+
+		if _, err := url.ParseRequestURI(src.Url); err != nil {
+			errs = append(errs, fmt.Errorf("source #%d %q: wrong url %q", i+1, src.Name, src.Url))
+		}
+
+		for opt := range src.Options {
+			if _, ok = info.Options[opt]; !ok {
+				errs = append(errs, fmt.Errorf("source #%d %q: unsupported option %q", i+1, src.Name, opt))
+			}
+		}
+
+		for field := range src.Priority {
+			if _, ok = MergeFields[field]; !ok && field != "default" {
+				errs = append(errs, fmt.Errorf("source #%d %q: unknown priority field %q", i+1, src.Name, field))
+			}
+		}
+
+		if err := src.Fetch.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("source #%d %q: fetch: %w", i+1, src.Name, err))
+		}
+
+		if info.Validate != nil {
+			if err := info.Validate(src); err != nil {
+				errs = append(errs, fmt.Errorf("source #%d %q: %w", i+1, src.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+type fakeSource struct{}
+
+func (s *fakeSource) LoadEvents(_ context.Context, _ *url.URL) ([]Event, error) { return nil, nil }
+
+func init() {
+	RegisterSource(SourceInfo{
+		Name:        "fake",
+		DisplayName: "Fake",
+		Options:     map[string]string{"depth": "how deep to crawl"},
+		New: func(_ Source) SourceInterface {
+			return &fakeSource{}
+		},
+	})
+	RegisterSource(SourceInfo{
+		Name: "fakeselector",
+		New: func(_ Source) SourceInterface {
+			return &fakeSource{}
+		},
+		Validate: func(sourceConfig Source) error {
+			if sourceConfig.Selector == nil {
+				return errors.New("missing selector")
+			}
+			return nil
+		},
+	})
+}
+
+func Test_RegisterSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		info      SourceInfo
+		wantPanic bool
+	}{
+		{
+			name:      "duplicate name",
+			info:      SourceInfo{Name: "fake", New: func(_ Source) SourceInterface { return &fakeSource{} }},
+			wantPanic: true,
+		},
+		{
+			name:      "empty name",
+			info:      SourceInfo{New: func(_ Source) SourceInterface { return &fakeSource{} }},
+			wantPanic: true,
+		},
+		{
+			name:      "nil constructor",
+			info:      SourceInfo{Name: "fake nil"},
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantPanic {
+				assert.Panics(t, func() { RegisterSource(tt.info) })
+			} else {
+				assert.NotPanics(t, func() { RegisterSource(tt.info) })
+			}
+		})
+	}
+
+	info, ok := GetSourceInfo("fake")
+	if assert.True(t, ok, "registered source not found") {
+		assert.Equal(t, "Fake", info.DisplayName)
+	}
+}
+
+func Test_NewSource(t *testing.T) {
+	src, err := NewSource(Source{Name: "fake", Url: "https://fake.com"})
+	assert.NoError(t, err)
+	assert.IsType(t, &fakeSource{}, src)
+
+	_, err = NewSource(Source{Name: "unknown", Url: "https://fake.com"})
+	assert.EqualError(t, err, `undefined source name "unknown"`)
+}
+
+func Test_ValidateSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Source
+		wantErr []string
+	}{
+		{
+			name: "ok",
+			sources: []Source{
+				{Name: "fake", Url: "https://fake.com"},
+				{Name: "fake", Url: "https://fake.com/events", Options: map[string]string{"depth": "2"}},
+			},
+		},
+		{
+			name: "undefined name",
+			sources: []Source{
+				{Name: "fake", Url: "https://fake.com"},
+				{Name: "teatromunicipal", Url: "https://fake.com"},
+			},
+			wantErr: []string{`source #2: undefined source name "teatromunicipal"`},
+		},
+		{
+			name: "wrong url and option",
+			sources: []Source{
+				{Name: "fake", Url: "wrongUrl", Options: map[string]string{"pages": "2"}},
+			},
+			wantErr: []string{
+				`source #1 "fake": wrong url "wrongUrl"`,
+				`source #1 "fake": unsupported option "pages"`,
+			},
+		},
+		{
+			name: "wrong fetch settings",
+			sources: []Source{
+				{Name: "fake", Url: "https://fake.com", Fetch: FetchConfig{RetryBackoff: "2s", RateLimit: 0.5, Proxy: "socks5://127.0.0.1:1080"}},
+				{Name: "fake", Url: "https://fake.com", Fetch: FetchConfig{RetryBackoff: "2", RateLimit: -1, Proxy: "127.0.0.1:1080"}},
+			},
+			wantErr: []string{
+				`source #2 "fake": fetch: wrong retry_backoff "2"`,
+				"wrong rate_limit -1",
+				`wrong proxy "127.0.0.1:1080"`,
+			},
+		},
+		{
+			name: "priority fields",
+			sources: []Source{
+				{Name: "fake", Url: "https://fake.com", Priority: map[string]int{"default": 1, "description": 2}},
+				{Name: "fake", Url: "https://fake.com", Priority: map[string]int{"descr": 2}},
+			},
+			wantErr: []string{`source #2 "fake": unknown priority field "descr"`},
+		},
+		{
+			name: "source validation",
+			sources: []Source{
+				{Name: "fakeselector", Url: "https://fake.com", Selector: &SelectorConfig{}},
+				{Name: "fakeselector", Url: "https://fake.com"},
+			},
+			wantErr: []string{`source #2 "fakeselector": missing selector`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSources(tt.sources)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				for _, want := range tt.wantErr {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+type (
+	// SelectorConfig declarative scraper config of the "selector" source, [source.selector] in event-sources.toml
+	SelectorConfig struct {
+		List   string                   `toml:"list"`   // CSS selector of one event on the listing page
+		Fields map[string]FieldSelector `toml:"fields"` // event fields from the listing item
+		Detail *DetailSelector          `toml:"detail"` // optional, follow "url" field to the event page
+		Date   DateConfig               `toml:"date"`
+	}
+
+	// DetailSelector fields from the event page
+	DetailSelector struct {
+		Wrap   string                   `toml:"wrap"` // CSS selector of event block on the page, default "body"
+		Fields map[string]FieldSelector `toml:"fields"`
+	}
+
+	// FieldSelector how to get one field value.
+	//
+	// Field names: id, title, url, image, description, place, location, date, time
+	FieldSelector struct {
+		Css   string `toml:"css"`   // CSS selector inside of the list item (or detail wrap), empty - item itself
+		Attr  string `toml:"attr"`  // attribute name, empty - element text
+		Regex string `toml:"regex"` // optional, first group (or whole match) of the regex is used
+	}
+
+	// DateConfig how to parse "date" and "time" fields
+	DateConfig struct {
+		Layouts       []string          `toml:"layouts"`        // Go time layouts of "date time" text, tried in order
+		Months        map[string]string `toml:"months"`         // translation table applied before parse, ex.: Fev = "Feb"
+		TimeSeparator string            `toml:"time_separator"` // start and end time separator, default " - "
+		Timezone      string            `toml:"timezone"`       // default Europe/Lisbon
+	}
+)
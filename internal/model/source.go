@@ -12,12 +12,21 @@ import (
 
 type (
 	Source struct {
-		Name string `toml:"name"`
-		Url  string `toml:"url"`
+		Name    string            `toml:"name"`
+		Url     string            `toml:"url"`
+		Timeout uint              `toml:"timeout"` // max time for events collection from the source, in seconds
+		Options map[string]string `toml:"options"` // source specific options, see SourceInfo.Options
+		Fetch   FetchConfig       `toml:"fetch"`   // http client settings
+
+		// Priority of the source fields on merge of duplicate events from other sources, higher wins.
+		// Key: field from MergeFields or "default", 0 by default
+		Priority map[string]int `toml:"priority"`
+
+		Selector *SelectorConfig `toml:"selector"` // only for "selector" source
 	}
 
 	Event struct {
-		ID          string
+		ID          string // "<source name>:<source id>", see EventId
 		Url         string
 		Title       string
 		Description string
@@ -25,14 +34,69 @@ type (
 		Place       string
 		Location    string
 		LocationMap string
-		DateText    string    // Example: "May 12th, 2022 - December 31st, 2022"
-		Days        string    // working days. Ex.: "mon, tue, wed, thu, fri, sat, sun"
-		Time        string    // "10:00 - 18:00"
-		Timestamp   time.Time // for events sort, if event has date range, here will be current date
-		Category    uint8     // 0: New event; 1: publish
+		Coordinates *Coordinates // of the place, nil if the source has no coordinates
+		Venue       string       // id of the known venue of the place, see Venue
+		DateText    string       // Example: "12 May 2022 - 31 Dec 2022", see SetDates
+		Days        string       // working days. Ex.: "mon, tue, wed, thu, fri, sat, sun", see Recurrence.DaysText
+		Time        string       // "10:00 - 18:00"
+		Start       time.Time    // in Lisbon, zero if the source has no dates
+		End         time.Time    // zero if unknown, exclusive midnight after the last day for AllDay events
+		AllDay      bool         // dates without time
+		Recurrence  *Recurrence  // weekly schedule of the event repeating between Start and End, nil for one-off events
+		Category    uint8        // 0: New event; 1: publish
+
+		Source            string   // url of the source from the sources list, set on collection
+		SourceName        string   // name of the source from the sources list, the namespace of ID
+		SourceId          string   // own id of the event in the source, empty if the source has no ids
+		Merged            []string // ids of duplicates from other sources merged into the event
+		PossibleDuplicate string   // id of the event this one probably duplicates, an editor confirms or splits them
+		NotDuplicates     []string // ids of events split from this one by an editor, not detected as duplicates again
+
+		Collected  map[string]string          // values of the fields in the source at the last collection, key: ChangeFields
+		Changes    []EventChange              // changes of the event in the source not reviewed by an editor yet
+		Provenance map[string]FieldProvenance // where values of the fields come from and their locks, key: ChangeFields
+	}
+
+	// FieldProvenance origin of the event field value: collected from the source or edited in the web UI
+	FieldProvenance struct {
+		Source    string    `json:"source,omitempty"` // name of the source of the collected value
+		FetchedAt time.Time `json:"fetchedAt"`        // collection of the value
+		EditedBy  string    `json:"editedBy,omitempty"`
+		EditedAt  time.Time `json:"editedAt"`
+		Locked    bool      `json:"locked,omitempty"` // collections never change the value, set by an editor
+	}
+
+	// EventChange of the event field in the source found on collection
+	EventChange struct {
+		Field string `json:"field"` // from ChangeFields
+		Old   string `json:"old"`
+		New   string `json:"new"`
 	}
 )
 
+// MergeFields fields of events merged from duplicates by sources priority. Key: name in Source.Priority
+var MergeFields = map[string]string{
+	"title":       "Title",
+	"description": "Description",
+	"image":       "Image",
+	"url":         "Url",
+	"place":       "Place, Location, LocationMap, Coordinates and Venue",
+	"dates":       "Start, End, AllDay, Recurrence with texts of them",
+}
+
+// ChangeFields fields of events compared with the source on every collection, in order of the changes list
+var ChangeFields = []string{"title", "description", "image", "url", "place", "location", "dates"}
+
+// Locked field of the event is never changed by collections
+func (e Event) Locked(field string) bool {
+	return e.Provenance[field].Locked
+}
+
+// Edited field of the event by an editor, it is not changed by collections
+func (e Event) Edited(field string) bool {
+	return e.Provenance[field].EditedBy != ""
+}
+
 var StripAllHtml = bluemonday.StrictPolicy()
 
 func GetSources(confPath string) ([]Source, error) {
@@ -1,11 +1,14 @@
 package agendaCulturalPorto
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/dateparse"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
 	log "github.com/sirupsen/logrus"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -13,18 +16,33 @@ import (
 
 type SourceAgendaculturalPorto struct {
 	m.Source
+	client *fetcher.Client
 }
 
-func (s *SourceAgendaculturalPorto) LoadEvents(u *url.URL) []m.Event {
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "agendaculturalporto",
+		DisplayName: "Agenda Cultural Porto",
+		Homepage:    "https://agendaculturalporto.org",
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+	})
+}
+
+// detailWorkers max parallel requests for event pages
+const detailWorkers = 4
+
+func (s *SourceAgendaculturalPorto) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
 	var (
-		ev     m.Event
-		events []m.Event
+		ev       m.Event
+		events   []m.Event
+		itemErrs []*m.ItemError
 	)
 
-	res, err := http.Get(u.String())
+	res, err := s.client.Get(ctx, u.String())
 	if err != nil {
-		log.Error(err, u.String())
-		return events
+		return nil, m.SourceFailed(u.String(), err)
 	}
 
 	//goland:noinspection GoUnhandledErrorResult
@@ -32,15 +50,14 @@ func (s *SourceAgendaculturalPorto) LoadEvents(u *url.URL) []m.Event {
 	// Load the HTML document
 	doc, err := m.LoadContent(res)
 	if err != nil {
-		log.Errorln("error load content ", err)
-		return events
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("load content: %w", err))
 	}
 
 	// Find the review items
 	doc.Find("article.mec-event-article").Each(func(i int, s *goquery.Selection) {
 		title := s.Find(".mec-event-title a")
 		if title.Length() == 0 {
-			log.Error("parse event| not found title", u.String())
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, u.String(), errors.New("not found title")))
 			return
 		}
 		ev = m.Event{
@@ -50,57 +67,85 @@ func (s *SourceAgendaculturalPorto) LoadEvents(u *url.URL) []m.Event {
 		ev.Url, _ = title.Attr("href")
 		srcSet, ok := s.Find(".mec-event-image img").Attr("data-lazy-srcset")
 		if !ok {
-			log.Error("image not found", ev.Url)
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, ev.Url, errors.New("image not found")))
 		} else {
 			ev.Image, err = image(srcSet)
 			if err != nil {
-				log.Error(err, ev.Url)
+				itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, ev.Url, err))
 			}
 		}
 
-		log.Debugln("visiting ev page for more data collect")
-		eventPageUrl, err := url.ParseRequestURI(ev.Url)
-		if err != nil {
-			log.Error("failed event url", ev.Url)
-		}
-		eventPageUrl.Scheme = u.Scheme // need for proper tests work
-		eventPageUrl.Host = u.Host     // need for proper tests work
+		events = append(events, ev)
+	})
 
-		res, err := http.Get(eventPageUrl.String())
-		if err != nil {
-			log.Error(err)
+	// visiting events pages for more data collect
+	loaded := make([]bool, len(events))
+	pageErrs := make([]*m.ItemError, len(events))
+	m.RunParallel(ctx, detailWorkers, len(events), func(ctx context.Context, i int) {
+		if err := s.eventPage(ctx, u, &events[i]); err != nil {
+			pageErrs[i] = m.ItemFailed(m.ItemDetail, events[i].Url, err)
 			return
 		}
+		loaded[i] = true
+	})
 
-		//goland:noinspection GoUnhandledErrorResult
-		defer res.Body.Close()
-		// Load the HTML document
-		d, err := m.LoadContent(res)
-		if err != nil {
-			log.Errorln("error load content ", err)
-			return
-		}
-		el := d.Find(".mec-single-event").First()
-		if el.Length() == 0 {
-			log.Error("parse event page| not found wrap", eventPageUrl)
-			return
-		}
-		ev.Place = el.Find(".mec-single-event-location .author").Text()
-		ev.Location = el.Find(".mec-single-event-location .mec-address").Text()
-		ev.Description = m.StripAllHtml.Sanitize(el.Find(".mec-single-event-description p").Text())
-		ev.Time = el.Find(".mec-single-event-time .mec-events-abbr").Text()
-		ev.DateText = monthPtToEn(el.Find(".mec-single-event-date .mec-events-abbr .mec-start-date-label").Text())
-		ev.Timestamp, err = timestamp(ev.DateText, ev.Time)
-		if err != nil {
-			log.Error("date parse", err, ev.DateText, ev.Time, ev.Url)
-			return
+	var eventsLoaded []m.Event
+	for i, ok := range loaded {
+		if ok {
+			eventsLoaded = append(eventsLoaded, events[i])
 		}
+	}
 
-		log.Print("ev Description ", ev.Description)
-		events = append(events, ev)
-	})
+	return eventsLoaded, m.JoinItemErrors(append(itemErrs, pageErrs...)...)
+}
+
+// eventPage fill event with data from the event page
+func (s *SourceAgendaculturalPorto) eventPage(ctx context.Context, u *url.URL, ev *m.Event) error {
+	log.Debugln("visiting ev page for more data collect", ev.Url)
+	eventPageUrl, err := url.ParseRequestURI(ev.Url)
+	if err != nil {
+		return fmt.Errorf("event url: %w", err)
+	}
+	eventPageUrl.Scheme = u.Scheme // need for proper tests work
+	eventPageUrl.Host = u.Host     // need for proper tests work
+
+	res, err := s.client.Get(ctx, eventPageUrl.String())
+	if err != nil {
+		return err
+	}
 
-	return events
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+	// Load the HTML document
+	d, err := m.LoadContent(res)
+	if err != nil {
+		return fmt.Errorf("load content: %w", err)
+	}
+	el := d.Find(".mec-single-event").First()
+	if el.Length() == 0 {
+		return errors.New("not found wrap .mec-single-event")
+	}
+	ev.Place = el.Find(".mec-single-event-location .author").Text()
+	ev.Location = el.Find(".mec-single-event-location .mec-address").Text()
+	ev.Description = m.StripAllHtml.Sanitize(el.Find(".mec-single-event-description p").Text())
+
+	dateText := el.Find(".mec-single-event-date .mec-events-abbr .mec-start-date-label").Text()
+	endDateText := el.Find(".mec-single-event-date .mec-events-abbr .mec-end-date-label").Text()
+	timeText := el.Find(".mec-single-event-time .mec-events-abbr").Text()
+	evDates, err := dates(dateText, endDateText, timeText, time.Now())
+	if err != nil {
+		return fmt.Errorf("date parse %q %q %q: %w", dateText, endDateText, timeText, err)
+	}
+	ev.SetDates(evDates.Start, evDates.End, evDates.AllDay)
+	if ev.Recurrence = recurrence(evDates, scheduleText(el)); ev.Recurrence != nil {
+		ev.Days = ev.Recurrence.DaysText()
+		if !evDates.AllDay && m.ClockOf(evDates.End) == m.ClockOf(evDates.Start) {
+			ev.Time = evDates.Start.Format("15:04") // unknown end of the day
+		}
+	}
+
+	log.Debugln("ev Description ", ev.Description)
+	return nil
 }
 
 func image(srcSet string) (string, error) {
@@ -120,52 +165,65 @@ func image(srcSet string) (string, error) {
 func New(sourceConfig m.Source) *SourceAgendaculturalPorto {
 	return &SourceAgendaculturalPorto{
 		m.Source{
-			Name: sourceConfig.Name,
-			Url:  sourceConfig.Url,
+			Name:    sourceConfig.Name,
+			Url:     sourceConfig.Url,
+			Options: sourceConfig.Options,
 		},
+		fetcher.New(sourceConfig.Fetch),
 	}
 }
 
-func monthPtToEn(datePt string) string {
-	var month = map[string]string{
-		"Fev": "Feb",
-		"Abr": "Apr",
-		"Mai": "May",
-		"Ago": "Aug",
-		"Set": "Sep",
-		"Out": "Oct",
-		"Dez": "Dec",
-	}
+// dates of the event in Lisbon, date labels may be a range of days, event without time is all day
+//
+// date: "06 Jan 2024", "18"; endDate: " - 20 Jan 2024" or empty
+//
+// timeTxt: "21:00 - 23:30", "21:00" or empty
+func dates(date string, endDate string, timeTxt string, now time.Time) (dateparse.Dates, error) {
+	return dateparse.Parse(date+" "+endDate+" "+timeTxt, now.In(m.Lisbon))
+}
 
-	for pt, en := range month {
-		if strings.Contains(datePt, pt) {
-			return strings.Replace(datePt, pt, en, 1)
+// scheduleText of the event page: the comment of the time, "Horário: ..." paragraphs of the description
+func scheduleText(el *goquery.Selection) string {
+	texts := []string{el.Find(".mec-single-event-time .mec-time-comment").Text()}
+	el.Find(".mec-single-event-description p").Each(func(_ int, p *goquery.Selection) {
+		if text := strings.TrimSpace(p.Text()); strings.HasPrefix(strings.ToLower(text), "horário") {
+			texts = append(texts, text)
 		}
-	}
-	return datePt
-}
+	})
 
-// timestamp generate
-//
-// date: "06 Jan 2023"
-//
-// timeTxt: "21:00 - 23:30"
-func timestamp(date string, timeTxt string) (time.Time, error) {
-	layoutInput := "02 Jan 2006 15:04"
+	return strings.Join(texts, "; ")
+}
 
-	i := strings.Index(timeTxt, " - ")
-	if i != -1 {
-		timeTxt = timeTxt[:i]
+// recurrence weekly schedule of the event lasting several days, by its schedule text.
+// Weekdays of the schedule without hours take the hours of the event start and end.
+// Nil for one day event and without schedule
+func recurrence(d dateparse.Dates, schedule string) *m.Recurrence {
+	if !d.Range || len(d.Days) < 2 {
+		return nil
 	}
 
-	start, err := time.Parse(layoutInput, date+" "+timeTxt)
+	hours, err := dateparse.Schedule(schedule)
 	if err != nil {
-		return time.Now(), err
+		log.Warnln("agendaculturalporto| schedule", schedule, err)
+		return nil
+	}
+	if len(hours) == 0 {
+		return nil
 	}
 
-	if start.Before(time.Now()) {
-		return time.Now().Truncate(time.Hour).In(time.FixedZone("WET", 0)), nil
+	var open, closing m.Clock
+	if !d.AllDay {
+		open, closing = m.ClockOf(d.Start), m.ClockOf(d.End) // the same without end time
+	}
+
+	rec := &m.Recurrence{From: d.Days[0], Until: d.Days[len(d.Days)-1]}
+	for _, h := range hours {
+		day := m.DayHours{Weekday: h.Weekday, Open: m.Clock(h.Start), Close: m.Clock(h.End)}
+		if h.Start == 0 && h.End == 0 {
+			day.Open, day.Close = open, closing
+		}
+		rec.Days = append(rec.Days, day)
 	}
 
-	return start, nil
+	return rec
 }
@@ -1,7 +1,9 @@
 package agendaCulturalPorto
 
 import (
+	"context"
 	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/sourcetest"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
@@ -22,7 +24,8 @@ func TestSourceAgendaculturalPorto_LoadEvents(t *testing.T) {
 	})
 
 	u, _ := url.Parse(source.Url)
-	evs := source.LoadEvents(u)
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
 
 	tests := []struct {
 		name string
@@ -57,12 +60,13 @@ promovido pela Vodafone e Sony Music Portugal em 2020.No dia 6 de Janeiro os Orf
 que conta com Antera na voz e sintetizadores, Filipe Mattos na guitarra,
  André Morais no baixo, Sebastião Bergmann na bateria, Lana Gasparotti 
 nas teclas e Zé Cruz na percussão.Orfélia em estreia ao vivo nos Maus Hábitos`,
-				Image:     "https://agendaculturalporto.org/wp-content/uploads/2022/12/Orfelia-150x150.jpg",
-				Place:     "Maus Hábitos - Espaço de Intervenção Cultural",
-				Location:  "R. de Passos Manuel 178 4º Piso, 4000-382 Porto",
-				DateText:  "06 Jan 2024",
-				Time:      "21:00 - 23:30",
-				Timestamp: time.Date(2024, time.January, 6, 21, 0, 0, 0, time.FixedZone("WET", 0)),
+				Image:    "https://agendaculturalporto.org/wp-content/uploads/2022/12/Orfelia-150x150.jpg",
+				Place:    "Maus Hábitos - Espaço de Intervenção Cultural",
+				Location: "R. de Passos Manuel 178 4º Piso, 4000-382 Porto",
+				DateText: "06 Jan 2024",
+				Time:     "21:00 - 23:30",
+				Start:    time.Date(2024, time.January, 6, 21, 0, 0, 0, model.Lisbon),
+				End:      time.Date(2024, time.January, 6, 23, 30, 0, 0, model.Lisbon),
 			},
 		},
 	}
@@ -78,11 +82,29 @@ nas teclas e Zé Cruz na percussão.Orfélia em estreia ao vivo nos Maus Hábito
 			assert.Equal(t, tt.want.Location, tt.got[0].Location)
 			assert.Equal(t, tt.want.DateText, tt.got[0].DateText)
 			assert.Equal(t, tt.want.Time, tt.got[0].Time)
-			assert.Truef(t, tt.want.Timestamp.Equal(tt.got[0].Timestamp), "want: %s, got: %s", tt.want.Timestamp, tt.got[0].Timestamp)
+			assert.Truef(t, tt.want.Start.Equal(tt.got[0].Start), "want: %s, got: %s", tt.want.Start, tt.got[0].Start)
+			assert.Truef(t, tt.want.End.Equal(tt.got[0].End), "want: %s, got: %s", tt.want.End, tt.got[0].End)
 		})
 	}
 }
 
+// TestSourceAgendaculturalPorto_LoadEvents_replay pages of agendaculturalporto.org saved in tests dir,
+// the cassette is assembled of them. -record flag records them again from the live site,
+// -update writes the events to the golden file
+func TestSourceAgendaculturalPorto_LoadEvents_replay(t *testing.T) {
+	ctx := sourcetest.Replay(t, "tests/replay/agendaculturalporto.json")
+
+	source := New(model.Source{
+		Name: "agendaculturalporto",
+		Url:  "https://agendaculturalporto.org/agenda-maus-habitos-porto",
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(ctx, u)
+	assert.NoError(t, err)
+	sourcetest.Golden(t, "tests/replay/agendaculturalporto.golden.json", evs)
+}
+
 func requestHandler(t *testing.T) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := "tests/"
@@ -105,40 +127,62 @@ func requestHandler(t *testing.T) http.HandlerFunc {
 	}
 }
 
-func Test_timestamp(t *testing.T) {
+func Test_dates(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+
 	type args struct {
 		date    string
+		endDate string
 		timeTxt string
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    time.Time
-		wantErr bool
+		name       string
+		args       args
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantAllDay bool
+		wantErr    bool
 	}{
 		{
-			name: "ok, date in future",
+			name: "ok, winter time",
 			args: args{
 				date:    "06 Jan 2024",
 				timeTxt: "21:00 - 23:30",
 			},
-			want:    time.Date(2024, time.January, 6, 21, 0, 0, 0, time.FixedZone("WET", 0)),
-			wantErr: false,
+			wantStart: time.Date(2024, time.January, 6, 21, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, time.January, 6, 23, 30, 0, 0, time.UTC),
 		},
 		{
-			name: "ok, date in past, use timeNow",
+			name: "ok, summer time",
 			args: args{
-				date:    "02 Jan 2022",
+				date:    "02 Jul 2022",
 				timeTxt: "21:00 - 23:30",
 			},
-			want:    time.Now().Truncate(time.Hour).In(time.FixedZone("WET", 0)),
-			wantErr: false,
+			wantStart: time.Date(2022, time.July, 2, 20, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2022, time.July, 2, 22, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "ends after midnight",
+			args: args{
+				date:    "06 Jan 2024",
+				timeTxt: "23:00 - 02:00",
+			},
+			wantStart: time.Date(2024, time.January, 6, 23, 0, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2024, time.January, 7, 2, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name: "portuguese month",
+			args: args{
+				date:    "10 Setembro 2030",
+				timeTxt: "21h30",
+			},
+			wantStart: time.Date(2030, time.September, 10, 21, 30, 0, 0, model.Lisbon),
 		},
 		{
 			name: "date parse err",
 			args: args{
 				date:    "Jan 2022",
-				timeTxt: "21:00 - 23:30",
+				timeTxt: "25:00 - 23:30",
 			},
 			wantErr: true,
 		},
@@ -148,21 +192,119 @@ func Test_timestamp(t *testing.T) {
 				date:    "06 Jan 2023",
 				timeTxt: "21:00",
 			},
-			wantErr: false,
-			want:    time.Date(2023, time.January, 6, 21, 0, 0, 0, time.FixedZone("WET", 0)),
+			wantStart: time.Date(2023, time.January, 6, 21, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name: "without time",
+			args: args{
+				date: "06 Jan 2023",
+			},
+			wantStart:  time.Date(2023, time.January, 6, 0, 0, 0, 0, model.Lisbon),
+			wantEnd:    time.Date(2023, time.January, 7, 0, 0, 0, 0, model.Lisbon),
+			wantAllDay: true,
+		},
+		{
+			name: "range of days of the same month",
+			args: args{
+				date:    "18",
+				endDate: " - 20 Jan 2024",
+				timeTxt: "21:00 - 23:30",
+			},
+			wantStart: time.Date(2024, time.January, 18, 21, 0, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2024, time.January, 20, 23, 30, 0, 0, model.Lisbon),
+		},
+		{
+			name: "range of days, without time",
+			args: args{
+				date:    "18 Dez 2023",
+				endDate: " - 20 Jan 2024",
+			},
+			wantStart:  time.Date(2023, time.December, 18, 0, 0, 0, 0, model.Lisbon),
+			wantEnd:    time.Date(2024, time.January, 21, 0, 0, 0, 0, model.Lisbon),
+			wantAllDay: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := timestamp(tt.args.date, tt.args.timeTxt)
+			got, err := dates(tt.args.date, tt.args.endDate, tt.args.timeTxt, now)
 			if tt.wantErr {
 				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Truef(t, tt.want.Equal(got), "wrong date, want: %s, got: %s", tt.want, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Truef(t, tt.wantStart.Equal(got.Start), "wrong start, want: %s, got: %s", tt.wantStart, got.Start)
+			assert.Truef(t, tt.wantEnd.Equal(got.End), "wrong end, want: %s, got: %s", tt.wantEnd, got.End)
+			assert.Equal(t, tt.wantAllDay, got.AllDay)
+			assert.Equal(t, model.Lisbon, got.Start.Location())
+		})
+	}
+}
+
+func Test_recurrence(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, model.Lisbon)
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, time.January, day, hour, 0, 0, 0, model.Lisbon)
+	}
+
+	tests := []struct {
+		name     string
+		timeTxt  string
+		schedule string
+		want     []model.DayHours
+	}{
+		{
+			name:     "hours of the schedule",
+			timeTxt:  "10:00 - 18:00",
+			schedule: "5ª feira e 6ª feira, 18h–23h; Sáb, 10h–23h",
+			want: []model.DayHours{
+				{Weekday: time.Thursday, Open: 18 * 60, Close: 23 * 60},
+				{Weekday: time.Friday, Open: 18 * 60, Close: 23 * 60},
+				{Weekday: time.Saturday, Open: 10 * 60, Close: 23 * 60},
+			},
+		},
+		{
+			name:     "hours of the event",
+			timeTxt:  "21:00 - 23:00",
+			schedule: "; Horário: Qui a Sáb",
+			want: []model.DayHours{
+				{Weekday: time.Thursday, Open: 21 * 60, Close: 23 * 60},
+				{Weekday: time.Friday, Open: 21 * 60, Close: 23 * 60},
+				{Weekday: time.Saturday, Open: 21 * 60, Close: 23 * 60},
+			},
+		},
+		{
+			name:     "all day",
+			schedule: "Qui a Sáb",
+			want: []model.DayHours{
+				{Weekday: time.Thursday}, {Weekday: time.Friday}, {Weekday: time.Saturday},
+			},
+		},
+		{name: "without schedule", timeTxt: "21:00 - 23:00", schedule: "; "},
+		{name: "not a schedule", timeTxt: "21:00 - 23:00", schedule: "Sábado, 20 de Janeiro, estreia"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := dates("18", " - 20 Jan 2024", tt.timeTxt, now)
+			assert.NoError(t, err)
+
+			rec := recurrence(d, tt.schedule)
+			if tt.want == nil {
+				assert.Nil(t, rec, "no schedule, no recurrence")
+				return
+			}
+			if assert.NotNil(t, rec) {
+				assert.True(t, at(18, 0).Equal(rec.From), "From %s", rec.From)
+				assert.True(t, at(20, 0).Equal(rec.Until), "Until %s", rec.Until)
+				assert.Equal(t, tt.want, rec.Days)
+				assert.Len(t, model.Event{Start: d.Start, Recurrence: rec}.Occurrences(at(18, 0), at(22, 0)), 3)
 			}
 		})
 	}
+
+	d, err := dates("20 Jan 2024", "", "21:00 - 23:00", now)
+	assert.NoError(t, err)
+	assert.Nil(t, recurrence(d, "Qui a Sáb"), "one day")
 }
 
 func Test_image(t *testing.T) {
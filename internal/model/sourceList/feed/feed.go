@@ -0,0 +1,188 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// RSS 2.0 and Atom 1.0 documents, only fields needed for events collection
+
+type (
+	rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Channel struct {
+			Items []rssItem `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	rssItem struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Guid        string `xml:"guid"`
+		PubDate     string `xml:"pubDate"`
+		Description string `xml:"description"`
+		Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		Enclosures  []struct {
+			Url  string `xml:"url,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"enclosure"`
+		Media []struct {
+			Url    string `xml:"url,attr"`
+			Medium string `xml:"medium,attr"`
+			Type   string `xml:"type,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnail struct {
+			Url string `xml:"url,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	}
+
+	atom struct {
+		XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+		Entries []atomEntry `xml:"entry"`
+	}
+
+	atomEntry struct {
+		Id        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Links     []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+	}
+
+	// item feed entry independent of the feed format
+	item struct {
+		id          string
+		title       string
+		link        string
+		description string
+		image       string
+		published   time.Time
+	}
+)
+
+// parse RSS or Atom feed
+func parse(r io.Reader) ([]item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var feedRss rss
+	if err = xml.Unmarshal(data, &feedRss); err == nil {
+		return rssItems(feedRss), nil
+	}
+
+	var feedAtom atom
+	if err = xml.Unmarshal(data, &feedAtom); err == nil {
+		return atomItems(feedAtom), nil
+	}
+
+	return nil, errors.New("not RSS or Atom feed")
+}
+
+func rssItems(f rss) []item {
+	items := make([]item, 0, len(f.Channel.Items))
+
+	for _, i := range f.Channel.Items {
+		it := item{
+			id:          strings.TrimSpace(i.Guid),
+			title:       strings.TrimSpace(i.Title),
+			link:        strings.TrimSpace(i.Link),
+			description: i.Description,
+			published:   parseTime(i.PubDate),
+		}
+
+		if it.description == "" {
+			it.description = i.Content
+		}
+		if it.id == "" {
+			it.id = it.link
+		}
+
+		for _, e := range i.Enclosures {
+			if strings.HasPrefix(e.Type, "image/") {
+				it.image = e.Url
+				break
+			}
+		}
+		for _, mc := range i.Media {
+			if it.image == "" && (mc.Medium == "image" || strings.HasPrefix(mc.Type, "image/")) {
+				it.image = mc.Url
+			}
+		}
+		if it.image == "" {
+			it.image = i.Thumbnail.Url
+		}
+
+		items = append(items, it)
+	}
+
+	return items
+}
+
+func atomItems(f atom) []item {
+	items := make([]item, 0, len(f.Entries))
+
+	for _, e := range f.Entries {
+		it := item{
+			id:          strings.TrimSpace(e.Id),
+			title:       strings.TrimSpace(e.Title),
+			description: e.Summary,
+			published:   parseTime(e.Published),
+		}
+
+		if it.description == "" {
+			it.description = e.Content
+		}
+		if it.published.IsZero() {
+			it.published = parseTime(e.Updated)
+		}
+
+		for _, l := range e.Links {
+			switch {
+			case (l.Rel == "" || l.Rel == "alternate") && it.link == "":
+				it.link = l.Href
+			case l.Rel == "enclosure" && strings.HasPrefix(l.Type, "image/") && it.image == "":
+				it.image = l.Href
+			}
+		}
+
+		if it.id == "" {
+			it.id = it.link
+		}
+
+		items = append(items, it)
+	}
+
+	return items
+}
+
+// parseTime RSS (RFC 822 and variations) and Atom (RFC 3339) dates
+func parseTime(v string) time.Time {
+	layouts := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700",
+		time.RFC3339,
+	}
+
+	v = strings.TrimSpace(v)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
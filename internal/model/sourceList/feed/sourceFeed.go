@@ -0,0 +1,224 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Lisbon without system zoneinfo
+)
+
+type SourceFeed struct {
+	m.Source
+	dateRule dateRule
+	client   *fetcher.Client
+}
+
+// dateRule how to get the event date from feed item
+type dateRule struct {
+	regex    *regexp.Regexp // nil - use item published date
+	layout   string
+	fields   []string // item fields to search date by regex: title, description
+	location *time.Location
+}
+
+const (
+	defaultDateLayout = "02/01/2006"
+	defaultTimezone   = "Europe/Lisbon"
+)
+
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "feed",
+		DisplayName: "RSS/Atom feed",
+		Options: map[string]string{
+			"date":        `where to get event date: "published" (default) - item publish date, "regex" - extract by date_regex`,
+			"date_regex":  `regular expression to find date text, first group or group named "date" is used`,
+			"date_layout": "Go time layout of found date text, default 02/01/2006",
+			"date_from":   `comma separated item fields to search date: "title", "description". Default both`,
+			"timezone":    "timezone of events dates, default Europe/Lisbon",
+		},
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+	})
+}
+
+func New(sourceConfig m.Source) *SourceFeed {
+	s := &SourceFeed{
+		Source: m.Source{
+			Name:    sourceConfig.Name,
+			Url:     sourceConfig.Url,
+			Options: sourceConfig.Options,
+		},
+		client: fetcher.New(sourceConfig.Fetch),
+	}
+
+	var err error
+	if s.dateRule, err = newDateRule(sourceConfig.Options); err != nil {
+		log.Error("feed source| wrong date options, item published date will be used ", sourceConfig.Url, err)
+		s.dateRule = dateRule{location: time.UTC}
+	}
+
+	return s
+}
+
+func newDateRule(opt map[string]string) (dateRule, error) {
+	rule := dateRule{
+		layout: defaultDateLayout,
+		fields: []string{"title", "description"},
+	}
+
+	tz := opt["timezone"]
+	if tz == "" {
+		tz = defaultTimezone
+	}
+
+	var err error
+	if rule.location, err = time.LoadLocation(tz); err != nil {
+		return rule, err
+	}
+
+	switch opt["date"] {
+	case "", "published":
+		return rule, nil
+	case "regex":
+	default:
+		return rule, errors.New("unknown date option " + opt["date"])
+	}
+
+	if opt["date_regex"] == "" {
+		return rule, errors.New("empty date_regex")
+	}
+
+	if rule.regex, err = regexp.Compile(opt["date_regex"]); err != nil {
+		return rule, err
+	}
+
+	if opt["date_layout"] != "" {
+		rule.layout = opt["date_layout"]
+	}
+
+	if opt["date_from"] != "" {
+		rule.fields = nil
+		for _, f := range strings.Split(opt["date_from"], ",") {
+			rule.fields = append(rule.fields, strings.TrimSpace(f))
+		}
+	}
+
+	return rule, nil
+}
+
+func (s *SourceFeed) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
+	var (
+		events   []m.Event
+		itemErrs []*m.ItemError
+	)
+
+	res, err := s.client.Get(ctx, u.String())
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("wrong response status %s", res.Status))
+	}
+
+	items, err := parse(res.Body)
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("feed parse: %w", err))
+	}
+
+	for _, it := range items {
+		ev, err := s.event(it)
+		if err != nil {
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, it.link, err))
+			continue
+		}
+
+		events = append(events, ev)
+	}
+
+	return events, m.JoinItemErrors(itemErrs...)
+}
+
+func (s *SourceFeed) event(it item) (m.Event, error) {
+	ev := m.Event{
+		ID:          m.StripAllHtml.Sanitize(it.id),
+		Title:       m.StripAllHtml.Sanitize(it.title),
+		Description: strings.TrimSpace(m.StripAllHtml.Sanitize(it.description)),
+		Url:         it.link,
+		Image:       it.image,
+	}
+
+	if ev.ID == "" {
+		return ev, errors.New("item without id and link " + it.title)
+	}
+
+	start, hasTime, err := s.dateRule.date(it)
+	if err != nil {
+		return ev, err
+	}
+
+	ev.SetDates(start, time.Time{}, !hasTime)
+
+	return ev, nil
+}
+
+// date of the event, returns true if date has time
+func (r dateRule) date(it item) (time.Time, bool, error) {
+	if r.regex == nil {
+		if it.published.IsZero() {
+			return it.published, false, errors.New("not found published date")
+		}
+		return it.published.In(r.location), true, nil
+	}
+
+	for _, field := range r.fields {
+		text := it.title
+		if field == "description" {
+			text = m.StripAllHtml.Sanitize(it.description)
+		}
+
+		dateText, ok := r.find(text)
+		if !ok {
+			continue
+		}
+
+		t, err := time.ParseInLocation(r.layout, dateText, r.location)
+		if err != nil {
+			return t, false, err
+		}
+
+		return t, strings.Contains(r.layout, ":04"), nil // layout with minutes has time
+	}
+
+	return time.Time{}, false, errors.New("date not found by regex in " + strings.Join(r.fields, ", "))
+}
+
+// find date text by regex: named group "date", first group or the whole match
+func (r dateRule) find(text string) (string, bool) {
+	match := r.regex.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+
+	if i := r.regex.SubexpIndex("date"); i > 0 {
+		return match[i], true
+	}
+	if len(match) > 1 {
+		return match[1], true
+	}
+
+	return match[0], true
+}
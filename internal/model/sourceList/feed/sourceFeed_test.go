@@ -0,0 +1,192 @@
+package feed
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceFeed_LoadEvents(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	svr := httptest.NewServer(requestHandler(t))
+	defer svr.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		options map[string]string
+		want    []model.Event
+		wantErr string
+	}{
+		{
+			name:    "rss, date by regex",
+			url:     svr.URL + "/rss.xml",
+			wantErr: "date not found by regex",
+			options: map[string]string{
+				"date":       "regex",
+				"date_regex": `(\d{2}/\d{2}/\d{4})`,
+			},
+			want: []model.Event{
+				{
+					ID:          "https://portojazz.example.org/?p=1201",
+					Url:         "https://portojazz.example.org/agenda/maria-joao-quartet/",
+					Title:       "Maria João Quartet | 14/03/2030",
+					Description: "A voz de Maria João regressa ao Porto.Hot Clube, 21h30.",
+					Image:       "https://portojazz.example.org/wp-content/uploads/maria-joao.jpg",
+					DateText:    "14 Mar 2030",
+					Start:       time.Date(2030, time.March, 14, 0, 0, 0, 0, lisbon),
+					AllDay:      true,
+				},
+				{
+					ID:          "https://portojazz.example.org/?p=1202",
+					Url:         "https://portojazz.example.org/agenda/jam-session-passos-manuel/",
+					Title:       "Jam session no Passos Manuel",
+					Description: "Jam aberta a todos os músicos. Data: 21/03/2030",
+					Image:       "https://portojazz.example.org/wp-content/uploads/jam.png",
+					DateText:    "21 Mar 2030",
+					Start:       time.Date(2030, time.March, 21, 0, 0, 0, 0, lisbon),
+					AllDay:      true,
+				},
+			},
+		},
+		{
+			name: "atom, published date",
+			url:  svr.URL + "/atom.xml",
+			want: []model.Event{
+				{
+					ID:          "tag:galeriamunicipal.example.org,2029:exposicao-88",
+					Url:         "https://galeriamunicipal.example.org/exposicoes/paisagens-invisiveis",
+					Title:       "Inauguração: Paisagens Invisíveis",
+					Description: "Exposição coletiva de jovens artistas.",
+					Image:       "https://galeriamunicipal.example.org/img/paisagens.jpg",
+					DateText:    "18 Jan 2030",
+					Time:        "18:00",
+					Start:       time.Date(2030, time.January, 18, 18, 0, 0, 0, lisbon),
+				},
+				{
+					ID:          "tag:galeriamunicipal.example.org,2029:visita-12",
+					Url:         "https://galeriamunicipal.example.org/visitas/12",
+					Title:       "Visita guiada",
+					Description: "Visita guiada com a curadora.",
+					DateText:    "01 Feb 2030",
+					Time:        "11:00",
+					Start:       time.Date(2030, time.February, 1, 11, 0, 0, 0, lisbon),
+				},
+			},
+		},
+		{
+			name:    "not a feed",
+			url:     svr.URL + "/page.html",
+			wantErr: "not RSS or Atom feed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := New(model.Source{Name: "feed", Url: tt.url, Options: tt.options})
+			u, _ := url.Parse(source.Url)
+
+			evs, err := source.LoadEvents(context.Background(), u)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if !assert.Len(t, evs, len(tt.want)) {
+				return
+			}
+
+			for i, want := range tt.want {
+				assert.Equal(t, want.ID, evs[i].ID, "ID")
+				assert.Equal(t, want.Url, evs[i].Url, "Url")
+				assert.Equal(t, want.Title, evs[i].Title, "Title")
+				assert.Equal(t, want.Description, evs[i].Description, "Description")
+				assert.Equal(t, want.Image, evs[i].Image, "Image")
+				assert.Equal(t, want.DateText, evs[i].DateText, "DateText")
+				assert.Equal(t, want.Time, evs[i].Time, "Time")
+				assert.Truef(t, want.Start.Equal(evs[i].Start), "want: %s, got: %s", want.Start, evs[i].Start)
+				assert.Equal(t, want.AllDay, evs[i].AllDay, "AllDay")
+			}
+		})
+	}
+}
+
+func requestHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ".xml") {
+			if _, err := w.Write([]byte("<html><body>Agenda</body></html>")); err != nil {
+				t.Fatal("write|", err)
+			}
+			return
+		}
+
+		file, err := os.ReadFile("tests" + r.URL.Path)
+		if err != nil {
+			t.Fatal("file not found|", err, r.RequestURI)
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		if _, err = w.Write(file); err != nil {
+			t.Fatal("write file|", err)
+		}
+	}
+}
+
+func Test_newDateRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   map[string]string
+		wantRegex bool
+		wantErr   bool
+	}{
+		{name: "default published", options: nil},
+		{name: "published", options: map[string]string{"date": "published"}},
+		{name: "regex", options: map[string]string{"date": "regex", "date_regex": `\d+`}, wantRegex: true},
+		{name: "regex empty", options: map[string]string{"date": "regex"}, wantErr: true},
+		{name: "regex wrong", options: map[string]string{"date": "regex", "date_regex": `(\d+`}, wantErr: true},
+		{name: "unknown date option", options: map[string]string{"date": "updated"}, wantErr: true},
+		{name: "wrong timezone", options: map[string]string{"timezone": "Porto/Ribeira"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDateRule(tt.options)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRegex, got.regex != nil)
+		})
+	}
+}
+
+func Test_dateRule_date(t *testing.T) {
+	rule, err := newDateRule(map[string]string{
+		"date":        "regex",
+		"date_regex":  `Data: (?P<date>\d{2}\.\d{2}\.\d{4} \d{2}:\d{2})`,
+		"date_layout": "02.01.2006 15:04",
+		"date_from":   "description",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got, hasTime, err := rule.date(item{
+		title:       "Data: 01.01.2031 10:00 should be ignored",
+		description: "<p>Concerto. Data: 05.04.2030 21:30</p>",
+	})
+	assert.NoError(t, err)
+	assert.True(t, hasTime)
+	assert.Equal(t, "2030-04-05 21:30", got.Format("2006-01-02 15:04"))
+
+	_, _, err = rule.date(item{description: "sem data"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,379 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Minimal RFC 5545 parser: only VEVENT components and properties needed for events collection
+
+type (
+	property struct {
+		name   string
+		params map[string]string
+		value  string
+	}
+
+	vevent struct {
+		uid          string
+		summary      string
+		description  string
+		location     string
+		url          string
+		image        string
+		geo          string // "41.1579;-8.6291"
+		start        time.Time
+		end          time.Time
+		allDay       bool
+		duration     time.Duration
+		rrule        *rrule
+		exDates      []time.Time
+		recurrenceId time.Time // not zero for modified occurrence of recurring event
+		cancelled    bool
+	}
+
+	rrule struct {
+		freq       string // DAILY, WEEKLY, MONTHLY, YEARLY
+		interval   int
+		count      int
+		until      time.Time
+		byDay      []weekdayNum
+		byMonthDay []int
+	}
+
+	weekdayNum struct {
+		n       int // 0 - every weekday; 1 - first; -1 - last
+		weekday time.Weekday
+	}
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parse VEVENTs from calendar. Floating date-times (without TZID or "Z") are in loc
+func parse(r io.Reader, loc *time.Location) ([]vevent, error) {
+	var (
+		events  []vevent
+		ev      *vevent
+		hasCal  bool
+		nesting []string
+	)
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch p.name {
+		case "BEGIN":
+			nesting = append(nesting, strings.ToUpper(p.value))
+			switch strings.ToUpper(p.value) {
+			case "VCALENDAR":
+				hasCal = true
+			case "VEVENT":
+				ev = &vevent{}
+			}
+			continue
+		case "END":
+			if len(nesting) == 0 || nesting[len(nesting)-1] != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("unexpected END:%s", p.value)
+			}
+			nesting = nesting[:len(nesting)-1]
+			if strings.ToUpper(p.value) == "VEVENT" && ev != nil {
+				events = append(events, *ev)
+				ev = nil
+			}
+			continue
+		}
+
+		// properties of nested components (VALARM in VEVENT) are ignored
+		if ev == nil || nesting[len(nesting)-1] != "VEVENT" {
+			continue
+		}
+
+		if err = ev.set(p, loc); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+
+	if !hasCal {
+		return nil, errors.New("not found VCALENDAR")
+	}
+
+	return events, nil
+}
+
+func (ev *vevent) set(p property, loc *time.Location) error {
+	var err error
+
+	switch p.name {
+	case "UID":
+		ev.uid = p.value
+	case "SUMMARY":
+		ev.summary = unescape(p.value)
+	case "DESCRIPTION":
+		ev.description = unescape(p.value)
+	case "LOCATION":
+		ev.location = unescape(p.value)
+	case "URL":
+		ev.url = p.value
+	case "IMAGE":
+		ev.image = p.value
+	case "ATTACH":
+		if strings.HasPrefix(p.params["FMTTYPE"], "image/") && ev.image == "" {
+			ev.image = p.value
+		}
+	case "GEO":
+		ev.geo = p.value
+	case "STATUS":
+		ev.cancelled = strings.ToUpper(p.value) == "CANCELLED"
+	case "DTSTART":
+		ev.start, ev.allDay, err = parseTime(p, loc)
+	case "DTEND":
+		ev.end, _, err = parseTime(p, loc)
+	case "DURATION":
+		ev.duration, err = parseDuration(p.value)
+	case "RRULE":
+		ev.rrule, err = parseRrule(p.value, loc)
+	case "EXDATE":
+		for _, v := range strings.Split(p.value, ",") {
+			var exDate time.Time
+			if exDate, _, err = parseTime(property{name: p.name, params: p.params, value: v}, loc); err != nil {
+				return err
+			}
+			ev.exDates = append(ev.exDates, exDate)
+		}
+	case "RECURRENCE-ID":
+		ev.recurrenceId, _, err = parseTime(p, loc)
+	}
+
+	return err
+}
+
+// unfold long content lines, see RFC 5545 3.1
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseLine "DTSTART;TZID=Europe/Lisbon:20300112T193000"
+func parseLine(line string) (property, error) {
+	p := property{params: make(map[string]string)}
+
+	// name and params part ends with the first colon not inside quotes
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return p, fmt.Errorf("wrong content line %q", line)
+	}
+
+	p.value = line[colon+1:]
+	parts := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+
+	return p, nil
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseTime DATE or DATE-TIME value. Returns true for DATE (all day) value
+func parseTime(p property, loc *time.Location) (time.Time, bool, error) {
+	v := p.value
+
+	if p.params["VALUE"] == "DATE" || len(v) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", v, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse("20060102T150405Z", v)
+		return t, false, err
+	}
+
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	return t, false, err
+}
+
+// parseDuration "PT1H30M", "P1D", "P1W"
+func parseDuration(v string) (time.Duration, error) {
+	var (
+		d      time.Duration
+		num    string
+		inTime bool
+		sign   time.Duration = 1
+	)
+
+	if strings.HasPrefix(v, "-") {
+		sign = -1
+	}
+	v = strings.TrimLeft(v, "+-")
+	if !strings.HasPrefix(v, "P") {
+		return 0, fmt.Errorf("wrong duration %q", v)
+	}
+
+	for _, c := range v[1:] {
+		if c >= '0' && c <= '9' {
+			num += string(c)
+			continue
+		}
+		if c == 'T' {
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("wrong duration %q", v)
+		}
+		num = ""
+
+		switch {
+		case c == 'W':
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D':
+			d += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("wrong duration %q", v)
+		}
+	}
+
+	return sign * d, nil
+}
+
+// parseRrule "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20300301T000000Z"
+func parseRrule(v string, loc *time.Location) (*rrule, error) {
+	rule := &rrule{interval: 1}
+
+	for _, part := range strings.Split(v, ";") {
+		k, val, _ := strings.Cut(part, "=")
+		var err error
+
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.until, _, err = parseTime(property{value: val, params: map[string]string{}}, loc)
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				var wd weekdayNum
+				if wd, err = parseWeekdayNum(d); err != nil {
+					break
+				}
+				rule.byDay = append(rule.byDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(val, ",") {
+				var n int
+				if n, err = strconv.Atoi(d); err != nil {
+					break
+				}
+				rule.byMonthDay = append(rule.byMonthDay, n)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("wrong rrule part %q", part)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported rrule freq %q", rule.freq)
+	}
+	if rule.interval < 1 {
+		rule.interval = 1
+	}
+
+	return rule, nil
+}
+
+// parseWeekdayNum "MO", "1SA", "-1SU"
+func parseWeekdayNum(v string) (weekdayNum, error) {
+	var wd weekdayNum
+
+	if len(v) < 2 {
+		return wd, fmt.Errorf("wrong weekday %q", v)
+	}
+
+	day, ok := weekdays[strings.ToUpper(v[len(v)-2:])]
+	if !ok {
+		return wd, fmt.Errorf("wrong weekday %q", v)
+	}
+	wd.weekday = day
+
+	if num := v[:len(v)-2]; num != "" {
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return wd, fmt.Errorf("wrong weekday %q", v)
+		}
+		wd.n = n
+	}
+
+	return wd, nil
+}
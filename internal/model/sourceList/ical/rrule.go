@@ -0,0 +1,168 @@
+package ical
+
+import (
+	"sort"
+	"time"
+)
+
+// maxPeriods protection from endless recurrence rules
+const maxPeriods = 5000
+
+// length of the event, used for every occurrence
+func (ev *vevent) length() time.Duration {
+	switch {
+	case !ev.end.IsZero():
+		return ev.end.Sub(ev.start)
+	case ev.duration != 0:
+		return ev.duration
+	case ev.allDay:
+		return 24 * time.Hour
+	}
+
+	return 0
+}
+
+// occurrences start times of the event which overlap [from, to) window
+func (ev *vevent) occurrences(from time.Time, to time.Time) []time.Time {
+	var (
+		list   []time.Time
+		length = ev.length()
+		count  = 0
+	)
+
+	overlaps := func(start time.Time) bool {
+		if length == 0 {
+			return !start.Before(from) && start.Before(to)
+		}
+		return start.Before(to) && start.Add(length).After(from)
+	}
+
+	if ev.rrule == nil {
+		if overlaps(ev.start) {
+			list = append(list, ev.start)
+		}
+		return list
+	}
+
+	r := ev.rrule
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.candidates(ev.start, period)
+
+		for _, c := range candidates {
+			if c.Before(ev.start) {
+				continue
+			}
+			if !r.until.IsZero() && c.After(r.until) {
+				return list
+			}
+			if !c.Before(to) {
+				return list
+			}
+
+			count++
+			if r.count > 0 && count > r.count {
+				return list
+			}
+
+			if !ev.isExcluded(c) && overlaps(c) {
+				list = append(list, c)
+			}
+		}
+	}
+
+	return list
+}
+
+func (ev *vevent) isExcluded(t time.Time) bool {
+	for _, ex := range ev.exDates {
+		if ex.Equal(t) || (ev.allDay && sameDay(ex, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates occurrences of the rule in the period number n (day, week, month or year counted from start)
+func (r *rrule) candidates(start time.Time, n int) []time.Time {
+	var list []time.Time
+	step := n * r.interval
+
+	switch r.freq {
+	case "DAILY":
+		list = append(list, start.AddDate(0, 0, step))
+
+	case "WEEKLY":
+		if len(r.byDay) == 0 {
+			return append(list, start.AddDate(0, 0, 7*step))
+		}
+		// weeks start on Monday (RFC 5545 default WKST=MO)
+		offset := (int(start.Weekday()) + 6) % 7
+		monday := start.AddDate(0, 0, 7*step-offset)
+		for _, wd := range r.byDay {
+			list = append(list, monday.AddDate(0, 0, (int(wd.weekday)+6)%7))
+		}
+
+	case "MONTHLY":
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1,
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		daysInMonth := first.AddDate(0, 1, -1).Day()
+
+		if len(r.byDay) == 0 && len(r.byMonthDay) == 0 {
+			if start.Day() <= daysInMonth {
+				list = append(list, first.AddDate(0, 0, start.Day()-1))
+			}
+		}
+		for _, d := range r.byMonthDay {
+			if d < 0 {
+				d = daysInMonth + d + 1
+			}
+			if d >= 1 && d <= daysInMonth {
+				list = append(list, first.AddDate(0, 0, d-1))
+			}
+		}
+		for _, wd := range r.byDay {
+			list = append(list, monthWeekdays(first, daysInMonth, wd)...)
+		}
+
+	case "YEARLY":
+		t := start.AddDate(step, 0, 0)
+		if t.Day() == start.Day() { // skip 29 Feb in not leap years
+			list = append(list, t)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Before(list[j])
+	})
+
+	return list
+}
+
+// monthWeekdays days of the month matching weekday: all of them or the n-th one (from the end for negative n)
+func monthWeekdays(first time.Time, daysInMonth int, wd weekdayNum) []time.Time {
+	var days []time.Time
+
+	for d := 0; d < daysInMonth; d++ {
+		if t := first.AddDate(0, 0, d); t.Weekday() == wd.weekday {
+			days = append(days, t)
+		}
+	}
+
+	switch {
+	case wd.n > 0 && wd.n <= len(days):
+		return days[wd.n-1 : wd.n]
+	case wd.n < 0 && -wd.n <= len(days):
+		i := len(days) + wd.n
+		return days[i : i+1]
+	case wd.n != 0:
+		return nil
+	}
+
+	return days
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,185 @@
+package ical
+
+import (
+	"context"
+	"fmt"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Lisbon and calendars TZID without system zoneinfo
+)
+
+type SourceIcal struct {
+	m.Source
+	lookAhead time.Duration
+	location  *time.Location
+	client    *fetcher.Client
+}
+
+const (
+	defaultLookAheadDays = 90
+	defaultTimezone      = "Europe/Lisbon"
+)
+
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "ical",
+		DisplayName: "iCalendar feed (.ics)",
+		Options: map[string]string{
+			"lookahead": "days ahead to expand recurring events, default 90",
+			"timezone":  "timezone for date-times without TZID, default Europe/Lisbon",
+		},
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+	})
+}
+
+func New(sourceConfig m.Source) *SourceIcal {
+	s := &SourceIcal{
+		Source: m.Source{
+			Name:    sourceConfig.Name,
+			Url:     sourceConfig.Url,
+			Options: sourceConfig.Options,
+		},
+		lookAhead: defaultLookAheadDays * 24 * time.Hour,
+		client:    fetcher.New(sourceConfig.Fetch),
+	}
+
+	if days, err := strconv.Atoi(sourceConfig.Options["lookahead"]); err == nil && days > 0 {
+		s.lookAhead = time.Duration(days) * 24 * time.Hour
+	}
+
+	tz := sourceConfig.Options["timezone"]
+	if tz == "" {
+		tz = defaultTimezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Error("ical source| wrong timezone, UTC will be used ", tz, err)
+		loc = time.UTC
+	}
+	s.location = loc
+
+	return s
+}
+
+func (s *SourceIcal) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
+	res, err := s.client.Get(ctx, u.String())
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("wrong response status %s", res.Status))
+	}
+
+	vevents, err := parse(res.Body, s.location)
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("ical parse: %w", err))
+	}
+
+	now := time.Now()
+
+	return s.events(vevents, u, now, now.Add(s.lookAhead)), nil
+}
+
+// events expand VEVENTs into events list for [from, to) window
+func (s *SourceIcal) events(vevents []vevent, u *url.URL, from time.Time, to time.Time) []m.Event {
+	var events []m.Event
+
+	// modified occurrences of recurring events replace the original ones
+	overrides := make(map[string]bool)
+	for _, v := range vevents {
+		if !v.recurrenceId.IsZero() {
+			overrides[occurrenceId(v.uid, v.recurrenceId)] = true
+		}
+	}
+
+	for _, v := range vevents {
+		if v.uid == "" || v.start.IsZero() {
+			log.Error("ical| skipped event without UID or DTSTART ", v.summary, u.String())
+			continue
+		}
+
+		if v.cancelled {
+			continue
+		}
+
+		for _, start := range v.occurrences(from, to) {
+			id := v.uid
+			if v.rrule != nil {
+				id = occurrenceId(v.uid, start)
+				if overrides[id] {
+					continue
+				}
+			}
+			if !v.recurrenceId.IsZero() {
+				id = occurrenceId(v.uid, v.recurrenceId)
+			}
+
+			events = append(events, s.event(v, id, start, u))
+		}
+	}
+
+	return events
+}
+
+func (s *SourceIcal) event(v vevent, id string, start time.Time, u *url.URL) m.Event {
+	ev := m.Event{
+		ID:          m.StripAllHtml.Sanitize(id),
+		Title:       m.StripAllHtml.Sanitize(v.summary),
+		Description: m.StripAllHtml.Sanitize(v.description),
+		Url:         v.url,
+		Image:       v.image,
+		Place:       m.StripAllHtml.Sanitize(v.location),
+		Coordinates: coordinates(v.geo),
+	}
+	if ev.Coordinates != nil {
+		ev.LocationMap = ev.Coordinates.MapUrl()
+	}
+
+	if ev.Url == "" {
+		ev.Url = u.String()
+	}
+
+	var end time.Time
+	if length := v.length(); length > 0 {
+		end = start.Add(length)
+	}
+	ev.SetDates(start, end, v.allDay)
+
+	return ev
+}
+
+// occurrenceId stable id of one occurrence of recurring event: "uid/20300112T193000"
+func occurrenceId(uid string, start time.Time) string {
+	return uid + "/" + start.Format("20060102T150405")
+}
+
+// coordinates from GEO property "41.1579;-8.6291", nil if it's empty or wrong
+func coordinates(geo string) *m.Coordinates {
+	lat, lon, ok := strings.Cut(geo, ";")
+	if !ok {
+		return nil
+	}
+
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil
+	}
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &m.Coordinates{Latitude: latitude, Longitude: longitude}
+}
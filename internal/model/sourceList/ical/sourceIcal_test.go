@@ -0,0 +1,320 @@
+package ical
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceIcal_events(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	file, err := os.Open("tests/calendar.ics")
+	if err != nil {
+		t.Fatal("file not found|", err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	vevents, err := parse(file, lisbon)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	source := New(model.Source{Name: "ical", Url: "https://www.casadamusica.com/agenda.ics"})
+	u, _ := url.Parse(source.Url)
+	evs := source.events(
+		vevents,
+		u,
+		time.Date(2030, time.January, 1, 0, 0, 0, 0, lisbon),
+		time.Date(2030, time.June, 1, 0, 0, 0, 0, lisbon),
+	)
+
+	tests := []struct {
+		name string
+		want model.Event
+	}{
+		{
+			name: "single event",
+			want: model.Event{
+				ID:          "concert-4411@casadamusica.com",
+				Url:         "https://www.casadamusica.com/en/agenda/orquestra-sinfonica-beethoven-9",
+				Title:       "Orquestra Sinfónica do Porto, Beethoven 9",
+				Description: "Beethoven&#39;s Ninth Symphony closes the season.\nTickets at the box office.",
+				Image:       "https://www.casadamusica.com/media/beethoven9.jpg",
+				Place:       "Casa da Música - Sala Suggia",
+				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.158889,-8.630556",
+				DateText:    "12 Jan 2030",
+				Time:        "19:30 - 21:00",
+				Start:       time.Date(2030, time.January, 12, 19, 30, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "recurring, first",
+			want: model.Event{
+				ID:       "workshop-77@casadamusica.com/20300305T180000",
+				Url:      "https://www.casadamusica.com/agenda.ics",
+				Title:    "Percussion workshop",
+				Place:    "Casa da Música - Sala 2",
+				DateText: "05 Mar 2030",
+				Time:     "18:00 - 19:30",
+				Start:    time.Date(2030, time.March, 5, 18, 0, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "recurring, second",
+			want: model.Event{
+				ID:       "workshop-77@casadamusica.com/20300307T180000",
+				Url:      "https://www.casadamusica.com/agenda.ics",
+				Title:    "Percussion workshop",
+				Place:    "Casa da Música - Sala 2",
+				DateText: "07 Mar 2030",
+				Time:     "18:00 - 19:30",
+				Start:    time.Date(2030, time.March, 7, 18, 0, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "recurring, after exdate and override",
+			want: model.Event{
+				ID:       "workshop-77@casadamusica.com/20300319T180000",
+				Url:      "https://www.casadamusica.com/agenda.ics",
+				Title:    "Percussion workshop",
+				Place:    "Casa da Música - Sala 2",
+				DateText: "19 Mar 2030",
+				Time:     "18:00 - 19:30",
+				Start:    time.Date(2030, time.March, 19, 18, 0, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "recurring, last by count",
+			want: model.Event{
+				ID:       "workshop-77@casadamusica.com/20300321T180000",
+				Url:      "https://www.casadamusica.com/agenda.ics",
+				Title:    "Percussion workshop",
+				Place:    "Casa da Música - Sala 2",
+				DateText: "21 Mar 2030",
+				Time:     "18:00 - 19:30",
+				Start:    time.Date(2030, time.March, 21, 18, 0, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "modified occurrence",
+			want: model.Event{
+				ID:       "workshop-77@casadamusica.com/20300314T180000",
+				Url:      "https://www.casadamusica.com/agenda.ics",
+				Title:    "Percussion workshop (late session)",
+				Place:    "Casa da Música - Sala 2",
+				DateText: "14 Mar 2030",
+				Time:     "19:00 - 20:30",
+				Start:    time.Date(2030, time.March, 14, 19, 0, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "all day, date range",
+			want: model.Event{
+				ID:       "exhibition-12@casadamusica.com",
+				Url:      "https://www.casadamusica.com/agenda.ics",
+				Title:    "Exhibition: 25 years of music",
+				Place:    "Casa da Música - Foyer",
+				DateText: "01 Apr 2030 - 30 Apr 2030",
+				Time:     "",
+				Start:    time.Date(2030, time.April, 1, 0, 0, 0, 0, lisbon),
+			},
+		},
+	}
+
+	if !assert.Len(t, evs, len(tests), "cancelled and out of window events should be skipped") {
+		t.FailNow()
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want.ID, evs[i].ID, "ID")
+			assert.Equal(t, tt.want.Url, evs[i].Url, "Url")
+			assert.Equal(t, tt.want.Title, evs[i].Title, "Title")
+			assert.Equal(t, tt.want.Description, evs[i].Description, "Description")
+			assert.Equal(t, tt.want.Image, evs[i].Image, "Image")
+			assert.Equal(t, tt.want.Place, evs[i].Place, "Place")
+			assert.Equal(t, tt.want.LocationMap, evs[i].LocationMap, "LocationMap")
+			assert.Equal(t, tt.want.DateText, evs[i].DateText, "DateText")
+			assert.Equal(t, tt.want.Time, evs[i].Time, "Time")
+			assert.Truef(t, tt.want.Start.Equal(evs[i].Start), "want: %s, got: %s", tt.want.Start, evs[i].Start)
+		})
+	}
+
+	allDay := evs[len(evs)-1]
+	assert.True(t, allDay.AllDay, "AllDay")
+	assert.Truef(t, time.Date(2030, time.May, 1, 0, 0, 0, 0, lisbon).Equal(allDay.End), "exclusive end: %s", allDay.End)
+}
+
+func TestSourceIcal_LoadEvents(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, err := os.ReadFile("tests/calendar.ics")
+		if err != nil {
+			t.Fatal("file not found|", err, r.RequestURI)
+		}
+
+		w.Header().Set("Content-Type", "text/calendar")
+		if _, err = w.Write(file); err != nil {
+			t.Fatal("write file|", err)
+		}
+	}))
+	defer svr.Close()
+
+	// yearly all day event is the only one inside of any one year window
+	source := New(model.Source{
+		Name:    "ical",
+		Url:     svr.URL + "/agenda.ics",
+		Options: map[string]string{"lookahead": "366"},
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, evs) {
+		assert.Equal(t, "Noite de São João", evs[0].Title)
+		assert.True(t, strings.HasPrefix(evs[0].ID, "sao-joao@casadamusica.com/"), evs[0].ID)
+		assert.True(t, strings.HasPrefix(evs[0].DateText, "23 Jun"), evs[0].DateText)
+		assert.Empty(t, evs[0].Time)
+	}
+}
+
+func Test_parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		ics     string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "ok",
+			ics:     "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nDTSTART:20300101T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			wantLen: 1,
+		},
+		{
+			name:    "not calendar",
+			ics:     "<html><body>Not found</body></html>",
+			wantErr: true,
+		},
+		{
+			name:    "wrong nesting",
+			ics:     "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:1\nEND:VCALENDAR\n",
+			wantErr: true,
+		},
+		{
+			name:    "wrong date",
+			ics:     "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:1\nDTSTART:2030-01-01\nEND:VEVENT\nEND:VCALENDAR\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported rrule",
+			ics:     "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:1\nDTSTART:20300101T100000Z\nRRULE:FREQ=HOURLY\nEND:VEVENT\nEND:VCALENDAR\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(strings.NewReader(tt.ics), time.UTC)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, got, tt.wantLen)
+		})
+	}
+}
+
+func Test_occurrences(t *testing.T) {
+	start := time.Date(2030, time.January, 31, 20, 0, 0, 0, time.UTC)
+	from := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2030, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rrule string
+		want  []string
+	}{
+		{
+			name:  "daily with interval and count",
+			rrule: "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			want:  []string{"2030-01-31", "2030-02-02", "2030-02-04"},
+		},
+		{
+			name:  "weekly until",
+			rrule: "FREQ=WEEKLY;UNTIL=20300215T000000Z",
+			want:  []string{"2030-01-31", "2030-02-07", "2030-02-14"},
+		},
+		{
+			name:  "monthly skips short months",
+			rrule: "FREQ=MONTHLY;COUNT=4",
+			want:  []string{"2030-01-31", "2030-03-31", "2030-05-31"},
+		},
+		{
+			name:  "monthly last friday",
+			rrule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			want:  []string{"2030-02-22", "2030-03-29", "2030-04-26"},
+		},
+		{
+			name:  "monthly by month day",
+			rrule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=4",
+			want:  []string{"2030-01-31", "2030-02-01", "2030-02-28", "2030-03-01"},
+		},
+		{
+			name:  "yearly out of window",
+			rrule: "FREQ=YEARLY",
+			want:  []string{"2030-01-31"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRrule(tt.rrule, time.UTC)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			ev := vevent{start: start, rrule: rule}
+			var got []string
+			for _, o := range ev.occurrences(from, to) {
+				got = append(got, o.Format("2006-01-02"))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "PT1H30M", want: 90 * time.Minute},
+		{in: "P1D", want: 24 * time.Hour},
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "P1DT2H", want: 26 * time.Hour},
+		{in: "-PT15M", want: -15 * time.Minute},
+		{in: "1H", wantErr: true},
+		{in: "P1H", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDuration(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
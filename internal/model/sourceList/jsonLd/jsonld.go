@@ -0,0 +1,280 @@
+package jsonLd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schema.org Event extracted from JSON-LD, see https://schema.org/Event
+type ldEvent struct {
+	id          string
+	name        string
+	description string
+	url         string
+	image       string
+	start       time.Time
+	end         time.Time
+	allDay      bool // startDate without time
+	place       string
+	address     string
+	latitude    float64
+	longitude   float64
+	hasGeo      bool
+	offers      []string // "12.00 EUR"
+}
+
+// extract schema.org events from all JSON-LD blocks of the document. Dates without zone are in loc
+func extract(doc *goquery.Document, loc *time.Location) ([]ldEvent, []error) {
+	var (
+		events []ldEvent
+		errs   []error
+	)
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(i int, s *goquery.Selection) {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			errs = append(errs, fmt.Errorf("json-ld block #%d: %w", i+1, err))
+			return
+		}
+
+		for _, node := range eventNodes(data) {
+			ev, err := parseEvent(node, loc)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			events = append(events, ev)
+		}
+	})
+
+	return events, errs
+}
+
+// eventNodes find Event objects in JSON-LD data: single object, array, @graph or ItemList
+func eventNodes(data any) []map[string]any {
+	var nodes []map[string]any
+
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			nodes = append(nodes, eventNodes(item)...)
+		}
+	case map[string]any:
+		if isEvent(v) {
+			return append(nodes, v)
+		}
+		if graph, ok := v["@graph"]; ok {
+			nodes = append(nodes, eventNodes(graph)...)
+		}
+		if list, ok := v["itemListElement"]; ok {
+			nodes = append(nodes, eventNodes(list)...)
+		}
+		if item, ok := v["item"]; ok { // ListItem
+			nodes = append(nodes, eventNodes(item)...)
+		}
+	}
+
+	return nodes
+}
+
+// isEvent Event or any of its subtypes: MusicEvent, TheaterEvent, Festival...
+func isEvent(node map[string]any) bool {
+	for _, t := range stringList(node["@type"]) {
+		t = strings.TrimPrefix(t, "http://schema.org/")
+		t = strings.TrimPrefix(t, "https://schema.org/")
+		if strings.HasSuffix(t, "Event") || t == "Festival" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEvent(node map[string]any, loc *time.Location) (ldEvent, error) {
+	ev := ldEvent{
+		id:          text(node["@id"]),
+		name:        text(node["name"]),
+		description: text(node["description"]),
+		url:         text(node["url"]),
+		image:       image(node["image"]),
+	}
+
+	if ev.name == "" {
+		return ev, fmt.Errorf("json-ld event without name")
+	}
+
+	var err error
+	if ev.start, ev.allDay, err = parseDate(text(node["startDate"]), loc); err != nil {
+		return ev, fmt.Errorf("json-ld event %q startDate: %w", ev.name, err)
+	}
+	if end := text(node["endDate"]); end != "" {
+		if ev.end, _, err = parseDate(end, loc); err != nil {
+			return ev, fmt.Errorf("json-ld event %q endDate: %w", ev.name, err)
+		}
+	}
+
+	ev.location(node["location"])
+	ev.offers = offers(node["offers"])
+
+	return ev, nil
+}
+
+func (ev *ldEvent) location(data any) {
+	switch v := data.(type) {
+	case string:
+		ev.place = v
+	case []any:
+		if len(v) > 0 {
+			ev.location(v[0])
+		}
+	case map[string]any:
+		ev.place = text(v["name"])
+		ev.address = address(v["address"])
+
+		if geo, ok := v["geo"].(map[string]any); ok {
+			lat, errLat := number(geo["latitude"])
+			lon, errLon := number(geo["longitude"])
+			if errLat == nil && errLon == nil {
+				ev.latitude, ev.longitude, ev.hasGeo = lat, lon, true
+			}
+		}
+	}
+}
+
+// address string or PostalAddress: "Av. da Boavista 604-610, 4149-071 Porto"
+func address(data any) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case map[string]any:
+		var parts []string
+		if street := text(v["streetAddress"]); street != "" {
+			parts = append(parts, street)
+		}
+
+		city := strings.TrimSpace(text(v["postalCode"]) + " " + text(v["addressLocality"]))
+		if city != "" {
+			parts = append(parts, city)
+		}
+
+		return strings.Join(parts, ", ")
+	}
+
+	return ""
+}
+
+// offers prices: "12.00 EUR", "Free"
+func offers(data any) []string {
+	var list []string
+
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			list = append(list, offers(item)...)
+		}
+	case map[string]any:
+		price := text(v["price"])
+		if price == "" {
+			price = text(v["lowPrice"])
+		}
+		switch {
+		case price == "0" || price == "0.00":
+			list = append(list, "Free")
+		case price != "":
+			list = append(list, strings.TrimSpace(price+" "+text(v["priceCurrency"])))
+		}
+	}
+
+	return list
+}
+
+// image url from string, list or ImageObject
+func image(data any) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case []any:
+		if len(v) > 0 {
+			return image(v[0])
+		}
+	case map[string]any:
+		if u := text(v["url"]); u != "" {
+			return u
+		}
+		return text(v["contentUrl"])
+	}
+
+	return ""
+}
+
+// parseDate ISO 8601 date or date-time, returns true for date without time
+func parseDate(v string, loc *time.Location) (time.Time, bool, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, false, fmt.Errorf("empty date")
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05-0700"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.In(loc), false, nil
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, false, nil
+		}
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", v, loc)
+	return t, true, err
+}
+
+// text value of string, number or {"@value": ...}
+func text(data any) string {
+	switch v := data.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		return text(v["@value"])
+	case []any:
+		if len(v) > 0 {
+			return text(v[0])
+		}
+	}
+
+	return ""
+}
+
+func stringList(data any) []string {
+	switch v := data.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+
+	return nil
+}
+
+func number(data any) (float64, error) {
+	switch v := data.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+
+	return 0, fmt.Errorf("not a number %v", data)
+}
@@ -0,0 +1,230 @@
+package jsonLd
+
+import (
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Lisbon without system zoneinfo
+)
+
+type SourceJsonLd struct {
+	m.Source
+	linksSelector string
+	maxPages      int
+	location      *time.Location
+	client        *fetcher.Client
+}
+
+const (
+	// detailWorkers max parallel requests for event pages
+	detailWorkers   = 4
+	defaultMaxPages = 50
+	defaultTimezone = "Europe/Lisbon"
+)
+
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "jsonld",
+		DisplayName: "schema.org Event (JSON-LD) pages",
+		Options: map[string]string{
+			"links":     "CSS selector of event pages links on the listing page, without it only the listing page is used",
+			"max_pages": "max event pages to visit, default 50",
+			"timezone":  "timezone for dates without offset, default Europe/Lisbon",
+		},
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+	})
+}
+
+func New(sourceConfig m.Source) *SourceJsonLd {
+	s := &SourceJsonLd{
+		Source: m.Source{
+			Name:    sourceConfig.Name,
+			Url:     sourceConfig.Url,
+			Options: sourceConfig.Options,
+		},
+		linksSelector: sourceConfig.Options["links"],
+		maxPages:      defaultMaxPages,
+		client:        fetcher.New(sourceConfig.Fetch),
+	}
+
+	if n, err := strconv.Atoi(sourceConfig.Options["max_pages"]); err == nil && n > 0 {
+		s.maxPages = n
+	}
+
+	tz := sourceConfig.Options["timezone"]
+	if tz == "" {
+		tz = defaultTimezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Error("jsonld source| wrong timezone, UTC will be used ", tz, err)
+		loc = time.UTC
+	}
+	s.location = loc
+
+	return s
+}
+
+func (s *SourceJsonLd) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
+	doc, err := s.page(ctx, u.String())
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), err)
+	}
+
+	var events []m.Event
+
+	links := s.links(doc, u)
+	pagesEvents := make([][]m.Event, len(links))
+	pageErrs := make([]*m.ItemError, len(links))
+	m.RunParallel(ctx, detailWorkers, len(links), func(ctx context.Context, i int) {
+		d, err := s.page(ctx, links[i].String())
+		if err != nil {
+			pageErrs[i] = m.ItemFailed(m.ItemDetail, links[i].String(), err)
+			return
+		}
+		pagesEvents[i] = s.events(d, links[i], false)
+	})
+
+	for _, evs := range pagesEvents {
+		events = append(events, evs...)
+	}
+
+	// event pages usually have more data than the listing, so they go first
+	events = append(events, s.events(doc, u, true)...)
+
+	return unique(events), m.JoinItemErrors(pageErrs...)
+}
+
+func (s *SourceJsonLd) page(ctx context.Context, pageUrl string) (*goquery.Document, error) {
+	res, err := s.client.Get(ctx, pageUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wrong response status %s", res.Status)
+	}
+
+	return m.LoadContent(res)
+}
+
+// links event pages urls from the listing page, without duplicates and limited by max_pages
+func (s *SourceJsonLd) links(doc *goquery.Document, u *url.URL) []*url.URL {
+	var (
+		links []*url.URL
+		seen  = map[string]bool{u.String(): true}
+	)
+
+	if s.linksSelector == "" {
+		return links
+	}
+
+	doc.Find(s.linksSelector).EachWithBreak(func(i int, sel *goquery.Selection) bool {
+		href, ok := sel.Attr("href")
+		if !ok {
+			return true
+		}
+
+		link, err := u.Parse(strings.TrimSpace(href))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return true
+		}
+		link.Fragment = ""
+
+		if !seen[link.String()] {
+			seen[link.String()] = true
+			links = append(links, link)
+		}
+
+		return len(links) < s.maxPages
+	})
+
+	return links
+}
+
+// events from JSON-LD of the page
+func (s *SourceJsonLd) events(doc *goquery.Document, pageUrl *url.URL, isListing bool) []m.Event {
+	var events []m.Event
+
+	ldEvents, errs := extract(doc, s.location)
+	for _, err := range errs {
+		log.Error("jsonld| ", err, pageUrl.String())
+	}
+
+	for _, ld := range ldEvents {
+		events = append(events, event(ld, pageUrl, isListing))
+	}
+
+	return events
+}
+
+func event(ld ldEvent, pageUrl *url.URL, isListing bool) m.Event {
+	ev := m.Event{
+		ID:          m.StripAllHtml.Sanitize(ld.id),
+		Title:       m.StripAllHtml.Sanitize(ld.name),
+		Description: m.StripAllHtml.Sanitize(ld.description),
+		Image:       ld.image,
+		Place:       m.StripAllHtml.Sanitize(ld.place),
+		Location:    m.StripAllHtml.Sanitize(ld.address),
+	}
+
+	ev.Url = pageUrl.String()
+	if ld.url != "" {
+		if eventUrl, err := pageUrl.Parse(ld.url); err == nil {
+			ev.Url = eventUrl.String()
+		}
+	}
+
+	if ev.ID == "" {
+		ev.ID = ev.Url
+		if isListing && ev.Url == pageUrl.String() { // listing page has many events
+			ev.ID += "#" + ld.start.Format("20060102T1504") + "-" + ev.Title
+		}
+	}
+
+	if len(ld.offers) > 0 {
+		ev.Description = strings.TrimSpace(ev.Description + "\nTickets: " + strings.Join(ld.offers, ", "))
+	}
+
+	if ld.hasGeo {
+		ev.Coordinates = &m.Coordinates{Latitude: ld.latitude, Longitude: ld.longitude}
+		ev.LocationMap = ev.Coordinates.MapUrl()
+	}
+
+	end := ld.end
+	if ld.allDay && !end.IsZero() {
+		end = end.AddDate(0, 0, 1) // endDate is the last day
+	}
+	ev.SetDates(ld.start, end, ld.allDay)
+
+	return ev
+}
+
+// unique events by ID, the first found wins
+func unique(events []m.Event) []m.Event {
+	var list []m.Event
+	seen := make(map[string]bool)
+
+	for _, ev := range events {
+		if seen[ev.ID] {
+			continue
+		}
+		seen[ev.ID] = true
+		list = append(list, ev)
+	}
+
+	return list
+}
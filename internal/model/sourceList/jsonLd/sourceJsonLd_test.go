@@ -0,0 +1,209 @@
+package jsonLd
+
+import (
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceJsonLd_LoadEvents(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	svr := httptest.NewServer(requestHandler(t))
+	defer svr.Close()
+
+	source := New(model.Source{
+		Name:    "jsonld",
+		Url:     svr.URL + "/agenda/",
+		Options: map[string]string{"links": ".event-list a.event-link"},
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		want model.Event
+	}{
+		{
+			name: "event page wins over the listing duplicate",
+			want: model.Event{
+				ID:          "https://casadofado.example.pt/agenda/noite-de-fado#event",
+				Url:         "https://casadofado.example.pt/agenda/noite-de-fado",
+				Title:       "Noite de Fado",
+				Description: "Fado tradicional com Ana Moura convidada.\nTickets: 15.00 EUR",
+				Image:       "https://casadofado.example.pt/img/noite-de-fado-1x1.jpg",
+				Place:       "Casa do Fado",
+				Location:    "Rua da Fonte Taurina 32, 4050-263 Porto",
+				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.140775,-8.613424",
+				DateText:    "17 Jan 2030",
+				Time:        "21:30 - 23:00",
+				Start:       time.Date(2030, time.January, 17, 21, 30, 0, 0, lisbon),
+				End:         time.Date(2030, time.January, 17, 23, 0, 0, 0, lisbon),
+			},
+		},
+		{
+			name: "exhibition, dates without time",
+			want: model.Event{
+				ID:          svr.URL + "/agenda/azulejo",
+				Url:         svr.URL + "/agenda/azulejo",
+				Title:       "Azulejo: 500 anos",
+				Description: "Exposição sobre a história do azulejo.\nTickets: Free",
+				Image:       "https://casadofado.example.pt/img/azulejo.jpg",
+				Place:       "Galeria da Casa",
+				Location:    "Rua da Fonte Taurina 34, Porto",
+				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.140800,-8.613500",
+				DateText:    "01 Feb 2030 - 31 Mar 2030",
+				Time:        "",
+				Start:       time.Date(2030, time.February, 1, 0, 0, 0, 0, lisbon),
+				End:         time.Date(2030, time.April, 1, 0, 0, 0, 0, lisbon),
+				AllDay:      true,
+			},
+		},
+	}
+
+	if !assert.Len(t, evs, len(tests)) {
+		t.FailNow()
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want.ID, evs[i].ID, "ID")
+			assert.Equal(t, tt.want.Url, evs[i].Url, "Url")
+			assert.Equal(t, tt.want.Title, evs[i].Title, "Title")
+			assert.Equal(t, tt.want.Description, evs[i].Description, "Description")
+			assert.Equal(t, tt.want.Image, evs[i].Image, "Image")
+			assert.Equal(t, tt.want.Place, evs[i].Place, "Place")
+			assert.Equal(t, tt.want.Location, evs[i].Location, "Location")
+			assert.Equal(t, tt.want.LocationMap, evs[i].LocationMap, "LocationMap")
+			assert.Equal(t, tt.want.DateText, evs[i].DateText, "DateText")
+			assert.Equal(t, tt.want.Time, evs[i].Time, "Time")
+			assert.Truef(t, tt.want.Start.Equal(evs[i].Start), "want: %s, got: %s", tt.want.Start, evs[i].Start)
+			assert.Truef(t, tt.want.End.Equal(evs[i].End), "want: %s, got: %s", tt.want.End, evs[i].End)
+			assert.Equal(t, tt.want.AllDay, evs[i].AllDay, "AllDay")
+		})
+	}
+}
+
+func TestSourceJsonLd_LoadEvents_listingOnly(t *testing.T) {
+	svr := httptest.NewServer(requestHandler(t))
+	defer svr.Close()
+
+	source := New(model.Source{Name: "jsonld", Url: svr.URL + "/agenda/"})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
+	if assert.Len(t, evs, 1) {
+		assert.Equal(t, "Noite de Fado", evs[0].Title)
+		assert.Equal(t, svr.URL+"/agenda/noite-de-fado", evs[0].Url, "relative url resolved")
+		assert.Equal(t, "Casa do Fado", evs[0].Place)
+		assert.Equal(t, "Rua da Fonte Taurina 32, 4050-263 Porto", evs[0].Location)
+	}
+}
+
+func requestHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filePath := "tests/listing.html"
+		if p := strings.TrimRight(r.URL.Path, "/"); p != "/agenda" {
+			filePath = "tests/" + path.Base(p) + ".html"
+		}
+
+		file, err := os.ReadFile(filePath)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err = w.Write(file); err != nil {
+			t.Fatal("write file|", err)
+		}
+	}
+}
+
+func Test_extract(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		wantLen  int
+		wantErrs int
+	}{
+		{
+			name:    "event subtype",
+			html:    `<script type="application/ld+json">{"@type":"TheaterEvent","name":"A","startDate":"2030-01-01"}</script>`,
+			wantLen: 1,
+		},
+		{
+			name:    "item list",
+			html:    `<script type="application/ld+json">{"@type":"ItemList","itemListElement":[{"@type":"ListItem","item":{"@type":"Festival","name":"F","startDate":"2030-06-01"}}]}</script>`,
+			wantLen: 1,
+		},
+		{
+			name: "not events",
+			html: `<script type="application/ld+json">{"@type":"Organization","name":"Org"}</script>`,
+		},
+		{
+			name:     "event without date",
+			html:     `<script type="application/ld+json">{"@type":"Event","name":"No date"}</script>`,
+			wantErrs: 1,
+		},
+		{
+			name:     "broken json, next block still parsed",
+			html:     `<script type="application/ld+json">{"@type":</script><script type="application/ld+json">{"@type":"Event","name":"B","startDate":"2030-01-01T20:00"}</script>`,
+			wantLen:  1,
+			wantErrs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><head>" + tt.html + "</head></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, errs := extract(doc, time.UTC)
+			assert.Len(t, got, tt.wantLen)
+			assert.Len(t, errs, tt.wantErrs)
+		})
+	}
+}
+
+func Test_parseDate(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+
+	tests := []struct {
+		in         string
+		want       time.Time
+		wantAllDay bool
+		wantErr    bool
+	}{
+		{in: "2030-07-10T21:00:00+01:00", want: time.Date(2030, time.July, 10, 21, 0, 0, 0, lisbon)},
+		{in: "2030-07-10T20:00:00Z", want: time.Date(2030, time.July, 10, 21, 0, 0, 0, lisbon)},
+		{in: "2030-07-10T21:00", want: time.Date(2030, time.July, 10, 21, 0, 0, 0, lisbon)},
+		{in: "2030-07-10", want: time.Date(2030, time.July, 10, 0, 0, 0, 0, lisbon), wantAllDay: true},
+		{in: "10 July 2030", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, allDay, err := parseDate(tt.in, lisbon)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAllDay, allDay)
+			assert.Truef(t, tt.want.Equal(got), "want: %s, got: %s", tt.want, got)
+		})
+	}
+}
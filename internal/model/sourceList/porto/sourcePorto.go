@@ -1,21 +1,27 @@
 package porto
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
 	log "github.com/sirupsen/logrus"
 	"io"
-	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type (
 	SourcePorto struct {
-		Name string
-		Url  string
+		Name      string
+		Url       string
+		maxPages  int
+		lookahead int // days, 0 - without limit
+		client    *fetcher.Client
 	}
 
 	Dates struct {
@@ -50,69 +56,140 @@ type (
 	EventList struct {
 		PageByUrl struct {
 			Events struct {
-				Items []EventSource `json:"items"`
+				Items      []EventSource `json:"items"`
+				Pagination Pagination    `json:"pagination"`
 			} `json:"events"`
 		} `json:"pageByUrl"`
 	}
+
+	Pagination struct {
+		CurrentPage int  `json:"currentPage"`
+		NextPage    *int `json:"nextPage"` // null on the last page
+		TotalPages  int  `json:"totalPages"`
+	}
+
+	// pageLimit when to stop walking the api pages
+	pageLimit struct {
+		maxPages int
+		until    time.Time // events starting later are skipped, zero - without limit
+	}
 )
 
-func (s *SourcePorto) LoadEvents(u *url.URL) []m.Event {
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "porto",
+		DisplayName: "Porto.pt",
+		Homepage:    "https://www.porto.pt/en/events",
+		Options: map[string]string{
+			"max_pages": "max api pages to load, default 10",
+			"lookahead": "days ahead, events starting later are skipped and pages are not loaded further, default without limit",
+		},
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+	})
+}
+
+const (
+	// detailWorkers max parallel requests for events description
+	detailWorkers   = 4
+	defaultMaxPages = 10
+	layoutApiDate   = "2006-01-02 15:04:05" // input format in json 2022-05-12 10:00:00
+)
+
+func (s *SourcePorto) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
 	var (
-		event  m.Event
-		events []m.Event
+		events   []m.Event
+		itemErrs []*m.ItemError
+		pageErrs m.ItemErrors
 	)
 
-	eventsData, err := getFromApi(u)
-	if err != nil {
-		log.Errorln("get from api| ", u.String(), err)
-		return events
+	limit := pageLimit{maxPages: s.maxPages}
+	if s.lookahead > 0 {
+		limit.until = time.Now().AddDate(0, 0, s.lookahead)
 	}
 
+	eventsData, err := s.getFromApi(ctx, u, limit)
+	if err != nil && !errors.As(err, &pageErrs) {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("get from api: %w", err))
+	}
+	itemErrs = append(itemErrs, pageErrs...)
+
+	var items []EventSource
 	for _, ev := range *eventsData {
-		event = m.Event{
+		if err := validItem(ev); err != nil {
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, ev.FullUrl, fmt.Errorf("event %s: %w", ev.Id, err)))
+			continue
+		}
+		items = append(items, ev)
+	}
+
+	descriptions := make([]string, len(items))
+	descriptionErrs := make([]*m.ItemError, len(items))
+	m.RunParallel(ctx, detailWorkers, len(items), func(ctx context.Context, i int) {
+		description, err := s.description(ctx, u.Scheme+"://"+u.Host+u.Path, items[i].Url)
+		if err != nil {
+			descriptionErrs[i] = m.ItemFailed(m.ItemDetail, items[i].FullUrl, fmt.Errorf("description: %w", err))
+		}
+		descriptions[i] = description
+	})
+
+	for i, ev := range items {
+		event := m.Event{
 			ID:          m.StripAllHtml.Sanitize(ev.Id),
 			Title:       m.StripAllHtml.Sanitize(ev.Title),
-			Description: description(u.Scheme+"://"+u.Host+u.Path, ev.Url),
+			Description: descriptions[i],
 			Url:         ev.FullUrl,
 			Image:       ev.Thumbnail.Small.Url,
 			Days:        parseDays(ev.Dates[0]),
 			Place:       parsePlace(ev),
-			LocationMap: fmt.Sprintf(
-				"https://www.google.com/maps/search/?api=1&query=%f,%f",
-				ev.Locations[0].Location.Latitude,
-				ev.Locations[0].Location.Longitude,
-			),
-			Timestamp: getTime(ev.Dates[0]),
+			Coordinates: &m.Coordinates{
+				Latitude:  ev.Locations[0].Location.Latitude,
+				Longitude: ev.Locations[0].Location.Longitude,
+			},
 		}
+		event.LocationMap = event.Coordinates.MapUrl()
 
-		event.DateText, event.Time = parseDate(ev.Dates[0])
+		start, end := apiDates(ev.Dates[0])
+		event.SetDates(start, end, false)
+		if event.Recurrence = recurrence(ev.Dates[0], start, end); event.Recurrence != nil {
+			event.Days = event.Recurrence.DaysText()
+		}
 
 		events = append(events, event)
 	}
 
-	return events
+	return events, m.JoinItemErrors(append(itemErrs, descriptionErrs...)...)
 }
 
-func New(sourceConfig m.Source) *SourcePorto {
-	return &SourcePorto{
-		Name: sourceConfig.Name,
-		Url:  sourceConfig.Url,
+// validItem item of the api has data required for the event: location and start date
+func validItem(ev EventSource) error {
+	if len(ev.Locations) == 0 {
+		return errors.New("without locations")
+	}
+	if _, err := apiTime(ev.Dates[0].Start); err != nil {
+		return fmt.Errorf("wrong start date: %w", err)
 	}
-}
 
-func getTime(dates Dates) time.Time {
-	layoutInput := "2006-01-02 15:04:05" // input format in json 2022-05-12 10:00:00
+	return nil
+}
 
-	start, err := time.Parse(layoutInput, dates.Start)
-	if err != nil {
-		log.Error("date parse", err, dates.Start)
+func New(sourceConfig m.Source) *SourcePorto {
+	s := &SourcePorto{
+		Name:     sourceConfig.Name,
+		Url:      sourceConfig.Url,
+		maxPages: defaultMaxPages,
+		client:   fetcher.New(sourceConfig.Fetch),
 	}
 
-	if start.Before(time.Now()) {
-		return time.Now().Truncate(time.Hour).In(time.FixedZone("WET", 0))
+	if n, err := strconv.Atoi(sourceConfig.Options["max_pages"]); err == nil && n > 0 {
+		s.maxPages = n
+	}
+	if n, err := strconv.Atoi(sourceConfig.Options["lookahead"]); err == nil && n > 0 {
+		s.lookahead = n
 	}
 
-	return start
+	return s
 }
 
 func parsePlace(event EventSource) string {
@@ -126,14 +203,73 @@ func parsePlace(event EventSource) string {
 	return m.StripAllHtml.Sanitize(loc.Locality)
 }
 
-func getFromApi(apiUrl *url.URL) (eventsSource *[]EventSource, err error) {
-	var data *EventList
+// getFromApi events from all api pages, starting from the "page" of apiUrl, without duplicates, apiUrl is not changed.
+// Events starting after limit.until are skipped, the api doesn't guarantee the order by dates.
+// Stops on the last page, empty page, limit.maxPages or the page of events all starting after limit.until.
+// Failed not first page stops too, its error is returned as m.ItemErrors with events of loaded pages
+func (s *SourcePorto) getFromApi(ctx context.Context, apiUrl *url.URL, limit pageLimit) (eventsSource *[]EventSource, err error) {
+	var (
+		items []EventSource
+		seen  = make(map[string]bool)
+	)
 
 	values := apiUrl.Query()
 	values.Set("startDate", time.Now().Format("2006-01-02"))
-	apiUrl.RawQuery = values.Encode()
 
-	res, err := http.Get(apiUrl.String())
+	page, err := strconv.Atoi(values.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	for i := 0; i < limit.maxPages; i++ {
+		pageUrl := *apiUrl
+		values.Set("page", strconv.Itoa(page))
+		pageUrl.RawQuery = values.Encode()
+
+		data, err := s.getPage(ctx, &pageUrl)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			return &items, m.JoinItemErrors(m.ItemFailed(m.ItemPage, pageUrl.String(), err))
+		}
+
+		events := data.PageByUrl.Events
+		if len(events.Items) == 0 {
+			break
+		}
+
+		afterLimit := 0 // items of the page starting after limit.until
+		for _, item := range events.Items {
+			if !limit.until.IsZero() {
+				if start, err := apiTime(item.Dates[0].Start); err == nil && start.After(limit.until) {
+					afterLimit++
+					continue
+				}
+			}
+
+			if seen[item.Id] {
+				continue
+			}
+			seen[item.Id] = true
+			items = append(items, item)
+		}
+
+		next := events.Pagination.NextPage
+		if afterLimit == len(events.Items) || next == nil || *next <= page {
+			break
+		}
+		page = *next
+	}
+
+	return &items, nil
+}
+
+// getPage one page of the events list
+func (s *SourcePorto) getPage(ctx context.Context, apiUrl *url.URL) (*EventList, error) {
+	var data *EventList
+
+	res, err := s.client.Get(ctx, apiUrl.String())
 	if err != nil {
 		return nil, err
 	}
@@ -146,19 +282,20 @@ func getFromApi(apiUrl *url.URL) (eventsSource *[]EventSource, err error) {
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		log.Error("read body", err, apiUrl.String())
-		return nil, err
+		return nil, fmt.Errorf("read body: %w", err)
 	}
 
 	if err = json.Unmarshal(body, &data); err != nil {
-		log.Error("unmarshal - ", err, apiUrl.String())
-		return nil, err
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("empty response %s", apiUrl.String())
 	}
 
-	return &data.PageByUrl.Events.Items, nil
+	return data, nil
 }
 
-func description(apiUrl string, eventPagePath string) string {
+func (s *SourcePorto) description(ctx context.Context, apiUrl string, eventPagePath string) (string, error) {
 	var descr struct {
 		PageByUrl struct {
 			Body []struct {
@@ -169,7 +306,7 @@ func description(apiUrl string, eventPagePath string) string {
 
 	u, err := url.ParseRequestURI(apiUrl)
 	if err != nil {
-		log.Error("parse apiUrl", err, apiUrl)
+		return "", err
 	}
 
 	values := u.Query()
@@ -177,30 +314,30 @@ func description(apiUrl string, eventPagePath string) string {
 	values.Add("urlPath", eventPagePath)
 	u.RawQuery = values.Encode()
 
-	res, err := http.Get(u.String())
+	res, err := s.client.Get(ctx, u.String())
 	if err != nil {
-		return ""
+		return "", err
 	}
 
 	defer func(Body io.ReadCloser) {
-		if err = Body.Close(); err != nil {
+		if err := Body.Close(); err != nil {
 			log.Error(err)
 		}
 	}(res.Body)
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		log.Error("read body", err, u.String())
-		return ""
+		return "", fmt.Errorf("read body: %w", err)
 	}
 
-	err = json.Unmarshal(body, &descr)
-	if err != nil || descr.PageByUrl.Body == nil {
-		log.Error("unmarshal description ", err, u.String())
-		return ""
+	if err = json.Unmarshal(body, &descr); err != nil {
+		return "", fmt.Errorf("unmarshal: %w", err)
+	}
+	if len(descr.PageByUrl.Body) == 0 {
+		return "", errors.New("empty body " + u.String())
 	}
 
-	return m.StripAllHtml.Sanitize(descr.PageByUrl.Body[0].Value)
+	return m.StripAllHtml.Sanitize(descr.PageByUrl.Body[0].Value), nil
 }
 
 func parseDays(dates Dates) string {
@@ -212,20 +349,50 @@ func parseDays(dates Dates) string {
 	return m.StripAllHtml.Sanitize(strings.TrimRight(days, ", "))
 }
 
-func parseDate(dates Dates) (DateText string, Time string) {
-	layoutInput := "2006-01-02 15:04:05" // input format in json 2022-05-12 10:00:00
-	layoutOutDate := "Jan 02th, 2006"
-	layoutOutTime := "15:04"
-
-	start, err := time.Parse(layoutInput, dates.Start)
+// apiDates start and end of the event, end is zero if the api has no valid end
+func apiDates(dates Dates) (start time.Time, end time.Time) {
+	start, err := apiTime(dates.Start)
 	if err != nil {
 		log.Error("date parse", err, dates.Start)
 	}
-	end, err := time.Parse(layoutInput, dates.End)
-	if err != nil {
-		log.Error("date parse", err, dates.Start)
+
+	end, err = apiTime(dates.End)
+	if err != nil || end.Before(start) {
+		end = time.Time{}
 	}
 
-	return start.Format(layoutOutDate) + " - " + end.Format(layoutOutDate),
-		start.Format(layoutOutTime) + " - " + end.Format(layoutOutTime)
+	return start, end
+}
+
+// recurrence weekly schedule of the event repeating on the days of Repeating labels from the start day to the end day,
+// with the same hours every day. Nil for one day events and without labels
+func recurrence(dates Dates, start time.Time, end time.Time) *m.Recurrence {
+	if len(dates.Repeating) == 0 || end.IsZero() || m.LisbonDate(start).Equal(m.LisbonDate(end)) {
+		return nil
+	}
+
+	open, closing := m.ClockOf(start), m.ClockOf(end)
+	if closing < open { // "21:00" - "18:00" of the last day, the end of every day is unknown
+		closing = open
+	}
+
+	r := &m.Recurrence{From: m.LisbonDate(start), Until: m.LisbonDate(end)}
+	for _, item := range dates.Repeating {
+		wd, ok := m.ParseWeekday(item.Label)
+		if !ok {
+			log.Warnln("porto| unknown weekday", item.Label)
+			continue
+		}
+		r.Days = append(r.Days, m.DayHours{Weekday: wd, Open: open, Close: closing})
+	}
+	if len(r.Days) == 0 {
+		return nil
+	}
+
+	return r
+}
+
+// apiTime date of the api, local time of Porto
+func apiTime(v string) (time.Time, error) {
+	return time.ParseInLocation(layoutApiDate, v, m.Lisbon)
 }
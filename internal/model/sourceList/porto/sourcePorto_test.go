@@ -1,39 +1,128 @@
 package porto
 
 import (
+	"context"
 	"fmt"
 	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/sourcetest"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-func Test_getTime(t *testing.T) {
+func Test_apiDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     Dates
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "summer time",
+			input:     Dates{Start: "2022-05-12 10:00:00", End: "2022-12-31 18:00:00"},
+			wantStart: time.Date(2022, time.May, 12, 9, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2022, time.December, 31, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "without end",
+			input:     Dates{Start: "2030-01-12 21:00:00"},
+			wantStart: time.Date(2030, time.January, 12, 21, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name:      "end before start",
+			input:     Dates{Start: "2030-01-12 21:00:00", End: "2030-01-11 18:00:00"},
+			wantStart: time.Date(2030, time.January, 12, 21, 0, 0, 0, model.Lisbon),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := apiDates(tt.input)
+			assert.True(t, tt.wantStart.Equal(start), "start %s", start)
+			assert.True(t, tt.wantEnd.Equal(end), "end %s", end)
+			assert.Equal(t, model.Lisbon, start.Location())
+		})
+	}
+}
+
+func Test_recurrence(t *testing.T) {
+	repeating := func(labels ...string) Dates {
+		var d Dates
+		for _, l := range labels {
+			d.Repeating = append(d.Repeating, struct {
+				Label string `json:"label"`
+			}{l})
+		}
+		return d
+	}
+	at := func(day int, hour int) time.Time {
+		return time.Date(2030, time.January, day, hour, 0, 0, 0, model.Lisbon)
+	}
+
 	tests := []struct {
 		name  string
-		input Dates
-		want  time.Time
+		dates Dates
+		start time.Time
+		end   time.Time
+		want  *model.Recurrence
 	}{
 		{
-			name:  "date before time.Now",
-			input: Dates{Start: "2022-05-12 10:00:00"},
-			want:  time.Now().Truncate(time.Hour).In(time.FixedZone("WET", 0)),
+			name:  "daily hours",
+			dates: repeating("sat", "sun", "wrong"),
+			start: at(5, 10),
+			end:   at(27, 18),
+			want: &model.Recurrence{
+				From:  at(5, 0),
+				Until: at(27, 0),
+				Days: []model.DayHours{
+					{Weekday: time.Saturday, Open: 10 * 60, Close: 18 * 60},
+					{Weekday: time.Sunday, Open: 10 * 60, Close: 18 * 60},
+				},
+			},
+		},
+		{
+			name:  "end time before start time",
+			dates: repeating("fri", "sat"),
+			start: at(11, 21),
+			end:   at(12, 18),
+			want: &model.Recurrence{
+				From:  at(11, 0),
+				Until: at(12, 0),
+				Days: []model.DayHours{
+					{Weekday: time.Friday, Open: 21 * 60, Close: 21 * 60},
+					{Weekday: time.Saturday, Open: 21 * 60, Close: 21 * 60},
+				},
+			},
+		},
+		{
+			name:  "one day",
+			dates: repeating("sat"),
+			start: at(5, 10),
+			end:   at(5, 18),
 		},
 		{
-			name:  "ok",
-			input: Dates{Start: "2024-05-12 10:00:00"},
-			want:  time.Date(2024, time.May, 12, 10, 0, 0, 0, time.FixedZone("WET", 0)),
+			name:  "without labels",
+			start: at(5, 10),
+			end:   at(27, 18),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			get := getTime(tt.input)
-			assert.True(t, tt.want.Equal(get), tt.name)
+			got := recurrence(tt.dates, tt.start, tt.end)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			if assert.NotNil(t, got) {
+				assert.True(t, tt.want.From.Equal(got.From), "From %s", got.From)
+				assert.True(t, tt.want.Until.Equal(got.Until), "Until %s", got.Until)
+				assert.Equal(t, tt.want.Days, got.Days)
+			}
 		})
 	}
 }
@@ -69,7 +158,7 @@ func Test_getFromApi(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotEventsSource, err := getFromApi(tt.apiUrl())
+			gotEventsSource, err := New(model.Source{}).getFromApi(context.Background(), tt.apiUrl(), pageLimit{maxPages: 1})
 
 			if tt.wantOk == false {
 				assert.Error(t, err, tt.apiUrl().String())
@@ -101,8 +190,6 @@ func Test_getFromApi(t *testing.T) {
 }
 
 func TestSourcePorto_LoadEvents(t *testing.T) {
-	timeNow := time.Now().Truncate(time.Hour).In(time.FixedZone("WET", 0))
-	timeLayout := "2006-01-02 15:04:05"
 	tests := []struct {
 		name string
 		want model.Event
@@ -117,8 +204,9 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 				Place:       "Museu FC Porto - Espaço João Espregueira Mendes",
 				Image:       "https://www.porto.pt/_next/image?url=https%3A%2F%2Fmedia.porto.pt%2Foriginal_images%2FIsabel_Mota_Fernando_Pereira_exposicao_Este_mundo_nao_nos_pertence_01.JPG&w=350&q=85",
 				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.161367,-8.583016",
-				Timestamp:   timeNow,
-				DateText:    "May 12th, 2022 - Dec 31th, 2022",
+				Start:       time.Date(2022, time.May, 12, 10, 0, 0, 0, model.Lisbon),
+				End:         time.Date(2022, time.December, 31, 18, 0, 0, 0, model.Lisbon),
+				DateText:    "12 May 2022 - 31 Dec 2022",
 				Days:        "mon, tue, wed, thu, fri, sat, sun",
 				Time:        "10:00 - 18:00",
 			},
@@ -133,8 +221,9 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 				Place:       "Escola das Artes da Universidade Católica Portuguesa",
 				Image:       "https://www.porto.pt/_next/image?url=https%3A%2F%2Fmedia.porto.pt%2Foriginal_images%2FDR_Fictional_Grounds_exposicao_coletivo_berru.jpg&w=350&q=85",
 				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.154207,-8.672795",
-				Timestamp:   timeNow,
-				DateText:    "Oct 20th, 2022 - Feb 17th, 2023",
+				Start:       time.Date(2022, time.October, 20, 10, 0, 0, 0, model.Lisbon),
+				End:         time.Date(2023, time.February, 17, 19, 0, 0, 0, model.Lisbon),
+				DateText:    "20 Oct 2022 - 17 Feb 2023",
 				Days:        "mon, tue, wed, thu, fri, sat, sun",
 				Time:        "10:00 - 19:00",
 			},
@@ -149,8 +238,9 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 				Place:       "Porto - Faculdade de Belas Artes",
 				Image:       "https://www.porto.pt/_next/image?url=https%3A%2F%2Fmedia.porto.pt%2Foriginal_images%2FDR_pavilhao_de_exposicoes_FBAUP.jpg&w=350&q=85",
 				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.145647,-8.600677",
-				Timestamp:   timeNow,
-				DateText:    "Oct 26th, 2022 - Jan 14th, 2023",
+				Start:       time.Date(2022, time.October, 26, 17, 30, 0, 0, model.Lisbon),
+				End:         time.Date(2023, time.January, 14, 18, 0, 0, 0, model.Lisbon),
+				DateText:    "26 Oct 2022 - 14 Jan 2023",
 				Days:        "mon, tue, wed, thu, fri, sat, sun",
 				Time:        "17:30 - 18:00",
 			},
@@ -165,8 +255,9 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 				Place:       "Porto - Casa das Artes",
 				Image:       "https://www.porto.pt/_next/image?url=https%3A%2F%2Fmedia.porto.pt%2Foriginal_images%2Fc48fd81f0c34-Por_do_Sol_nas_artes_Casa_das_Artes.jpg&w=350&q=85",
 				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.156465,-8.643391",
-				Timestamp:   timeNow,
-				DateText:    "Nov 05th, 2022 - Dec 23th, 2022",
+				Start:       time.Date(2022, time.November, 5, 15, 30, 0, 0, model.Lisbon),
+				End:         time.Date(2022, time.December, 23, 19, 0, 0, 0, model.Lisbon),
+				DateText:    "05 Nov 2022 - 23 Dec 2022",
 				Days:        "mon, tue, wed, thu, fri, sat, sun",
 				Time:        "15:30 - 19:00",
 			},
@@ -181,8 +272,9 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 				Place:       "Porto - Coliseu Porto Ageas",
 				Image:       "https://www.porto.pt/_next/image?url=https%3A%2F%2Fmedia.porto.pt%2Foriginal_images%2FDR_Luis_de_Matos_coliseu.jpg&w=350&q=85",
 				LocationMap: "https://www.google.com/maps/search/?api=1&query=41.146992,-8.605417",
-				Timestamp:   time.Date(2023, time.January, 13, 21, 0, 0, 0, time.FixedZone("WET", 0)),
-				DateText:    "Jan 13th, 2023 - Jan 14th, 2023",
+				Start:       time.Date(2023, time.January, 13, 21, 0, 0, 0, model.Lisbon),
+				End:         time.Date(2023, time.January, 14, 18, 0, 0, 0, model.Lisbon),
+				DateText:    "13 Jan 2023 - 14 Jan 2023",
 				Days:        "fri, sat",
 				Time:        "21:00 - 18:00",
 			},
@@ -199,7 +291,8 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 
 	u, _ := url.Parse(source.Url)
 
-	evs := source.LoadEvents(u)
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
 
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -210,7 +303,8 @@ func TestSourcePorto_LoadEvents(t *testing.T) {
 			assert.Equal(t, tt.want.Place, evs[i].Place, "Place")
 			assert.Equal(t, tt.want.Image, evs[i].Image, "Image")
 			assert.Equal(t, tt.want.LocationMap, evs[i].LocationMap, "LocationMap")
-			assert.Equal(t, tt.want.Timestamp.Format(timeLayout), evs[i].Timestamp.Format(timeLayout))
+			assert.True(t, tt.want.Start.Equal(evs[i].Start), "Start %s", evs[i].Start)
+			assert.True(t, tt.want.End.Equal(evs[i].End), "End %s", evs[i].End)
 			assert.Equal(t, tt.want.DateText, evs[i].DateText, "DateText")
 			assert.Equal(t, tt.want.Days, evs[i].Days, "Days")
 			assert.Equal(t, tt.want.Time, evs[i].Time, "Time")
@@ -240,3 +334,162 @@ func requestHandler(t *testing.T) http.HandlerFunc {
 		}
 	}
 }
+
+func Test_getFromApi_pages(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		limit     pageLimit
+		wantIds   []string
+		wantPages []string
+	}{
+		{
+			name:      "all pages until empty page, without duplicates",
+			limit:     pageLimit{maxPages: 10},
+			wantIds:   []string{"36013", "35973", "35974"},
+			wantPages: []string{"1", "2", "3"},
+		},
+		{
+			name:      "max pages",
+			limit:     pageLimit{maxPages: 1},
+			wantIds:   []string{"36013", "35973"},
+			wantPages: []string{"1"},
+		},
+		{
+			name:      "lookahead date, later events are skipped, next pages are loaded",
+			limit:     pageLimit{maxPages: 10, until: time.Date(2030, time.January, 31, 0, 0, 0, 0, time.UTC)},
+			wantIds:   []string{"36013", "35973"},
+			wantPages: []string{"1", "2", "3"},
+		},
+		{
+			name:      "lookahead date, stops on the page of later events",
+			limit:     pageLimit{maxPages: 10, until: time.Date(2030, time.January, 15, 0, 0, 0, 0, time.UTC)},
+			wantIds:   []string{"36013"},
+			wantPages: []string{"1", "2"},
+		},
+		{
+			name:      "start page from the url",
+			query:     "&page=2",
+			limit:     pageLimit{maxPages: 10},
+			wantIds:   []string{"35973", "35974"},
+			wantPages: []string{"2", "3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages []string
+			svr := httptest.NewServer(pagesHandler(t, &pages))
+			defer svr.Close()
+
+			u, _ := url.Parse(svr.URL + "/api/graphql?queryName=PageByUrl&urlPath=/en/events/" + tt.query)
+			before := u.String()
+
+			got, err := New(model.Source{}).getFromApi(context.Background(), u, tt.limit)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, before, u.String(), "url of the caller is not changed")
+
+			var ids []string
+			for _, e := range *got {
+				ids = append(ids, e.Id)
+			}
+			assert.Equal(t, tt.wantIds, ids)
+			assert.Equal(t, tt.wantPages, pages, "requested pages")
+		})
+	}
+}
+
+func TestSourcePorto_LoadEvents_pages(t *testing.T) {
+	var pages []string
+	svr := httptest.NewServer(pagesHandler(t, &pages))
+	defer svr.Close()
+
+	source := New(model.Source{
+		Name:    "test",
+		Url:     svr.URL + "/api/graphql?queryName=PageByUrl&urlPath=/en/events/&page=1",
+		Options: map[string]string{"max_pages": "2"},
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
+	if assert.Len(t, evs, 3) {
+		assert.Equal(t, "36013", evs[0].ID)
+		assert.Equal(t, "Exhibition | Walking Art Maps", evs[2].Title)
+		assert.Equal(t, "15 Feb 2030 - 14 Mar 2030", evs[2].DateText)
+		assert.NotEmpty(t, evs[2].Description, "description from the event page")
+	}
+	assert.Equal(t, []string{"1", "2"}, pages, "requested pages")
+}
+
+func TestSourcePorto_LoadEvents_malformedItems(t *testing.T) {
+	events := requestHandler(t)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("urlPath") != "/en/events/" {
+			events(w, r) // event view page
+			return
+		}
+		http.ServeFile(w, r, "tests/events_malformed.json")
+	}))
+	defer svr.Close()
+
+	source := New(model.Source{Name: "test", Url: svr.URL + "/api/graphql?queryName=PageByUrl&urlPath=/en/events/"})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	if assert.Len(t, evs, 1, "valid items are collected") {
+		assert.Equal(t, "36013", evs[0].ID)
+	}
+
+	var items model.ItemErrors
+	if assert.ErrorAs(t, err, &items) && assert.Len(t, items, 2) {
+		assert.Equal(t, "https://www.porto.pt/en/event/concert-without-location/", items[0].Url)
+		assert.ErrorContains(t, items[0], "without locations")
+		assert.ErrorContains(t, items[1], "wrong start date")
+	}
+}
+
+// pagesHandler multi-page events list tests/events_page_N.json, requested pages numbers are added to pages
+func pagesHandler(t *testing.T, pages *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	events := requestHandler(t)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("urlPath") != "/en/events/" {
+			events(w, r) // event view page
+			return
+		}
+
+		page := r.URL.Query().Get("page")
+		mu.Lock()
+		*pages = append(*pages, page)
+		mu.Unlock()
+
+		file, err := os.ReadFile("tests/events_page_" + page + ".json")
+		if err != nil {
+			t.Fatal("file not found|", err, r.RequestURI)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err = w.Write(file); err != nil {
+			t.Fatal("write file|", err)
+		}
+	}
+}
+
+// TestSourcePorto_LoadEvents_replay responses of porto.pt saved in tests dir, the cassette is assembled of them.
+// -record flag records them again from the live site, -update writes the events to the golden file
+func TestSourcePorto_LoadEvents_replay(t *testing.T) {
+	ctx := sourcetest.Replay(t, "tests/replay/porto.json")
+
+	source := New(model.Source{
+		Name: "porto",
+		Url:  "https://www.porto.pt/api/graphql?queryName=PageByUrl&urlPath=/en/events/&startDate=2022-11-25&searchQuery=&page=1",
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(ctx, u)
+	assert.NoError(t, err)
+	sourcetest.Golden(t, "tests/replay/porto.golden.json", evs)
+}
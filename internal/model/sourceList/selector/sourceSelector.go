@@ -0,0 +1,396 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Lisbon without system zoneinfo
+)
+
+type (
+	SourceSelector struct {
+		m.Source
+		config   m.SelectorConfig
+		regex    map[string]*regexp.Regexp // compiled FieldSelector.Regex by regex text
+		location *time.Location
+		client   *fetcher.Client
+	}
+)
+
+const (
+	// detailWorkers max parallel requests for event pages
+	detailWorkers        = 4
+	defaultTimeSeparator = " - "
+	defaultTimezone      = "Europe/Lisbon"
+)
+
+// fieldNames supported event fields
+var fieldNames = map[string]bool{
+	"id":          true,
+	"title":       true,
+	"url":         true,
+	"image":       true,
+	"description": true,
+	"place":       true,
+	"location":    true,
+	"date":        true,
+	"time":        true,
+}
+
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "selector",
+		DisplayName: "CSS selectors scraper ([source.selector] config)",
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+		Validate: Validate,
+	})
+}
+
+// Validate selector config: required selectors, known fields, CSS selectors, regex and timezone
+func Validate(sourceConfig m.Source) error {
+	var errs []error
+
+	c := sourceConfig.Selector
+	if c == nil {
+		return errors.New("missing [source.selector] config")
+	}
+
+	if _, err := cascadia.ParseGroup(c.List); err != nil {
+		errs = append(errs, fmt.Errorf("list selector %q: %w", c.List, err))
+	}
+
+	fields := c.Fields
+	if c.Detail != nil {
+		if c.Detail.Wrap != "" {
+			if _, err := cascadia.ParseGroup(c.Detail.Wrap); err != nil {
+				errs = append(errs, fmt.Errorf("detail wrap selector %q: %w", c.Detail.Wrap, err))
+			}
+		}
+		if _, ok := c.Fields["url"]; !ok {
+			errs = append(errs, errors.New(`detail page needs "url" field in the list fields`))
+		}
+		errs = append(errs, validateFields("detail", c.Detail.Fields)...)
+	}
+	errs = append(errs, validateFields("list", fields)...)
+
+	if !hasField(c, "title") {
+		errs = append(errs, errors.New(`"title" field is required`))
+	}
+	if !hasField(c, "date") {
+		errs = append(errs, errors.New(`"date" field is required`))
+	}
+	if len(c.Date.Layouts) == 0 {
+		errs = append(errs, errors.New("date layouts are required"))
+	}
+
+	if c.Date.Timezone != "" {
+		if _, err := time.LoadLocation(c.Date.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("timezone: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateFields(where string, fields map[string]m.FieldSelector) []error {
+	var errs []error
+
+	for name, f := range fields {
+		if !fieldNames[name] {
+			errs = append(errs, fmt.Errorf("%s field %q: unknown field", where, name))
+		}
+		if f.Css != "" {
+			if _, err := cascadia.ParseGroup(f.Css); err != nil {
+				errs = append(errs, fmt.Errorf("%s field %q selector %q: %w", where, name, f.Css, err))
+			}
+		}
+		if f.Regex != "" {
+			if _, err := regexp.Compile(f.Regex); err != nil {
+				errs = append(errs, fmt.Errorf("%s field %q regex: %w", where, name, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+func hasField(c *m.SelectorConfig, name string) bool {
+	if _, ok := c.Fields[name]; ok {
+		return true
+	}
+	if c.Detail != nil {
+		_, ok := c.Detail.Fields[name]
+		return ok
+	}
+	return false
+}
+
+func New(sourceConfig m.Source) *SourceSelector {
+	s := &SourceSelector{
+		Source: m.Source{
+			Name:     sourceConfig.Name,
+			Url:      sourceConfig.Url,
+			Options:  sourceConfig.Options,
+			Selector: sourceConfig.Selector,
+		},
+		regex:    make(map[string]*regexp.Regexp),
+		location: time.UTC,
+		client:   fetcher.New(sourceConfig.Fetch),
+	}
+
+	if sourceConfig.Selector == nil {
+		return s
+	}
+	s.config = *sourceConfig.Selector
+
+	if s.config.Date.TimeSeparator == "" {
+		s.config.Date.TimeSeparator = defaultTimeSeparator
+	}
+
+	tz := s.config.Date.Timezone
+	if tz == "" {
+		tz = defaultTimezone
+	}
+	if loc, err := time.LoadLocation(tz); err == nil {
+		s.location = loc
+	}
+
+	fields := []map[string]m.FieldSelector{s.config.Fields}
+	if s.config.Detail != nil {
+		fields = append(fields, s.config.Detail.Fields)
+	}
+	for _, list := range fields {
+		for _, f := range list {
+			if re, err := regexp.Compile(f.Regex); err == nil && f.Regex != "" {
+				s.regex[f.Regex] = re
+			}
+		}
+	}
+
+	return s
+}
+
+func (s *SourceSelector) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
+	var (
+		events   []m.Event
+		itemErrs []*m.ItemError
+	)
+
+	if err := Validate(s.Source); err != nil {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("wrong config: %w", err))
+	}
+
+	doc, err := s.page(ctx, u.String())
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), err)
+	}
+
+	values := make([]map[string]string, 0)
+	doc.Find(s.config.List).Each(func(i int, sel *goquery.Selection) {
+		values = append(values, s.fields(sel, s.config.Fields))
+	})
+
+	detailErrs := make([]*m.ItemError, len(values))
+	if s.config.Detail != nil {
+		m.RunParallel(ctx, detailWorkers, len(values), func(ctx context.Context, i int) {
+			if err := s.detail(ctx, u, values[i]); err != nil {
+				detailErrs[i] = m.ItemFailed(m.ItemDetail, values[i]["url"], err)
+			}
+		})
+	}
+
+	for _, v := range values {
+		ev, err := s.event(v, u)
+		if err != nil {
+			itemUrl := v["url"]
+			if itemUrl == "" {
+				itemUrl = u.String()
+			}
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, itemUrl, err))
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	return events, m.JoinItemErrors(append(itemErrs, detailErrs...)...)
+}
+
+// detail add fields from the event page to values, list fields are kept if the page failed
+func (s *SourceSelector) detail(ctx context.Context, u *url.URL, values map[string]string) error {
+	if values["url"] == "" {
+		return nil
+	}
+
+	eventUrl, err := u.Parse(values["url"])
+	if err != nil {
+		return fmt.Errorf("wrong event url: %w", err)
+	}
+
+	doc, err := s.page(ctx, eventUrl.String())
+	if err != nil {
+		return err
+	}
+
+	wrap := s.config.Detail.Wrap
+	if wrap == "" {
+		wrap = "body"
+	}
+
+	el := doc.Find(wrap).First()
+	if el.Length() == 0 {
+		return fmt.Errorf("not found wrap %q", wrap)
+	}
+
+	for name, val := range s.fields(el, s.config.Detail.Fields) {
+		if val != "" {
+			values[name] = val
+		}
+	}
+
+	return nil
+}
+
+// fields values of the selection
+func (s *SourceSelector) fields(sel *goquery.Selection, fields map[string]m.FieldSelector) map[string]string {
+	values := make(map[string]string, len(fields))
+
+	for name, f := range fields {
+		el := sel
+		if f.Css != "" {
+			el = sel.Find(f.Css)
+		}
+		if el.Length() == 0 {
+			continue
+		}
+
+		var val string
+		if f.Attr != "" {
+			val, _ = el.First().Attr(f.Attr)
+		} else if name == "description" {
+			val = el.Text() // all paragraphs
+		} else {
+			val = el.First().Text()
+		}
+
+		if re := s.regex[f.Regex]; re != nil {
+			match := re.FindStringSubmatch(val)
+			switch {
+			case match == nil:
+				val = ""
+			case len(match) > 1:
+				val = match[1]
+			default:
+				val = match[0]
+			}
+		}
+
+		values[name] = strings.TrimSpace(val)
+	}
+
+	return values
+}
+
+func (s *SourceSelector) event(values map[string]string, u *url.URL) (m.Event, error) {
+	ev := m.Event{
+		ID:          m.StripAllHtml.Sanitize(values["id"]),
+		Title:       m.StripAllHtml.Sanitize(values["title"]),
+		Url:         values["url"],
+		Image:       values["image"],
+		Description: m.StripAllHtml.Sanitize(values["description"]),
+		Place:       m.StripAllHtml.Sanitize(values["place"]),
+		Location:    m.StripAllHtml.Sanitize(values["location"]),
+		Time:        values["time"],
+	}
+
+	if ev.Title == "" {
+		return ev, errors.New("not found title")
+	}
+
+	if ev.Url != "" {
+		if eventUrl, err := u.Parse(ev.Url); err == nil {
+			ev.Url = eventUrl.String()
+		}
+	}
+	if ev.Image != "" {
+		if imageUrl, err := u.Parse(ev.Image); err == nil {
+			ev.Image = imageUrl.String()
+		}
+	}
+	if ev.ID == "" {
+		ev.ID = ev.Url
+	}
+
+	start, err := s.timestamp(values["date"], values["time"])
+	if err != nil {
+		return ev, fmt.Errorf("date parse %q %q: %w, %s", values["date"], values["time"], err, ev.Url)
+	}
+
+	ev.DateText = translate(values["date"], s.config.Date.Months) // text of the site, it may have a range of dates
+	ev.Start = start.In(m.Lisbon)
+
+	return ev, nil
+}
+
+// timestamp parse "date start_time" by configured layouts
+func (s *SourceSelector) timestamp(date string, timeTxt string) (time.Time, error) {
+	if i := strings.Index(timeTxt, s.config.Date.TimeSeparator); i != -1 {
+		timeTxt = timeTxt[:i]
+	}
+
+	text := strings.TrimSpace(translate(date, s.config.Date.Months) + " " + strings.TrimSpace(timeTxt))
+
+	var err error
+	for _, layout := range s.config.Date.Layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, text, s.location); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// translate words by table, ex.: months names "Fev" => "Feb". Longer words replaced first
+func translate(text string, table map[string]string) string {
+	words := make([]string, 0, len(table))
+	for from := range table {
+		words = append(words, from)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		return len(words[i]) > len(words[j]) || (len(words[i]) == len(words[j]) && words[i] < words[j])
+	})
+
+	for _, from := range words {
+		text = strings.ReplaceAll(text, from, table[from])
+	}
+
+	return text
+}
+
+func (s *SourceSelector) page(ctx context.Context, pageUrl string) (*goquery.Document, error) {
+	res, err := s.client.Get(ctx, pageUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wrong response status %s", res.Status)
+	}
+
+	return m.LoadContent(res)
+}
@@ -0,0 +1,238 @@
+package selector
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// agendaConfig same events as agendaculturalporto source, but declared by config
+func agendaConfig() *model.SelectorConfig {
+	return &model.SelectorConfig{
+		List: "article.mec-event-article",
+		Fields: map[string]model.FieldSelector{
+			"id":    {Css: ".mec-event-title a", Attr: "data-event-id"},
+			"title": {Css: ".mec-event-title a"},
+			"url":   {Css: ".mec-event-title a", Attr: "href"},
+			"image": {Css: ".mec-event-image img", Attr: "data-lazy-srcset", Regex: `(\S+) 300w`},
+		},
+		Detail: &model.DetailSelector{
+			Wrap: ".mec-single-event",
+			Fields: map[string]model.FieldSelector{
+				"description": {Css: ".mec-single-event-description p"},
+				"place":       {Css: ".mec-single-event-location .author"},
+				"location":    {Css: ".mec-single-event-location .mec-address"},
+				"date":        {Css: ".mec-single-event-date .mec-events-abbr .mec-start-date-label"},
+				"time":        {Css: ".mec-single-event-time .mec-events-abbr"},
+			},
+		},
+		Date: model.DateConfig{
+			Layouts: []string{"02 Jan 2006 15:04", "02 Jan 2006"},
+			Months:  map[string]string{"Fev": "Feb", "Abr": "Apr", "Mai": "May", "Ago": "Aug", "Set": "Sep", "Out": "Oct", "Dez": "Dec"},
+		},
+	}
+}
+
+func TestSourceSelector_LoadEvents(t *testing.T) {
+	svr := httptest.NewServer(requestHandler(t))
+	defer svr.Close()
+
+	source := New(model.Source{
+		Name:     "selector",
+		Url:      svr.URL + "/agenda-maus-habitos-porto",
+		Selector: agendaConfig(),
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
+	if !assert.Len(t, evs, 1) {
+		t.FailNow()
+	}
+
+	got := evs[0]
+	assert.Equal(t, "35215", got.ID)
+	assert.Equal(t, "Orfélia em estreia ao vivo no Maus Hábitos", got.Title)
+	assert.Equal(t, svr.URL+"/orfelia-em-estreia-ao-vivo-no-maus-habitos", got.Url, "relative url resolved")
+	assert.Equal(t, "https://agendaculturalporto.org/wp-content/uploads/2022/12/Orfelia-300x300.jpg", got.Image)
+	assert.True(t, strings.HasPrefix(got.Description, "O PROJETO MUSICAL LUSO-BRASILEIRO"), got.Description)
+	assert.Equal(t, "Maus Hábitos - Espaço de Intervenção Cultural", got.Place)
+	assert.Equal(t, "R. de Passos Manuel 178 4º Piso, 4000-382 Porto", got.Location)
+	assert.Equal(t, "06 Jan 2024", got.DateText)
+	assert.Equal(t, "21:00 - 23:30", got.Time)
+	assert.False(t, got.Start.IsZero())
+}
+
+func TestSourceSelector_LoadEvents_wrongConfig(t *testing.T) {
+	svr := httptest.NewServer(requestHandler(t))
+	defer svr.Close()
+
+	config := agendaConfig()
+	config.List = "article["
+
+	source := New(model.Source{Name: "selector", Url: svr.URL, Selector: config})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.Empty(t, evs)
+
+	var sourceErr *model.SourceError
+	assert.ErrorAs(t, err, &sourceErr, "fatal error of the source")
+}
+
+// requestHandler serves agendaculturalporto fixtures
+func requestHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := "../agendaCulturalPorto/tests/"
+		filePath := path + "eventsList.html"
+		eventPage := "orfelia-em-estreia-ao-vivo-no-maus-habitos"
+
+		if strings.Contains(r.URL.String(), eventPage) {
+			filePath = path + eventPage + ".html"
+		}
+
+		file, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal("file not found|", err, r.RequestURI)
+		}
+
+		if _, err = w.Write(file); err != nil {
+			t.Fatal("write file|", err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  func(c *model.SelectorConfig) *model.SelectorConfig
+		wantErr []string
+	}{
+		{
+			name:   "ok",
+			config: func(c *model.SelectorConfig) *model.SelectorConfig { return c },
+		},
+		{
+			name:    "missing selector table",
+			config:  func(c *model.SelectorConfig) *model.SelectorConfig { return nil },
+			wantErr: []string{"missing [source.selector] config"},
+		},
+		{
+			name: "wrong css and regex",
+			config: func(c *model.SelectorConfig) *model.SelectorConfig {
+				c.List = "article["
+				c.Fields["image"] = model.FieldSelector{Css: "img", Regex: "(\\S+"}
+				c.Detail.Fields["place"] = model.FieldSelector{Css: ".author >"}
+				return c
+			},
+			wantErr: []string{
+				`list selector "article["`,
+				`list field "image" regex`,
+				`detail field "place" selector ".author >"`,
+			},
+		},
+		{
+			name: "unknown field and timezone",
+			config: func(c *model.SelectorConfig) *model.SelectorConfig {
+				c.Fields["price"] = model.FieldSelector{Css: ".price"}
+				c.Date.Timezone = "Europe/Porto"
+				return c
+			},
+			wantErr: []string{`list field "price": unknown field`, "timezone:"},
+		},
+		{
+			name: "required fields",
+			config: func(c *model.SelectorConfig) *model.SelectorConfig {
+				delete(c.Fields, "title")
+				delete(c.Fields, "url")
+				delete(c.Detail.Fields, "date")
+				c.Date.Layouts = nil
+				return c
+			},
+			wantErr: []string{
+				`"title" field is required`,
+				`"date" field is required`,
+				"date layouts are required",
+				`detail page needs "url" field`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(model.Source{Name: "selector", Selector: tt.config(agendaConfig())})
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				for _, want := range tt.wantErr {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceSelector_timestamp(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	s := New(model.Source{Name: "selector", Selector: agendaConfig()})
+
+	tests := []struct {
+		name    string
+		date    string
+		timeTxt string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:    "date and time range",
+			date:    "06 Jan 2030",
+			timeTxt: "21:00 - 23:30",
+			want:    time.Date(2030, time.January, 6, 21, 0, 0, 0, lisbon),
+		},
+		{
+			name:    "portuguese month, summer time",
+			date:    "15 Ago 2030",
+			timeTxt: "18:30",
+			want:    time.Date(2030, time.August, 15, 18, 30, 0, 0, lisbon),
+		},
+		{
+			name: "without time, next layout",
+			date: "01 Dez 2030",
+			want: time.Date(2030, time.December, 1, 0, 0, 0, 0, lisbon),
+		},
+		{
+			name:    "unknown format",
+			date:    "1st of January",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.timestamp(tt.date, tt.timeTxt)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Truef(t, tt.want.Equal(got), "want: %s, got: %s", tt.want, got)
+		})
+	}
+}
+
+func Test_translate(t *testing.T) {
+	table := map[string]string{"Set": "Sep", "Setembro": "September", "Out": "Oct"}
+
+	assert.Equal(t, "10 September 2030", translate("10 Setembro 2030", table), "longer word first")
+	assert.Equal(t, "10 Sep 2030", translate("10 Set 2030", table))
+	assert.Equal(t, "10 Out 2030", translate("10 Out 2030", nil), "no table")
+}
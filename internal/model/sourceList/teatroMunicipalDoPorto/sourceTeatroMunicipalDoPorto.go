@@ -0,0 +1,253 @@
+package teatroMunicipalDoPorto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	m "github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	log "github.com/sirupsen/logrus"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type (
+	SourceTeatroMunicipalDoPorto struct {
+		m.Source
+		client *fetcher.Client
+	}
+
+	session struct {
+		date string // "2030-01-12"
+		time string // "19:30"
+	}
+)
+
+// detailWorkers max parallel requests for event pages
+const detailWorkers = 4
+
+// theatreAddress addresses of the Teatro Municipal do Porto stages
+var theatreAddress = map[string]string{
+	"Rivoli":       "Praça D. João I, 4000-295 Porto",
+	"Campo Alegre": "Rua das Estrelas, 4150-762 Porto",
+}
+
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "teatromunicipaldoporto",
+		DisplayName: "Teatro Municipal do Porto",
+		Homepage:    "https://www.teatromunicipaldoporto.pt/en/programa/",
+		New: func(sourceConfig m.Source) m.SourceInterface {
+			return New(sourceConfig)
+		},
+	})
+}
+
+func (s *SourceTeatroMunicipalDoPorto) LoadEvents(ctx context.Context, u *url.URL) ([]m.Event, error) {
+	var (
+		events   []m.Event
+		itemErrs []*m.ItemError
+	)
+
+	res, err := s.client.Get(ctx, u.String())
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+	doc, err := m.LoadContent(res)
+	if err != nil {
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("load content: %w", err))
+	}
+
+	list := doc.Find(".programa-list")
+	if list.Length() == 0 { // changed markup of the site, not an empty programme
+		return nil, m.SourceFailed(u.String(), errors.New("not found events list .programa-list"))
+	}
+
+	list.Find("article.event-card").Each(func(i int, s *goquery.Selection) {
+		id, ok := s.Attr("data-id")
+		if !ok {
+			return // promo banners in the list have no id
+		}
+
+		href, ok := s.Find("a.event-card__link").Attr("href")
+		if !ok {
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, u.String(), fmt.Errorf("event %s: not found link", id)))
+			return
+		}
+
+		eventUrl, err := u.Parse(href)
+		if err != nil {
+			itemErrs = append(itemErrs, m.ItemFailed(m.ItemParse, u.String(), fmt.Errorf("event %s: wrong link %q", id, href)))
+			return
+		}
+
+		ev := m.Event{
+			ID:    id,
+			Url:   eventUrl.String(),
+			Title: strings.TrimSpace(s.Find(".event-card__title").Text()),
+		}
+		ev.Image, _ = s.Find(".event-card__image").Attr("src")
+
+		events = append(events, ev)
+	})
+
+	loaded := make([]bool, len(events))
+	pageErrs := make([]*m.ItemError, len(events))
+	m.RunParallel(ctx, detailWorkers, len(events), func(ctx context.Context, i int) {
+		if err := s.eventPage(ctx, &events[i]); err != nil {
+			pageErrs[i] = m.ItemFailed(m.ItemDetail, events[i].Url, err)
+			return
+		}
+		loaded[i] = true
+	})
+
+	var eventsLoaded []m.Event
+	for i, ok := range loaded {
+		if ok {
+			eventsLoaded = append(eventsLoaded, events[i])
+		}
+	}
+
+	return eventsLoaded, m.JoinItemErrors(append(itemErrs, pageErrs...)...)
+}
+
+func New(sourceConfig m.Source) *SourceTeatroMunicipalDoPorto {
+	return &SourceTeatroMunicipalDoPorto{
+		m.Source{
+			Name:    sourceConfig.Name,
+			Url:     sourceConfig.Url,
+			Options: sourceConfig.Options,
+		},
+		fetcher.New(sourceConfig.Fetch),
+	}
+}
+
+// eventPage fill event with data from the event page
+func (s *SourceTeatroMunicipalDoPorto) eventPage(ctx context.Context, ev *m.Event) error {
+	log.Debugln("visiting ev page for more data collect", ev.Url)
+
+	res, err := s.client.Get(ctx, ev.Url)
+	if err != nil {
+		return err
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+	d, err := m.LoadContent(res)
+	if err != nil {
+		return fmt.Errorf("load content: %w", err)
+	}
+
+	el := d.Find("main.event-detail").First()
+	if el.Length() == 0 {
+		return errors.New("not found wrap main.event-detail")
+	}
+
+	var sessions []session
+	el.Find(".event-detail__sessions .session").Each(func(i int, s *goquery.Selection) {
+		date, _ := s.Attr("data-date")
+		t, _ := s.Attr("data-time")
+		sessions = append(sessions, session{date: date, time: t})
+	})
+
+	if ev.Title == "" {
+		ev.Title = strings.TrimSpace(el.Find(".event-detail__title").Text())
+	}
+
+	theatre := strings.TrimSpace(el.Find(".event-detail__theatre").Text())
+	ev.Place = place(theatre, strings.TrimSpace(el.Find(".event-detail__hall").Text()))
+	ev.Location = theatreAddress[theatre]
+	ev.Description = description(el.Find(".event-detail__description p"))
+
+	start, end, allDay, err := sessionsDates(sessions)
+	if err != nil {
+		return fmt.Errorf("date parse: %w", err)
+	}
+	ev.SetDates(start, end, allDay)
+	ev.Time = sessionsTimes(sessions)
+
+	return nil
+}
+
+// place "Rivoli - Grande Auditório"
+func place(theatre string, hall string) string {
+	if hall == "" {
+		return theatre
+	}
+	if theatre == "" {
+		return hall
+	}
+
+	return theatre + " - " + hall
+}
+
+// description paragraphs text, one paragraph per line
+func description(paragraphs *goquery.Selection) string {
+	var lines []string
+	paragraphs.Each(func(i int, s *goquery.Selection) {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	})
+
+	return m.StripAllHtml.Sanitize(strings.Join(lines, "\n"))
+}
+
+// sessionsTimes all different sessions times: "19:30, 17:00"
+func sessionsTimes(sessions []session) string {
+	var times []string
+	for _, s := range sessions {
+		if s.time != "" && !slices.Contains(times, s.time) {
+			times = append(times, s.time)
+		}
+	}
+
+	return strings.Join(times, ", ")
+}
+
+// sessionsDates from the earliest session to the start of the latest one, in Lisbon.
+// All day if no session has time, the end is zero for one session
+func sessionsDates(sessions []session) (start time.Time, end time.Time, allDay bool, err error) {
+	if len(sessions) == 0 {
+		return start, end, false, errors.New("no sessions")
+	}
+
+	allDay = !slices.ContainsFunc(sessions, func(s session) bool { return s.time != "" })
+
+	starts := make([]time.Time, 0, len(sessions))
+	for _, s := range sessions {
+		t, err := sessionTime(s, allDay)
+		if err != nil {
+			return start, end, false, err
+		}
+		starts = append(starts, t)
+	}
+	slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+
+	start = starts[0]
+	if len(starts) == 1 {
+		return start, time.Time{}, allDay, nil
+	}
+
+	end = starts[len(starts)-1]
+	if allDay {
+		end = end.AddDate(0, 0, 1) // exclusive end of the last day
+	}
+
+	return start, end, allDay, nil
+}
+
+// sessionTime start of the session in Lisbon, midnight of the session day if dateOnly or without time
+func sessionTime(s session, dateOnly bool) (time.Time, error) {
+	if dateOnly || s.time == "" {
+		return time.ParseInLocation("2006-01-02", s.date, m.Lisbon)
+	}
+
+	return time.ParseInLocation("2006-01-02 15:04", s.date+" "+s.time, m.Lisbon)
+}
@@ -0,0 +1,234 @@
+package teatroMunicipalDoPorto
+
+import (
+	"context"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/sourcetest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSourceTeatroMunicipalDoPorto_LoadEvents pages of tests dir are synthetic, written by hand after the site markup
+func TestSourceTeatroMunicipalDoPorto_LoadEvents(t *testing.T) {
+	svr := httptest.NewServer(requestHandler(t))
+
+	defer svr.Close()
+	source := New(model.Source{
+		Name: "test",
+		Url:  svr.URL + "/en/programa/",
+	})
+
+	u, _ := url.Parse(source.Url)
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		want model.Event
+	}{
+		{
+			name: "ok 4821",
+			want: model.Event{
+				ID:          "4821",
+				Url:         svr.URL + "/en/programa/2030/a-sagracao-da-primavera/",
+				Title:       "A Sagração da Primavera",
+				Description: "A new reading of Stravinsky’s masterpiece by the Companhia Nacional de Bailado.\nDuration 60 min. M/6",
+				Image:       "https://www.teatromunicipaldoporto.pt/media/programa/2030/sagracao-da-primavera-600x400.jpg",
+				Place:       "Rivoli - Grande Auditório",
+				Location:    "Praça D. João I, 4000-295 Porto",
+				DateText:    "12 Jan 2030 - 13 Jan 2030",
+				Time:        "19:30, 17:00",
+				Start:       time.Date(2030, time.January, 12, 19, 30, 0, 0, model.Lisbon),
+				End:         time.Date(2030, time.January, 13, 17, 0, 0, 0, model.Lisbon),
+			},
+		},
+		{
+			name: "ok 4830",
+			want: model.Event{
+				ID:          "4830",
+				Url:         svr.URL + "/en/programa/2030/conversas-no-campo/",
+				Title:       "Conversas no Campo & Amigos",
+				Description: "Monthly conversation about the city and its stages. Free entry.",
+				Image:       "https://www.teatromunicipaldoporto.pt/media/programa/2030/conversas-no-campo-600x400.jpg",
+				Place:       "Campo Alegre - Café-Teatro",
+				Location:    "Rua das Estrelas, 4150-762 Porto",
+				DateText:    "20 Feb 2030",
+				Time:        "18:30",
+				Start:       time.Date(2030, time.February, 20, 18, 30, 0, 0, model.Lisbon),
+			},
+		},
+	}
+
+	if !assert.Len(t, evs, len(tests), "promo banner should be skipped") {
+		t.FailNow()
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want.ID, evs[i].ID, "ID")
+			assert.Equal(t, tt.want.Url, evs[i].Url, "Url")
+			assert.Equal(t, tt.want.Title, evs[i].Title, "Title")
+			assert.Equal(t, tt.want.Description, evs[i].Description, "Description")
+			assert.Equal(t, tt.want.Image, evs[i].Image, "Image")
+			assert.Equal(t, tt.want.Place, evs[i].Place, "Place")
+			assert.Equal(t, tt.want.Location, evs[i].Location, "Location")
+			assert.Equal(t, tt.want.DateText, evs[i].DateText, "DateText")
+			assert.Equal(t, tt.want.Time, evs[i].Time, "Time")
+			assert.Truef(t, tt.want.Start.Equal(evs[i].Start), "want: %s, got: %s", tt.want.Start, evs[i].Start)
+			assert.Truef(t, tt.want.End.Equal(evs[i].End), "want: %s, got: %s", tt.want.End, evs[i].End)
+		})
+	}
+}
+
+func TestSourceTeatroMunicipalDoPorto_LoadEvents_changedMarkup(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><section class="programme"><article class="card">Hamlet</article></section></body></html>`))
+	}))
+	defer svr.Close()
+
+	source := New(model.Source{Name: "test", Url: svr.URL + "/en/programa/"})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(context.Background(), u)
+	assert.Empty(t, evs)
+	var sourceErr *model.SourceError
+	assert.ErrorAs(t, err, &sourceErr, "the source fails, not an empty programme")
+}
+
+func requestHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filePath := "tests/programa.html"
+
+		if strings.HasPrefix(r.URL.Path, "/en/programa/2030/") { // event view page
+			filePath = "tests/" + path.Base(strings.TrimRight(r.URL.Path, "/")) + ".html"
+		}
+
+		file, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal("file not found|", err, r.RequestURI)
+		}
+
+		if _, err = w.Write(file); err != nil {
+			t.Fatal("write file|", err)
+		}
+	}
+}
+
+func Test_sessionsTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []session
+		want     string
+	}{
+		{
+			name:     "range, different times",
+			sessions: []session{{"2030-01-12", "19:30"}, {"2030-01-13", "17:00"}, {"2030-01-14", "19:30"}},
+			want:     "19:30, 17:00",
+		},
+		{
+			name:     "one day, two sessions",
+			sessions: []session{{"2030-03-01", "16:00"}, {"2030-03-01", "21:00"}},
+			want:     "16:00, 21:00",
+		},
+		{
+			name:     "no time",
+			sessions: []session{{"2030-03-01", ""}},
+		},
+		{
+			name: "no sessions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sessionsTimes(tt.sessions))
+		})
+	}
+}
+
+func Test_sessionsDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		sessions   []session
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantAllDay bool
+		wantErr    bool
+	}{
+		{
+			name:      "one session",
+			sessions:  []session{{"2030-01-12", "19:30"}},
+			wantStart: time.Date(2030, time.January, 12, 19, 30, 0, 0, model.Lisbon),
+		},
+		{
+			name:      "range, summer time",
+			sessions:  []session{{"2030-07-12", "19:30"}, {"2030-07-14", "17:00"}},
+			wantStart: time.Date(2030, time.July, 12, 18, 30, 0, 0, time.UTC),
+			wantEnd:   time.Date(2030, time.July, 14, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "no time",
+			sessions:   []session{{"2030-01-12", ""}, {"2030-01-13", ""}},
+			wantStart:  time.Date(2030, time.January, 12, 0, 0, 0, 0, model.Lisbon),
+			wantEnd:    time.Date(2030, time.January, 14, 0, 0, 0, 0, model.Lisbon),
+			wantAllDay: true,
+		},
+		{
+			name:      "not sorted sessions",
+			sessions:  []session{{"2030-01-14", "17:00"}, {"2030-01-12", "19:30"}, {"2030-01-13", "21:00"}},
+			wantStart: time.Date(2030, time.January, 12, 19, 30, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2030, time.January, 14, 17, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name:      "the first session without time",
+			sessions:  []session{{"2030-01-12", ""}, {"2030-01-13", "21:00"}},
+			wantStart: time.Date(2030, time.January, 12, 0, 0, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2030, time.January, 13, 21, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name:     "wrong date",
+			sessions: []session{{"12 Jan", "19:30"}},
+			wantErr:  true,
+		},
+		{
+			name:    "no sessions",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, allDay, err := sessionsDates(tt.sessions)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Truef(t, tt.wantStart.Equal(start), "want: %s, got: %s", tt.wantStart, start)
+			assert.Truef(t, tt.wantEnd.Equal(end), "want: %s, got: %s", tt.wantEnd, end)
+			assert.Equal(t, tt.wantAllDay, allDay)
+		})
+	}
+}
+
+// TestSourceTeatroMunicipalDoPorto_LoadEvents_replay synthetic cassette, written by hand after the format of the site responses, it isn't a recording.
+// -record flag records the responses of the live site to the cassette without ".synthetic" in the name
+func TestSourceTeatroMunicipalDoPorto_LoadEvents_replay(t *testing.T) {
+	ctx := sourcetest.Replay(t, "tests/replay/teatromunicipaldoporto.synthetic.json")
+
+	source := New(model.Source{
+		Name: "teatromunicipaldoporto",
+		Url:  "https://www.teatromunicipaldoporto.pt/en/programa/",
+	})
+	u, _ := url.Parse(source.Url)
+
+	evs, err := source.LoadEvents(ctx, u)
+	assert.NoError(t, err)
+	sourcetest.Golden(t, "tests/replay/teatromunicipaldoporto.synthetic.golden.json", evs)
+}
@@ -1,9 +1,10 @@
 package testing
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	m "github.com/oleksiy-os/porto-events/internal/model"
-	log "github.com/sirupsen/logrus"
 	"net/url"
 	"os"
 )
@@ -14,19 +15,29 @@ type (
 	}
 )
 
-func (s *SourceTesting) LoadEvents(_ *url.URL) []m.Event {
+func init() {
+	m.RegisterSource(m.SourceInfo{
+		Name:        "testing",
+		DisplayName: "Testing (local json file)",
+		New: func(_ m.Source) m.SourceInterface {
+			return New()
+		},
+	})
+}
+
+func (s *SourceTesting) LoadEvents(_ context.Context, u *url.URL) ([]m.Event, error) {
 	var events []m.Event
 
 	file, err := os.ReadFile(s.pathToFile)
 	if err != nil {
-		log.Error("wrong events file path|", err)
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("wrong events file path: %w", err))
 	}
 
 	if err = json.Unmarshal(file, &events); err != nil {
-		log.Error("json unmarshal|", err)
+		return nil, m.SourceFailed(u.String(), fmt.Errorf("json unmarshal: %w", err))
 	}
 
-	return events
+	return events, nil
 }
 
 func New() *SourceTesting {
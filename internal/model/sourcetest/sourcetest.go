@@ -0,0 +1,87 @@
+// Package sourcetest sources tests helpers: replay of cassettes of http exchanges and golden files of events.
+// Cassettes are recorded from the live sites or assembled from saved responses of the sites. Synthetic cassettes,
+// written by hand for sources not checked on the live site yet, are named "*.synthetic.json".
+//
+// Record cassettes from the live sites and update golden files, synthetic ones are recorded to names without
+// ".synthetic":
+//
+//	go test ./internal/model/sourceList/porto -run _replay -record -update
+package sourcetest
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/oleksiy-os/porto-events/internal/model/fetcher"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	record = flag.Bool("record", false, "record sources cassettes from the live sites")
+	update = flag.Bool("update", false, "write events to golden files")
+)
+
+// Replay ctx replaying the cassette at path. With -record flag requests go to the live site
+// and the cassette is saved after the test
+func Replay(t *testing.T, path string) context.Context {
+	t.Helper()
+
+	if *record {
+		c := fetcher.NewCassette(recordedPath(path))
+		t.Cleanup(func() {
+			if err := c.Save(); err != nil {
+				t.Error("save cassette|", err)
+			}
+		})
+
+		return fetcher.WithCassette(context.Background(), c)
+	}
+
+	c, err := fetcher.LoadCassette(path)
+	if err != nil {
+		t.Fatal("load cassette|", err)
+	}
+
+	return fetcher.WithCassette(context.Background(), c)
+}
+
+// Golden compare events with the golden JSON file. With -update flag events are written to the file
+func Golden(t *testing.T, path string, events []model.Event) {
+	t.Helper()
+
+	if *record {
+		path = recordedPath(path)
+	}
+
+	got, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, append(got, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err, ", run the test with -update flag to create it")
+	}
+
+	assert.JSONEq(t, string(want), string(got), "golden file %s, run the test with -update flag if changes are expected", path)
+}
+
+// recordedPath of the recorded file instead of the synthetic one at path: "porto.synthetic.json" -> "porto.json"
+func recordedPath(path string) string {
+	return strings.Replace(path, ".synthetic.", ".", 1)
+}
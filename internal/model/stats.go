@@ -0,0 +1,205 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// SourceRun statistics of one source collection, stored to compare runs of the source
+	SourceRun struct {
+		Source       string        `json:"source"`
+		Url          string        `json:"url"` // sources with the same name differ by url
+		Started      time.Time     `json:"started"`
+		Duration     time.Duration `json:"duration"`
+		Events       int           `json:"events"`
+		DetailErrors int           `json:"detail_errors"`          // event or list pages failed to load or parse
+		ParseErrors  int           `json:"parse_errors"`           // not found elements, wrong dates...
+		HttpStatus   map[int]int   `json:"http_status,omitempty"`  // responses count by status code
+		Error        string        `json:"error,omitempty"`        // source failed or stopped
+		LastProblem  string        `json:"last_problem,omitempty"` // the last detail or parse error
+	}
+
+	// RunStats collects SourceRun of the running source, safe for concurrent use.
+	// HttpStatus of nil RunStats does nothing, so sources work without stats in ctx
+	RunStats struct {
+		mu  sync.Mutex
+		run SourceRun
+	}
+
+	// HealthStatus of the source by its last runs
+	HealthStatus string
+
+	// SourceHealth status of the source with found problems, for admins
+	SourceHealth struct {
+		Source   string       `json:"source"`
+		Url      string       `json:"url"`
+		Status   HealthStatus `json:"status"`
+		Problems []string     `json:"problems,omitempty"`
+		LastRun  *SourceRun   `json:"last_run,omitempty"`
+	}
+
+	runStatsKey struct{}
+)
+
+const (
+	HealthOk       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded" // source works, but lost part of events
+	HealthBroken   HealthStatus = "broken"   // no events, scraper needs fixing
+	HealthUnknown  HealthStatus = "unknown"  // source was never run
+
+	// HealthHistoryRuns previous runs compared with the last one
+	HealthHistoryRuns = 10
+
+	// healthDropRatio events count of the last run below this part of usual count degrades the source
+	healthDropRatio = 0.5
+
+	// healthErrorsRatio detail or parse errors above this part of events degrade the source
+	healthErrorsRatio = 0.2
+)
+
+// NewRunStats stats of the source run started now
+func NewRunStats(source Source) *RunStats {
+	return &RunStats{run: SourceRun{Source: source.Name, Url: source.Url, Started: time.Now()}}
+}
+
+// WithRunStats ctx for the source collection, sources and fetcher report to stats from it
+func WithRunStats(ctx context.Context, stats *RunStats) context.Context {
+	return context.WithValue(ctx, runStatsKey{}, stats)
+}
+
+// RunStatsFrom stats of the running source, nil if ctx has no stats
+func RunStatsFrom(ctx context.Context) *RunStats {
+	stats, _ := ctx.Value(runStatsKey{}).(*RunStats)
+
+	return stats
+}
+
+// HttpStatus response status code of the source site
+func (s *RunStats) HttpStatus(code int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.run.HttpStatus == nil {
+		s.run.HttpStatus = make(map[int]int)
+	}
+	s.run.HttpStatus[code]++
+}
+
+// Finish the run with collected events count and error of the source, returns the run statistics.
+// Failed items of ItemErrors are counted by kind, other errors are errors of the run
+func (s *RunStats) Finish(events int, err error) SourceRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.run.Duration = time.Since(s.run.Started).Round(time.Millisecond)
+	s.run.Events = events
+
+	var items ItemErrors
+	switch {
+	case errors.As(err, &items):
+		for _, item := range items {
+			switch item.Kind {
+			case ItemDetail, ItemPage:
+				s.run.DetailErrors++
+			default:
+				s.run.ParseErrors++
+			}
+			s.run.LastProblem = item.Error()
+		}
+	case err != nil:
+		s.run.Error = err.Error()
+	}
+
+	run := s.run
+	if run.HttpStatus != nil {
+		run.HttpStatus = make(map[int]int, len(s.run.HttpStatus))
+		for code, count := range s.run.HttpStatus {
+			run.HttpStatus[code] = count
+		}
+	}
+
+	return run
+}
+
+// Health of the source by its runs, the newest first.
+// The last run is compared with the usual (median) events count of previous runs
+func Health(source Source, runs []SourceRun) SourceHealth {
+	h := SourceHealth{Source: source.Name, Url: source.Url, Status: HealthUnknown}
+	if len(runs) == 0 {
+		return h
+	}
+
+	last := runs[0]
+	h.LastRun = &last
+	h.Status = HealthOk
+
+	degrade := func(status HealthStatus, problem string, args ...any) {
+		h.Problems = append(h.Problems, fmt.Sprintf(problem, args...))
+		if status == HealthBroken || h.Status == HealthOk {
+			h.Status = status
+		}
+	}
+
+	if last.Error != "" {
+		degrade(HealthBroken, "failed: %s", last.Error)
+	}
+
+	usual := usualEvents(runs[1:])
+	switch {
+	case last.Events == 0:
+		degrade(HealthBroken, "no events")
+	case float64(last.Events) < float64(usual)*healthDropRatio:
+		degrade(HealthDegraded, "events dropped from usual %d to %d", usual, last.Events)
+	}
+
+	if last.DetailErrors > 0 && float64(last.DetailErrors) > float64(last.Events)*healthErrorsRatio {
+		degrade(HealthDegraded, "%d event pages failed", last.DetailErrors)
+	}
+	if last.ParseErrors > 0 && float64(last.ParseErrors) > float64(last.Events)*healthErrorsRatio {
+		degrade(HealthDegraded, "%d parse errors", last.ParseErrors)
+	}
+
+	var failed, responses int
+	for code, count := range last.HttpStatus {
+		responses += count
+		if code >= 400 {
+			failed += count
+		}
+	}
+	if failed > 0 && float64(failed) > float64(responses)*healthErrorsRatio {
+		degrade(HealthDegraded, "%d of %d http responses failed", failed, responses)
+	}
+
+	if h.Status != HealthOk && last.LastProblem != "" {
+		h.Problems = append(h.Problems, "last problem: "+last.LastProblem)
+	}
+
+	return h
+}
+
+// usualEvents median events count of successful runs, 0 without them
+func usualEvents(runs []SourceRun) int {
+	var counts []int
+	for i := 0; i < len(runs) && i < HealthHistoryRuns; i++ {
+		if runs[i].Events > 0 {
+			counts = append(counts, runs[i].Events)
+		}
+	}
+
+	if len(counts) == 0 {
+		return 0
+	}
+
+	sort.Ints(counts)
+
+	return counts[len(counts)/2]
+}
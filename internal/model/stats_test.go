@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRunStats(t *testing.T) {
+	stats := NewRunStats(Source{Name: "agendaculturalporto", Url: "https://agendaculturalporto.org"})
+	ctx := WithRunStats(context.Background(), stats)
+
+	RunParallel(ctx, 4, 10, func(ctx context.Context, i int) {
+		RunStatsFrom(ctx).HttpStatus(200)
+	})
+
+	run := stats.Finish(8, JoinItemErrors(
+		ItemFailed(ItemDetail, "https://agendaculturalporto.org/evento/1", errors.New("not found wrap")),
+		nil,
+		ItemFailed(ItemPage, "https://agendaculturalporto.org/page/2", errors.New("502 Bad Gateway")),
+		ItemFailed(ItemParse, "https://agendaculturalporto.org", errors.New("not found title")),
+	))
+	assert.Equal(t, "agendaculturalporto", run.Source)
+	assert.Equal(t, "https://agendaculturalporto.org", run.Url)
+	assert.Equal(t, 8, run.Events)
+	assert.Equal(t, 2, run.DetailErrors)
+	assert.Equal(t, 1, run.ParseErrors)
+	assert.Equal(t, map[int]int{200: 10}, run.HttpStatus)
+	assert.Equal(t, "parse https://agendaculturalporto.org: not found title", run.LastProblem)
+	assert.Empty(t, run.Error, "items errors are not the source error")
+
+	run = NewRunStats(Source{}).Finish(0, SourceFailed("https://agendaculturalporto.org", context.DeadlineExceeded))
+	assert.Equal(t, "source https://agendaculturalporto.org: context deadline exceeded", run.Error)
+
+	assert.Nil(t, RunStatsFrom(context.Background()))
+	assert.NotPanics(t, func() { RunStatsFrom(context.Background()).HttpStatus(200) })
+}
+
+func TestHealth(t *testing.T) {
+	history := []SourceRun{{Events: 40}, {Events: 38}, {Events: 0}, {Events: 42}}
+
+	tests := []struct {
+		name         string
+		runs         []SourceRun
+		wantStatus   HealthStatus
+		wantProblems int
+	}{
+		{
+			name:       "never run",
+			wantStatus: HealthUnknown,
+		},
+		{
+			name:       "usual events count",
+			runs:       append([]SourceRun{{Events: 35, HttpStatus: map[int]int{200: 36}}}, history...),
+			wantStatus: HealthOk,
+		},
+		{
+			name:       "the first run",
+			runs:       []SourceRun{{Events: 3}},
+			wantStatus: HealthOk,
+		},
+		{
+			name:         "events dropped",
+			runs:         append([]SourceRun{{Events: 12}}, history...),
+			wantStatus:   HealthDegraded,
+			wantProblems: 1,
+		},
+		{
+			name:         "many event pages failed",
+			runs:         append([]SourceRun{{Events: 30, DetailErrors: 10, LastProblem: "not found wrap"}}, history...),
+			wantStatus:   HealthDegraded,
+			wantProblems: 2,
+		},
+		{
+			name:         "http errors",
+			runs:         append([]SourceRun{{Events: 30, HttpStatus: map[int]int{200: 5, 404: 3}}}, history...),
+			wantStatus:   HealthDegraded,
+			wantProblems: 1,
+		},
+		{
+			name:         "no events",
+			runs:         append([]SourceRun{{ParseErrors: 40, LastProblem: "not found title"}}, history...),
+			wantStatus:   HealthBroken,
+			wantProblems: 3,
+		},
+		{
+			name:         "failed source",
+			runs:         append([]SourceRun{{Events: 20, Error: "context deadline exceeded"}}, history...),
+			wantStatus:   HealthBroken,
+			wantProblems: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Health(Source{Name: "porto", Url: "https://www.porto.pt"}, tt.runs)
+			assert.Equal(t, tt.wantStatus, h.Status)
+			assert.Len(t, h.Problems, tt.wantProblems, h.Problems)
+			assert.Equal(t, "porto", h.Source)
+		})
+	}
+}
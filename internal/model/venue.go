@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// earthRadius in meters, for distances between coordinates
+const earthRadius = 6371000
+
+type (
+	// Coordinates of the place of the event or the venue
+	Coordinates struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	}
+
+	// Venue known place of events curated by editors. Places of collected events are matched to venues
+	// by the name or aliases, see NormalizeVenueName, events get the name, address and coordinates of the venue
+	Venue struct {
+		ID          string       `json:"id"` // see VenueId
+		Name        string       `json:"name"`
+		Aliases     []string     `json:"aliases"` // other names of the venue in sources: "Coliseu do Porto", "Coliseu Ageas"
+		Address     string       `json:"address"`
+		Coordinates *Coordinates `json:"coordinates,omitempty"`
+		Website     string       `json:"website"`
+	}
+)
+
+// Unaccent letters of portuguese words: "Música" -> "Musica"
+var Unaccent = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+)
+
+// venueArticles leading words of venue names dropped on normalization: "O Meu Mercedes" is "Meu Mercedes"
+var venueArticles = map[string]bool{"o": true, "a": true, "os": true, "as": true, "the": true}
+
+// MapUrl google maps link of the coordinates
+func (c Coordinates) MapUrl() string {
+	return fmt.Sprintf("https://www.google.com/maps/search/?api=1&query=%f,%f", c.Latitude, c.Longitude)
+}
+
+// Distance between the coordinates in meters
+func (c Coordinates) Distance(other Coordinates) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat, dLon := rad(other.Latitude-c.Latitude), rad(other.Longitude-c.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(c.Latitude))*math.Cos(rad(other.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// NormalizeVenueName for comparison of venue names: lower case words without accents, punctuation
+// and leading article. "O Maus Hábitos!" -> "maus habitos"
+func NormalizeVenueName(name string) string {
+	list := strings.FieldsFunc(Unaccent.Replace(strings.ToLower(name)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(list) > 1 && venueArticles[list[0]] {
+		list = list[1:]
+	}
+
+	return strings.Join(list, " ")
+}
+
+// VenueId stable id of the venue by its name: "maus-habitos"
+func VenueId(name string) string {
+	return strings.ReplaceAll(NormalizeVenueName(name), " ", "-")
+}
+
+// Names of the venue normalized for matching: the name and aliases, without duplicates
+func (v Venue) Names() []string {
+	var list []string
+	for _, name := range append([]string{v.Name}, v.Aliases...) {
+		n := NormalizeVenueName(name)
+		if n != "" && !slices.Contains(list, n) {
+			list = append(list, n)
+		}
+	}
+
+	return list
+}
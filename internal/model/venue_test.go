@@ -0,0 +1,36 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeVenueName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Maus Hábitos", want: "maus habitos"},
+		{name: " O  Meu Mercedes é Maior que o Teu! ", want: "meu mercedes e maior que o teu"},
+		{name: "Casa da Música - Sala Suggia", want: "casa da musica sala suggia"},
+		{name: "A", want: "a"},
+		{name: "...", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeVenueName(tt.name))
+		})
+	}
+
+	assert.Equal(t, "casa-da-musica", VenueId("Casa da Música"))
+	assert.Equal(t, []string{"coliseu porto", "coliseu do porto"}, Venue{Name: "Coliseu Porto", Aliases: []string{"Coliseu do Porto", "COLISEU PORTO"}}.Names())
+}
+
+func TestCoordinates_Distance(t *testing.T) {
+	coliseu := Coordinates{Latitude: 41.146992, Longitude: -8.605417}
+	casaDaMusica := Coordinates{Latitude: 41.158889, Longitude: -8.630556}
+
+	assert.Zero(t, coliseu.Distance(coliseu))
+	assert.InDelta(t, 2460, coliseu.Distance(casaDaMusica), 50)
+	assert.Equal(t, "https://www.google.com/maps/search/?api=1&query=41.146992,-8.605417", coliseu.MapUrl())
+}
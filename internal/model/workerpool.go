@@ -0,0 +1,85 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// RunParallel call fn for every index in [0, n) using not more than `workers` goroutines at once.
+//
+// When ctx is done no new calls are started, already running calls should watch ctx themselves.
+// Returns when all started calls are finished.
+//
+// Panic of fn doesn't crash the app from the worker goroutine: other calls are finished
+// and the first panic is raised again in the caller goroutine, where it can be recovered
+func RunParallel(ctx context.Context, workers int, n int, fn func(ctx context.Context, i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var (
+		jobs      = make(chan int)
+		wg        = sync.WaitGroup{}
+		panicOnce sync.Once
+		panicked  *workerPanic
+	)
+
+	call := func(i int) {
+		defer func() {
+			if r := recover(); r != nil {
+				panicOnce.Do(func() { panicked = &workerPanic{value: r, stack: debug.Stack()} })
+			}
+		}()
+		fn(ctx, i)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				call(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case <-ctx.Done():
+		case jobs <- i:
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// workerPanic panic of RunParallel call with the stack of the worker goroutine
+type workerPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *workerPanic) String() string {
+	return fmt.Sprintf("%v\n\nworker goroutine:\n%s", p.value, p.stack)
+}
+
+// PanicValue recovered value without RunParallel wrapping, for error messages
+func PanicValue(r any) any {
+	for {
+		p, ok := r.(*workerPanic)
+		if !ok {
+			return r
+		}
+		r = p.value
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RunParallel(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		n       int
+	}{
+		{name: "more jobs than workers", workers: 3, n: 20},
+		{name: "more workers than jobs", workers: 10, n: 2},
+		{name: "zero workers", workers: 0, n: 5},
+		{name: "no jobs", workers: 4, n: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning int32
+			done := make([]bool, tt.n)
+
+			RunParallel(context.Background(), tt.workers, tt.n, func(_ context.Context, i int) {
+				cur := atomic.AddInt32(&running, 1)
+				for {
+					prev := atomic.LoadInt32(&maxRunning)
+					if cur <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, cur) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				done[i] = true
+				atomic.AddInt32(&running, -1)
+			})
+
+			for i, ok := range done {
+				assert.Truef(t, ok, "job %d not called", i)
+			}
+
+			limit := int32(tt.workers)
+			if limit < 1 {
+				limit = 1
+			}
+			assert.LessOrEqual(t, maxRunning, limit, "too many goroutines at once")
+		})
+	}
+}
+
+func Test_RunParallel_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+
+	RunParallel(ctx, 1, 100, func(_ context.Context, i int) {
+		if atomic.AddInt32(&calls, 1) == 3 {
+			cancel()
+		}
+	})
+
+	assert.Less(t, atomic.LoadInt32(&calls), int32(100), "jobs should stop after cancel")
+}
+
+func Test_RunParallel_panic(t *testing.T) {
+	var calls int32
+
+	defer func() {
+		r := recover()
+		if assert.NotNil(t, r, "panic in the caller goroutine") {
+			assert.Equal(t, "index out of range", PanicValue(r))
+		}
+		assert.Equal(t, int32(10), atomic.LoadInt32(&calls), "other calls are finished")
+	}()
+
+	RunParallel(context.Background(), 3, 10, func(_ context.Context, i int) {
+		atomic.AddInt32(&calls, 1)
+		if i == 4 {
+			panic("index out of range")
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+var archiveBucket = []byte("Archive")
+
+type (
+	// ArchiveRepository ended events keyed by id, out of the Event bucket
+	ArchiveRepository struct {
+		dbPath string
+	}
+)
+
+func (r *ArchiveRepository) Add(ev model.ArchivedEvent) bool {
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("archive event|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		if ev.Event.ID == "" {
+			return errors.New("archived event without id")
+		}
+
+		b, err := tx.CreateBucketIfNotExists(archiveBucket)
+		if err != nil {
+			return err
+		}
+
+		evJson, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(ev.Event.ID), evJson)
+	}); err != nil {
+		log.Error("archive event|", err)
+		return false
+	}
+
+	return true
+}
+
+func (r *ArchiveRepository) List() []model.ArchivedEvent {
+	var list []model.ArchivedEvent
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("archived events|", err)
+		return list
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(archiveBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var ev model.ArchivedEvent
+			if err := json.Unmarshal(v, &ev); err != nil {
+				log.Error("decode bolt|", err)
+				return nil
+			}
+			list = append(list, ev)
+			return nil
+		})
+	}); err != nil {
+		log.Error("archived events|", err)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ArchivedAt.After(list[j].ArchivedAt) })
+
+	return list
+}
+
+func (r *ArchiveRepository) GetById(id string) (*model.ArchivedEvent, bool) {
+	var ev *model.ArchivedEvent
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("archived event|", err)
+		return nil, false
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(archiveBucket)
+		if b == nil {
+			return nil
+		}
+
+		v := b.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+
+		return json.Unmarshal(v, &ev)
+	}); err != nil {
+		log.Error("archived event|", err)
+		return nil, false
+	}
+
+	return ev, ev != nil
+}
+
+func (r *ArchiveRepository) Purge(before time.Time) int {
+	n := 0
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("purge archive|", err)
+		return n
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(archiveBucket)
+		if b == nil {
+			return nil
+		}
+
+		var purged [][]byte
+		if err := b.ForEach(func(k, v []byte) error {
+			var ev model.ArchivedEvent
+			if err := json.Unmarshal(v, &ev); err != nil {
+				log.Error("decode bolt|", err)
+				return nil
+			}
+			if ev.ArchivedAt.Before(before) {
+				purged = append(purged, append([]byte(nil), k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range purged {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(purged)
+
+		return nil
+	}); err != nil {
+		log.Error("purge archive|", err)
+		return 0
+	}
+
+	return n
+}
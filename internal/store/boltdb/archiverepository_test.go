@@ -0,0 +1,41 @@
+package boltdb
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveRepository(t *testing.T) {
+	r := &ArchiveRepository{dbPath: filepath.Join(t.TempDir(), "archive_bolt.db")}
+
+	assert.Empty(t, r.List(), "empty db")
+	assert.Zero(t, r.Purge(time.Now()), "empty db")
+
+	archived := time.Date(2030, time.January, 10, 4, 0, 0, 0, time.UTC)
+	for i, id := range []string{"porto:1", "porto:2", "porto:3"} {
+		assert.True(t, r.Add(model.ArchivedEvent{
+			Event:      model.Event{ID: id, Title: "Event " + id},
+			ArchivedAt: archived.AddDate(0, 0, i),
+		}))
+	}
+	assert.False(t, r.Add(model.ArchivedEvent{}), "without id")
+
+	list := r.List()
+	if assert.Len(t, list, 3) {
+		assert.Equal(t, "porto:3", list[0].Event.ID, "the last archived first")
+	}
+
+	ev, ok := r.GetById("porto:2")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Event porto:2", ev.Event.Title)
+		assert.True(t, archived.AddDate(0, 0, 1).Equal(ev.ArchivedAt))
+	}
+
+	assert.Equal(t, 2, r.Purge(archived.AddDate(0, 0, 2)))
+	_, ok = r.GetById("porto:1")
+	assert.False(t, ok, "purged")
+	assert.Len(t, r.List(), 1)
+}
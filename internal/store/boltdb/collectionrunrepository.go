@@ -0,0 +1,132 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// collectionRunsKeep runs kept in db, older are removed
+const collectionRunsKeep = 500
+
+var collectionRunBucket = []byte("CollectionRun")
+
+type (
+	// CollectionRunRepository runs keyed by id, ids are sortable by time
+	CollectionRunRepository struct {
+		dbPath string
+	}
+)
+
+func (r *CollectionRunRepository) Add(run model.CollectionRun) bool {
+	db, err := openEventsDb(r.dbPath)
+	if err != nil {
+		log.Error("add collection run|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		if run.ID == "" {
+			return errors.New("collection run without id")
+		}
+
+		b, err := tx.CreateBucketIfNotExists(collectionRunBucket)
+		if err != nil {
+			return err
+		}
+
+		runJson, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		if err = b.Put([]byte(run.ID), runJson); err != nil {
+			return err
+		}
+
+		n := 0
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+		for k, _ := c.First(); k != nil && n > collectionRunsKeep; k, _ = c.First() {
+			if err = b.Delete(k); err != nil {
+				return err
+			}
+			n--
+		}
+
+		return nil
+	}); err != nil {
+		log.Error("add collection run|", err)
+		return false
+	}
+
+	return true
+}
+
+func (r *CollectionRunRepository) List(limit int) []model.CollectionRun {
+	var runs []model.CollectionRun
+
+	db, err := openEventsDb(r.dbPath)
+	if err != nil {
+		log.Error("collection runs|", err)
+		return runs
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(collectionRunBucket)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && len(runs) < limit; k, v = c.Prev() {
+			var run model.CollectionRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				log.Error("decode bolt|", err)
+				continue
+			}
+			runs = append(runs, run)
+		}
+
+		return nil
+	}); err != nil {
+		log.Error("collection runs|", err)
+	}
+
+	return runs
+}
+
+func (r *CollectionRunRepository) GetById(id string) (*model.CollectionRun, bool) {
+	var run *model.CollectionRun
+
+	db, err := openEventsDb(r.dbPath)
+	if err != nil {
+		log.Error("collection run|", err)
+		return nil, false
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(collectionRunBucket)
+		if b == nil {
+			return nil
+		}
+
+		v := b.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+
+		return json.Unmarshal(v, &run)
+	}); err != nil {
+		log.Error("collection run|", err)
+		return nil, false
+	}
+
+	return run, run != nil
+}
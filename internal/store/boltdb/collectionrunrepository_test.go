@@ -0,0 +1,40 @@
+package boltdb
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollectionRunRepository(t *testing.T) {
+	r := &CollectionRunRepository{dbPath: filepath.Join(t.TempDir(), "runs_bolt.db")}
+
+	assert.Empty(t, r.List(10), "empty db")
+	_, ok := r.GetById("20300106T100000.000000000Z")
+	assert.False(t, ok, "empty db")
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		run := model.NewCollectionRun(model.TriggerManual)
+		run.Added = []string{"event " + string(rune('a'+i))}
+		run.Finished = run.Started.Add(time.Second)
+		assert.True(t, r.Add(run))
+		ids = append(ids, run.ID)
+		time.Sleep(time.Millisecond)
+	}
+	assert.False(t, r.Add(model.CollectionRun{}), "without id")
+
+	runs := r.List(2)
+	if assert.Len(t, runs, 2) {
+		assert.Equal(t, ids[2], runs[0].ID, "the newest first")
+		assert.Equal(t, ids[1], runs[1].ID)
+	}
+
+	run, ok := r.GetById(ids[0])
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"event a"}, run.Added)
+		assert.Equal(t, model.TriggerManual, run.Trigger)
+	}
+}
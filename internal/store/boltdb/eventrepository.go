@@ -3,6 +3,7 @@ package boltdb
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/boltdb/bolt"
 	"github.com/oleksiy-os/porto-events/internal/model"
 	"github.com/oleksiy-os/porto-events/internal/store"
@@ -41,8 +42,8 @@ func (r *EventRepository) Get() *map[string]model.Event {
 			}
 		}
 
-		var val model.Event
 		if err = b.ForEach(func(k, v []byte) error {
+			var val model.Event // fresh value per record, old records have no newer fields
 			if err = json.Unmarshal(v, &val); err != nil {
 				log.Error("decode bolt|", err)
 			}
@@ -83,15 +84,15 @@ func (r *EventRepository) GetCategoryPublish() *[]model.Event {
 }
 
 func (r *EventRepository) Add(event *model.Event) {
+	if event.ID == "" { // event of the source without ids, see model.EventId
+		event.ID = model.EventId(event.SourceName, event.SourceId, *event)
+	}
+
 	if _, isExist := r.GetById(event.ID); isExist {
 		log.Debugln("add event, already exists, will be skipped|", event.ID, event.Title)
 		return
 	}
 
-	if event.ID == "" {
-		event.ID = event.Title
-	}
-
 	db, err := r.openDb()
 	defer closeDb(db)
 	if err != nil {
@@ -229,9 +230,30 @@ func closeDb(db *bolt.DB) {
 }
 
 func (r *EventRepository) openDb() (*bolt.DB, error) {
-	if r.dbPath == "" {
-		r.dbPath = dbPath
+	return openEventsDb(r.dbPath)
+}
+
+// openDb at path, default dbPath if path is empty
+func openDb(path string) (*bolt.DB, error) {
+	if path == "" {
+		path = dbPath
+	}
+
+	return bolt.Open(path, 0600, nil)
+}
+
+// openEventsDb db of events and collection runs at path, data of old versions is migrated, see migrations.
+// Other repositories open their files by openDb, migrations of events never touch them
+func openEventsDb(path string) (*bolt.DB, error) {
+	db, err := openDb(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migrate(db); err != nil {
+		closeDb(db)
+		return nil, fmt.Errorf("migrate db: %w", err)
 	}
 
-	return bolt.Open(r.dbPath, 0600, nil)
+	return db, nil
 }
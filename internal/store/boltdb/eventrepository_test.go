@@ -7,18 +7,31 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
-const testDbPath = "tests_events_bolt.db"
-
 var (
 	logTestHook = NewTestLogger()
-	repo        = &EventRepository{
-		dbPath: testDbPath,
-	}
+	repo        = &EventRepository{} // db in a temporary dir, see TestMain
 )
 
+// TestMain with the test db out of the checked-in files
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "boltdb_test")
+	if err != nil {
+		log.Fatal("temp dir|", err)
+	}
+	repo.dbPath = filepath.Join(dir, "tests_events_bolt.db")
+
+	code := m.Run()
+	if err = os.RemoveAll(dir); err != nil {
+		log.Error("remove temp dir|", err)
+	}
+	os.Exit(code)
+}
+
 func TestEventRepository_Add(t *testing.T) {
 	if err := resetTestDb(repo); err != nil {
 		t.Fatal("failed create test db|", err)
@@ -63,6 +76,16 @@ func TestEventRepository_Add(t *testing.T) {
 			wantLogMessage: false,
 			wantAddOk:      true,
 		},
+		{
+			name: "ok without id, hash id of the source",
+			event: &model.Event{
+				Url:        "https://ev2.com",
+				Title:      "Event 2",
+				SourceName: "agendaculturalporto",
+			},
+			wantLogMessage: false,
+			wantAddOk:      true,
+		},
 		{
 			name: "already exist, skip adding",
 			event: &model.Event{
@@ -368,6 +391,7 @@ func Test_validateCategory(t *testing.T) {
 
 func TestEventRepository_openDb(t *testing.T) {
 	r := &EventRepository{}
+	dir := t.TempDir()
 
 	tests := []struct {
 		name   string
@@ -376,7 +400,7 @@ func TestEventRepository_openDb(t *testing.T) {
 	}{
 		{
 			name:   "ok",
-			dbPath: "events_bolt.db",
+			dbPath: filepath.Join(dir, "events_bolt.db"),
 			want:   true,
 		},
 		{
@@ -0,0 +1,154 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	gazetteerBucket    = []byte("Gazetteer")
+	geocodeCacheBucket = []byte("GeocodeCache")
+)
+
+type (
+	// GazetteerRepository entries of the gazetteer keyed by "<name>|<number>", geocodes cached by address
+	GazetteerRepository struct {
+		dbPath string
+	}
+)
+
+func (r *GazetteerRepository) Import(entries []model.GazetteerEntry) bool {
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("import gazetteer|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{gazetteerBucket, geocodeCacheBucket} {
+			if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+
+		b, err := tx.CreateBucket(gazetteerBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			entryJson, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err = b.Put([]byte(entry.Name+"|"+entry.Number), entryJson); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		log.Error("import gazetteer|", err)
+		return false
+	}
+
+	return true
+}
+
+func (r *GazetteerRepository) List() []model.GazetteerEntry {
+	var list []model.GazetteerEntry
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("gazetteer|", err)
+		return list
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(gazetteerBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var entry model.GazetteerEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				log.Error("decode bolt|", err)
+				return nil
+			}
+			list = append(list, entry)
+			return nil
+		})
+	}); err != nil {
+		log.Error("gazetteer|", err)
+	}
+
+	return list
+}
+
+func (r *GazetteerRepository) Cached(address string) (*model.Geocode, bool) {
+	var g *model.Geocode
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("cached geocode|", err)
+		return nil, false
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(geocodeCacheBucket)
+		if b == nil {
+			return nil
+		}
+
+		v := b.Get([]byte(address))
+		if v == nil {
+			return nil
+		}
+
+		return json.Unmarshal(v, &g)
+	}); err != nil {
+		log.Error("cached geocode|", err)
+		return nil, false
+	}
+
+	return g, g != nil
+}
+
+func (r *GazetteerRepository) Cache(address string, g model.Geocode) bool {
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("cache geocode|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		if address == "" {
+			return errors.New("geocode without address")
+		}
+
+		b, err := tx.CreateBucketIfNotExists(geocodeCacheBucket)
+		if err != nil {
+			return err
+		}
+
+		gJson, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(address), gJson)
+	}); err != nil {
+		log.Error("cache geocode|", err)
+		return false
+	}
+
+	return true
+}
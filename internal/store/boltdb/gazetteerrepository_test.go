@@ -0,0 +1,47 @@
+package boltdb
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestGazetteerRepository(t *testing.T) {
+	r := &GazetteerRepository{dbPath: filepath.Join(t.TempDir(), "gazetteer_bolt.db")}
+
+	assert.Empty(t, r.List(), "empty db")
+	_, ok := r.Cached("rua passos manuel 137")
+	assert.False(t, ok, "empty db")
+
+	assert.True(t, r.Import([]model.GazetteerEntry{
+		{Name: "Rua de Passos Manuel", Coordinates: model.Coordinates{Latitude: 41.14725, Longitude: -8.6059}},
+		{Name: "Rua de Passos Manuel", Number: "137", Coordinates: model.Coordinates{Latitude: 41.146992, Longitude: -8.605417}},
+	}))
+	assert.Len(t, r.List(), 2)
+
+	c := &model.Coordinates{Latitude: 41.146992, Longitude: -8.605417}
+	assert.True(t, r.Cache("rua passos manuel 137", model.Geocode{Coordinates: c, Name: "Rua de Passos Manuel", Number: "137"}))
+	assert.True(t, r.Cache("rua nova", model.Geocode{}), "not found")
+	assert.False(t, r.Cache("", model.Geocode{}), "without address")
+
+	g, ok := r.Cached("rua passos manuel 137")
+	if assert.True(t, ok) {
+		assert.Equal(t, c, g.Coordinates)
+		assert.Equal(t, "137", g.Number)
+	}
+	g, ok = r.Cached("rua nova")
+	if assert.True(t, ok) {
+		assert.Nil(t, g.Coordinates)
+	}
+
+	assert.True(t, r.Import([]model.GazetteerEntry{
+		{Name: "Teatro Rivoli", Coordinates: model.Coordinates{Latitude: 41.1484, Longitude: -8.6105}},
+	}))
+	list := r.List()
+	if assert.Len(t, list, 1, "replaced entries") {
+		assert.Equal(t, "Teatro Rivoli", list[0].Name)
+	}
+	_, ok = r.Cached("rua passos manuel 137")
+	assert.False(t, ok, "cache is removed on import")
+}
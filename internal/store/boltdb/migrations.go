@@ -0,0 +1,296 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	metaBucket       = []byte("Meta")
+	schemaVersionKey = []byte("schema_version")
+
+	// migrations of the events db data in order, see openEventsDb, migrations[i] upgrades the db from version i to i+1.
+	// Migrations must be safe to run again on migrated data
+	migrations = []func(tx *bolt.Tx) error{
+		migrateEventDates, // 1: Start, End and AllDay of events instead of Timestamp
+		migrateEventIds,   // 2: "<source name>:<source id>" keys of events, see model.EventId
+	}
+
+	migrated sync.Map // paths of db files migrated by the process
+
+	legacyTimeRegex = regexp.MustCompile(`^\s*(\d{1,2}:\d{2})(?:\s*-\s*(\d{1,2}:\d{2}))?`)
+
+	// legacySources names of the sources by hosts of their events urls, for events saved before namespaced ids
+	legacySources = map[string]string{
+		"porto.pt":                  "porto",
+		"agendaculturalporto.org":   "agendaculturalporto",
+		"teatromunicipaldoporto.pt": "teatromunicipaldoporto",
+	}
+)
+
+// migrate db to the last schema version, once per process for every db file
+func migrate(db *bolt.DB) error {
+	if _, ok := migrated.Load(db.Path()); ok {
+		return nil
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		version := 0
+		if v := meta.Get(schemaVersionKey); len(v) == 8 {
+			version = int(binary.BigEndian.Uint64(v))
+		}
+		if version >= len(migrations) {
+			return nil
+		}
+
+		for ; version < len(migrations); version++ {
+			if err = migrations[version](tx); err != nil {
+				return fmt.Errorf("migration %d: %w", version+1, err)
+			}
+			log.Infoln("db migrated to version|", version+1, db.Path())
+		}
+
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, uint64(version))
+
+		return meta.Put(schemaVersionKey, v)
+	}); err != nil {
+		return err
+	}
+
+	migrated.Store(db.Path(), true)
+
+	return nil
+}
+
+// migrateEventDates fill Start, End and AllDay of events saved with Timestamp only. DateText is kept, it might be edited
+func migrateEventDates(tx *bolt.Tx) error {
+	b := tx.Bucket([]byte("Event"))
+	if b == nil {
+		return nil
+	}
+
+	updated := make(map[string][]byte)
+	if err := b.ForEach(func(k, v []byte) error {
+		var legacy struct {
+			model.Event
+			Timestamp time.Time
+		}
+		if err := json.Unmarshal(v, &legacy); err != nil {
+			log.Error("decode bolt|", err)
+			return nil
+		}
+		if !legacy.Start.IsZero() {
+			return nil
+		}
+
+		ev := legacy.Event
+		ev.Start, ev.End, ev.AllDay = legacyDates(ev.DateText, ev.Time, legacy.Timestamp)
+		if ev.Start.IsZero() {
+			return nil
+		}
+
+		evJson, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		updated[string(k)] = evJson
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for k, v := range updated {
+		if err := b.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateEventIds namespaced keys of events saved with ids of their sources, the same for events of different sources.
+// The source is known by the event url, events of other sources keep their keys. Events saved by title have no
+// id in the source, they get a hash one. References to renamed events in events and collection runs are renamed too
+func migrateEventIds(tx *bolt.Tx) error {
+	b := tx.Bucket([]byte("Event"))
+	if b == nil {
+		return nil
+	}
+
+	events := make(map[string]model.Event)
+	renamed := make(map[string]string) // old key -> new one
+	if err := b.ForEach(func(k, v []byte) error {
+		var ev model.Event
+		if err := json.Unmarshal(v, &ev); err != nil {
+			log.Error("decode bolt|", err)
+			return nil
+		}
+		events[string(k)] = ev
+
+		name := legacySourceName(ev.Url)
+		if ev.SourceName != "" || name == "" {
+			return nil
+		}
+
+		sourceId := string(k)
+		if sourceId == ev.Title { // the source has no ids
+			sourceId = ""
+		}
+		ev.SetSourceId(name, sourceId)
+		if b.Get([]byte(ev.ID)) != nil {
+			log.Warnln("migrate event id, already exists|", string(k), ev.ID)
+			return nil
+		}
+		events[string(k)], renamed[string(k)] = ev, ev.ID
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(renamed) == 0 {
+		return nil
+	}
+
+	rename := func(ids []string) []string {
+		for i, id := range ids {
+			if newId, ok := renamed[id]; ok {
+				ids[i] = newId
+			}
+		}
+		return ids
+	}
+
+	for k, ev := range events {
+		ev.Merged, ev.NotDuplicates = rename(ev.Merged), rename(ev.NotDuplicates)
+		if newId, ok := renamed[ev.PossibleDuplicate]; ok {
+			ev.PossibleDuplicate = newId
+		}
+
+		evJson, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		key := k
+		if newId, ok := renamed[k]; ok {
+			if err = b.Delete([]byte(k)); err != nil {
+				return err
+			}
+			key = newId
+		}
+		if err = b.Put([]byte(key), evJson); err != nil {
+			return err
+		}
+	}
+
+	runs := tx.Bucket(collectionRunBucket)
+	if runs == nil {
+		return nil
+	}
+
+	updated := make(map[string][]byte)
+	if err := runs.ForEach(func(k, v []byte) error {
+		var run model.CollectionRun
+		if err := json.Unmarshal(v, &run); err != nil {
+			log.Error("decode bolt|", err)
+			return nil
+		}
+		run.Added, run.Skipped, run.Merged = rename(run.Added), rename(run.Skipped), rename(run.Merged)
+
+		runJson, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		updated[string(k)] = runJson
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for k, v := range updated {
+		if err := runs.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// legacySourceName of the event by its url, empty for unknown sources
+func legacySourceName(eventUrl string) string {
+	u, err := url.Parse(eventUrl)
+	if err != nil {
+		return ""
+	}
+
+	return legacySources[strings.TrimPrefix(u.Hostname(), "www.")]
+}
+
+// legacyDates of the event saved before Start and End by its date and time texts:
+// "Jan 02th, 2006 - Jan 03th, 2006" of porto.pt or "02 Jan 2006 - 03 Jan 2006" of other sources, "19:30 - 21:00".
+// Unknown date text falls back to Timestamp, it has Lisbon wall clock (or the save time for ongoing events)
+func legacyDates(dateText string, timeText string, timestamp time.Time) (start time.Time, end time.Time, allDay bool) {
+	parse := func(v string) (time.Time, bool) {
+		for _, layout := range []string{"Jan 02th, 2006", "02 Jan 2006"} {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), model.Lisbon); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+
+	firstText, lastText, isRange := strings.Cut(dateText, " - ")
+	first, ok := parse(firstText)
+	if !ok {
+		if timestamp.IsZero() {
+			return start, end, false
+		}
+		ts := timestamp
+		return time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), 0, 0, model.Lisbon), end, false
+	}
+
+	last := first
+	if d, ok := parse(lastText); isRange && ok {
+		last = d
+	}
+
+	clocks := legacyTimeRegex.FindStringSubmatch(timeText)
+	if clocks == nil {
+		return first, last.AddDate(0, 0, 1), true
+	}
+
+	at := func(day time.Time, clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, model.Lisbon)
+	}
+
+	start = at(first, clocks[1])
+	switch {
+	case clocks[2] != "":
+		end = at(last, clocks[2])
+		if !end.After(start) { // ends after midnight
+			end = end.AddDate(0, 0, 1)
+		}
+	case isRange:
+		end = at(last, clocks[1])
+	}
+
+	return start, end, false
+}
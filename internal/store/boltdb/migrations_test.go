@@ -0,0 +1,226 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigrate_eventDates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old_bolt.db")
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("Event"))
+		if err != nil {
+			return err
+		}
+		for id, record := range map[string]string{
+			"36013": `{"ID":"36013","Title":"Show","DateText":"Jul 10th, 2030 - Jul 11th, 2030","Time":"21:00 - 23:00","Timestamp":"2030-07-10T21:00:00Z","Category":1}`,
+			"35215": `{"ID":"35215","Title":"Concert","DateText":"06 Jan 2030","Time":"","Timestamp":"2030-01-06T00:00:00Z"}`,
+		} {
+			if err := b.Put([]byte(id), []byte(record)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	closeDb(db)
+
+	r := &EventRepository{dbPath: path}
+
+	show, ok := r.GetById("36013")
+	if assert.True(t, ok) {
+		assert.Truef(t, time.Date(2030, time.July, 10, 20, 0, 0, 0, time.UTC).Equal(show.Start), "summer time, %s", show.Start)
+		assert.Truef(t, time.Date(2030, time.July, 11, 22, 0, 0, 0, time.UTC).Equal(show.End), "end %s", show.End)
+		assert.Equal(t, "Jul 10th, 2030 - Jul 11th, 2030", show.DateText, "text is kept")
+		assert.Equal(t, uint8(1), show.Category)
+	}
+
+	concert, ok := r.GetById("35215")
+	if assert.True(t, ok) {
+		assert.True(t, concert.AllDay)
+		assert.Truef(t, time.Date(2030, time.January, 6, 0, 0, 0, 0, model.Lisbon).Equal(concert.Start), "start %s", concert.Start)
+		assert.Truef(t, time.Date(2030, time.January, 7, 0, 0, 0, 0, model.Lisbon).Equal(concert.End), "end %s", concert.End)
+	}
+
+	db, err = bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDb(db)
+	_ = db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(metaBucket).Get(schemaVersionKey)
+		if assert.Len(t, v, 8) {
+			assert.Equal(t, uint64(len(migrations)), binary.BigEndian.Uint64(v))
+		}
+		assert.NotContains(t, string(tx.Bucket([]byte("Event")).Get([]byte("36013"))), "Timestamp")
+		return nil
+	})
+}
+
+func TestMigrate_eventIds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old_bolt.db")
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucket(metaBucket)
+		if err != nil {
+			return err
+		}
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, 1) // dates are migrated
+		if err = meta.Put(schemaVersionKey, v); err != nil {
+			return err
+		}
+
+		b, err := tx.CreateBucket([]byte("Event"))
+		if err != nil {
+			return err
+		}
+		for id, record := range map[string]string{
+			"36013":           `{"ID":"36013","Url":"https://www.porto.pt/en/event/orfelia/","Title":"Orfélia","Category":1}`,
+			"5001":            `{"ID":"5001","Url":"https://agendaculturalporto.org/orfelia/","Title":"Orfélia ao vivo","PossibleDuplicate":"36013"}`,
+			"Hamlet":          `{"ID":"Hamlet","Url":"https://www.teatromunicipaldoporto.pt/en/programa/2030/hamlet/","Title":"Hamlet"}`,
+			"uid@example.com": `{"ID":"uid@example.com","Url":"https://example.com/agenda/1","Title":"Feira"}`,
+		} {
+			if err := b.Put([]byte(id), []byte(record)); err != nil {
+				return err
+			}
+		}
+
+		runs, err := tx.CreateBucket(collectionRunBucket)
+		if err != nil {
+			return err
+		}
+		return runs.Put([]byte("20300101T100000.000000000Z"), []byte(`{"id":"20300101T100000.000000000Z","added":["36013","uid@example.com"],"skipped":["5001"]}`))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	closeDb(db)
+
+	r := &EventRepository{dbPath: path}
+
+	_, ok := r.GetById("36013")
+	assert.False(t, ok, "renamed")
+
+	porto, ok := r.GetById("porto:36013")
+	if assert.True(t, ok) {
+		assert.Equal(t, "porto", porto.SourceName)
+		assert.Equal(t, "36013", porto.SourceId)
+		assert.Equal(t, uint8(1), porto.Category)
+	}
+
+	agenda, ok := r.GetById("agendaculturalporto:5001")
+	if assert.True(t, ok) {
+		assert.Equal(t, "porto:36013", agenda.PossibleDuplicate, "references are renamed")
+	}
+
+	withoutId := false
+	for id, ev := range *r.Get() {
+		if ev.Title == "Hamlet" {
+			withoutId = true
+			assert.Regexp(t, `^teatromunicipaldoporto:[0-9a-f]{16}$`, id, "hash id of the event saved by title")
+			assert.Equal(t, model.EventId("teatromunicipaldoporto", "", ev), id, "the id of the event collected again")
+			assert.Empty(t, ev.SourceId)
+		}
+	}
+	assert.True(t, withoutId)
+
+	_, ok = r.GetById("uid@example.com")
+	assert.True(t, ok, "unknown source, the key is kept")
+
+	run, ok := (&CollectionRunRepository{dbPath: path}).GetById("20300101T100000.000000000Z")
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"porto:36013", "uid@example.com"}, run.Added)
+		assert.Equal(t, []string{"agendaculturalporto:5001"}, run.Skipped)
+	}
+}
+
+func TestMigrate_eventsDbOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "venue_bolt.db")
+
+	assert.True(t, (&VenueRepository{dbPath: path}).Save(model.Venue{ID: "coliseu-porto", Name: "Coliseu Porto"}))
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDb(db)
+	_ = db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(metaBucket), "not migrated")
+		return nil
+	})
+}
+
+func Test_legacyDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		dateText   string
+		timeText   string
+		timestamp  time.Time
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantAllDay bool
+	}{
+		{
+			name:      "porto.pt, date range with daily hours",
+			dateText:  "May 12th, 2022 - Dec 31th, 2022",
+			timeText:  "10:00 - 18:00",
+			wantStart: time.Date(2022, time.May, 12, 10, 0, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2022, time.December, 31, 18, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name:      "ends after midnight",
+			dateText:  "06 Jan 2024",
+			timeText:  "22:00 - 02:00",
+			wantStart: time.Date(2024, time.January, 6, 22, 0, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2024, time.January, 7, 2, 0, 0, 0, model.Lisbon),
+		},
+		{
+			name:      "sessions times",
+			dateText:  "12 Jan 2030 - 13 Jan 2030",
+			timeText:  "19:30, 17:00",
+			wantStart: time.Date(2030, time.January, 12, 19, 30, 0, 0, model.Lisbon),
+			wantEnd:   time.Date(2030, time.January, 13, 19, 30, 0, 0, model.Lisbon),
+		},
+		{
+			name:       "without time",
+			dateText:   "01 Apr 2030 - 30 Apr 2030",
+			wantStart:  time.Date(2030, time.April, 1, 0, 0, 0, 0, model.Lisbon),
+			wantEnd:    time.Date(2030, time.May, 1, 0, 0, 0, 0, model.Lisbon),
+			wantAllDay: true,
+		},
+		{
+			name:      "unknown text, timestamp",
+			dateText:  "sábado, 12 de janeiro",
+			timeText:  "19:30",
+			timestamp: time.Date(2030, time.January, 12, 19, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2030, time.January, 12, 19, 30, 0, 0, model.Lisbon),
+		},
+		{
+			name:     "without dates",
+			dateText: "soon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, allDay := legacyDates(tt.dateText, tt.timeText, tt.timestamp)
+			assert.Truef(t, tt.wantStart.Equal(start), "start %s", start)
+			assert.Truef(t, tt.wantEnd.Equal(end), "end %s", end)
+			assert.Equal(t, tt.wantAllDay, allDay)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// sourceRunsKeep runs of every source kept in db, older are removed
+const sourceRunsKeep = 100
+
+var sourceRunBucket = []byte("SourceRun")
+
+type (
+	// SourceRunRepository runs of every source in own nested bucket by source url, keyed by start time
+	SourceRunRepository struct {
+		dbPath string
+	}
+)
+
+func (r *SourceRunRepository) Add(run model.SourceRun) bool {
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("add source run|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(sourceRunBucket)
+		if err != nil {
+			return err
+		}
+		b, err := root.CreateBucketIfNotExists([]byte(run.Url))
+		if err != nil {
+			return err
+		}
+
+		runJson, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		if err = b.Put(runKey(run), runJson); err != nil {
+			return err
+		}
+
+		n := 0
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+		for k, _ := c.First(); k != nil && n > sourceRunsKeep; k, _ = c.First() {
+			if err = b.Delete(k); err != nil {
+				return err
+			}
+			n--
+		}
+
+		return nil
+	}); err != nil {
+		log.Error("add source run|", err)
+		return false
+	}
+
+	return true
+}
+
+func (r *SourceRunRepository) Runs(sourceUrl string, limit int) []model.SourceRun {
+	var runs []model.SourceRun
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("source runs|", err)
+		return runs
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(sourceRunBucket)
+		if root == nil {
+			return nil
+		}
+		b := root.Bucket([]byte(sourceUrl))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && len(runs) < limit; k, v = c.Prev() {
+			var run model.SourceRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				log.Error("decode bolt|", err)
+				continue
+			}
+			runs = append(runs, run)
+		}
+
+		return nil
+	}); err != nil {
+		log.Error("source runs|", err)
+	}
+
+	return runs
+}
+
+// runKey sortable by time key of the run
+func runKey(run model.SourceRun) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(run.Started.UnixNano()))
+
+	return key
+}
@@ -0,0 +1,40 @@
+package boltdb
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSourceRunRepository(t *testing.T) {
+	r := &SourceRunRepository{dbPath: filepath.Join(t.TempDir(), "runs_bolt.db")}
+
+	assert.Empty(t, r.Runs("https://www.porto.pt", 10), "empty db")
+
+	started := time.Date(2030, time.January, 6, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < sourceRunsKeep+5; i++ {
+		ok := r.Add(model.SourceRun{
+			Source:  "porto",
+			Url:     "https://www.porto.pt",
+			Started: started.Add(time.Duration(i) * time.Hour),
+			Events:  i,
+		})
+		assert.True(t, ok)
+	}
+	assert.True(t, r.Add(model.SourceRun{Source: "porto", Url: "https://www.porto.pt/en", Started: started, Events: 1}))
+
+	runs := r.Runs("https://www.porto.pt", 3)
+	if assert.Len(t, runs, 3) {
+		assert.Equal(t, sourceRunsKeep+4, runs[0].Events, "the newest first")
+		assert.Equal(t, sourceRunsKeep+2, runs[2].Events)
+	}
+
+	runs = r.Runs("https://www.porto.pt", 1000)
+	if assert.Len(t, runs, sourceRunsKeep, "old runs removed") {
+		assert.Equal(t, 5, runs[len(runs)-1].Events)
+	}
+
+	assert.Len(t, r.Runs("https://www.porto.pt/en", 10), 1, "runs by source url")
+}
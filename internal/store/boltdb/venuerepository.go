@@ -0,0 +1,138 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/boltdb/bolt"
+	"github.com/oleksiy-os/porto-events/internal/model"
+	log "github.com/sirupsen/logrus"
+	"sort"
+)
+
+var venueBucket = []byte("Venue")
+
+type (
+	// VenueRepository known places of events keyed by venue id
+	VenueRepository struct {
+		dbPath string
+	}
+)
+
+func (r *VenueRepository) List() []model.Venue {
+	var list []model.Venue
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("venues|", err)
+		return list
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(venueBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var venue model.Venue
+			if err := json.Unmarshal(v, &venue); err != nil {
+				log.Error("decode bolt|", err)
+				return nil
+			}
+			list = append(list, venue)
+			return nil
+		})
+	}); err != nil {
+		log.Error("venues|", err)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func (r *VenueRepository) GetById(id string) (*model.Venue, bool) {
+	var venue *model.Venue
+
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("venue|", err)
+		return nil, false
+	}
+	defer closeDb(db)
+
+	if err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(venueBucket)
+		if b == nil {
+			return nil
+		}
+
+		v := b.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+
+		return json.Unmarshal(v, &venue)
+	}); err != nil {
+		log.Error("venue|", err)
+		return nil, false
+	}
+
+	return venue, venue != nil
+}
+
+func (r *VenueRepository) Save(venue model.Venue) bool {
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("save venue|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		if venue.ID == "" {
+			return errors.New("venue without id")
+		}
+
+		b, err := tx.CreateBucketIfNotExists(venueBucket)
+		if err != nil {
+			return err
+		}
+
+		venueJson, err := json.Marshal(venue)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(venue.ID), venueJson)
+	}); err != nil {
+		log.Error("save venue|", err)
+		return false
+	}
+
+	return true
+}
+
+func (r *VenueRepository) Delete(id string) bool {
+	db, err := openDb(r.dbPath)
+	if err != nil {
+		log.Error("delete venue|", err)
+		return false
+	}
+	defer closeDb(db)
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(venueBucket)
+		if b == nil || b.Get([]byte(id)) == nil {
+			return errors.New("not found venue " + id)
+		}
+
+		return b.Delete([]byte(id))
+	}); err != nil {
+		log.Error("delete venue|", err)
+		return false
+	}
+
+	return true
+}
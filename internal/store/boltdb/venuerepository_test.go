@@ -0,0 +1,45 @@
+package boltdb
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestVenueRepository(t *testing.T) {
+	r := &VenueRepository{dbPath: filepath.Join(t.TempDir(), "venue_bolt.db")}
+
+	assert.Empty(t, r.List(), "empty db")
+	assert.False(t, r.Delete("coliseu-porto"), "empty db")
+
+	assert.True(t, r.Save(model.Venue{ID: "coliseu-porto", Name: "Coliseu Porto", Aliases: []string{"Coliseu Porto Ageas"}}))
+	assert.True(t, r.Save(model.Venue{
+		ID:          "casa-da-musica",
+		Name:        "Casa da Música",
+		Address:     "Av. da Boavista 604-610, Porto",
+		Coordinates: &model.Coordinates{Latitude: 41.158889, Longitude: -8.630556},
+	}))
+	assert.False(t, r.Save(model.Venue{Name: "Without id"}))
+
+	list := r.List()
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "Casa da Música", list[0].Name, "sorted by name")
+	}
+
+	v, ok := r.GetById("casa-da-musica")
+	if assert.True(t, ok) && assert.NotNil(t, v.Coordinates) {
+		assert.Equal(t, 41.158889, v.Coordinates.Latitude)
+	}
+
+	assert.True(t, r.Save(model.Venue{ID: "coliseu-porto", Name: "Coliseu do Porto"}), "update")
+	v, ok = r.GetById("coliseu-porto")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Coliseu do Porto", v.Name)
+		assert.Empty(t, v.Aliases)
+	}
+
+	assert.True(t, r.Delete("coliseu-porto"))
+	_, ok = r.GetById("coliseu-porto")
+	assert.False(t, ok, "deleted")
+}
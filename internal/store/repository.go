@@ -1,6 +1,9 @@
 package store
 
-import "github.com/oleksiy-os/porto-events/internal/model"
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"time"
+)
 
 const (
 	CategoryNew       = 0
@@ -46,4 +49,65 @@ type (
 		// ChangeCategory event (new OR publish)
 		ChangeCategory(data ChangeCategoryData) bool
 	}
+
+	SourceRunRepository interface {
+		// Add statistics of the source run
+		Add(run model.SourceRun) bool
+
+		// Runs of the source by its url, the newest first, not more than limit
+		Runs(sourceUrl string, limit int) []model.SourceRun
+	}
+
+	CollectionRunRepository interface {
+		// Add collection run
+		Add(run model.CollectionRun) bool
+
+		// List of the last runs, the newest first, not more than limit
+		List(limit int) []model.CollectionRun
+
+		// GetById collection run
+		GetById(id string) (*model.CollectionRun, bool)
+	}
+
+	ArchiveRepository interface {
+		// Add ended event to the archive
+		Add(ev model.ArchivedEvent) bool
+
+		// List of archived events, the last archived first
+		List() []model.ArchivedEvent
+
+		// GetById archived event
+		GetById(id string) (*model.ArchivedEvent, bool)
+
+		// Purge events archived before the time, returns count of purged events
+		Purge(before time.Time) int
+	}
+
+	VenueRepository interface {
+		// List of venues sorted by name
+		List() []model.Venue
+
+		// GetById venue
+		GetById(id string) (*model.Venue, bool)
+
+		// Save new or changed venue
+		Save(v model.Venue) bool
+
+		// Delete venue
+		Delete(id string) bool
+	}
+
+	GazetteerRepository interface {
+		// Import entries of the gazetteer instead of the stored ones, cached geocodes are removed
+		Import(entries []model.GazetteerEntry) bool
+
+		// List of the gazetteer entries
+		List() []model.GazetteerEntry
+
+		// Cached geocode of the address
+		Cached(address string) (*model.Geocode, bool)
+
+		// Cache geocode of the address, not found addresses are cached too
+		Cache(address string, g model.Geocode) bool
+	}
 )
@@ -3,4 +3,19 @@ package store
 type StoreInterface interface {
 	//Event repository
 	Event() EventRepository
+
+	//SourceRun repository, statistics of sources runs
+	SourceRun() SourceRunRepository
+
+	//CollectionRun repository, history of events collections
+	CollectionRun() CollectionRunRepository
+
+	//Archive repository, ended events
+	Archive() ArchiveRepository
+
+	//Venue repository, known places of events
+	Venue() VenueRepository
+
+	//Gazetteer repository, local geocoding data
+	Gazetteer() GazetteerRepository
 }
@@ -0,0 +1,51 @@
+package teststore
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"sort"
+	"time"
+)
+
+type (
+	TestArchiveRepository struct {
+		events map[string]model.ArchivedEvent
+	}
+)
+
+func (r *TestArchiveRepository) Add(ev model.ArchivedEvent) bool {
+	if r.events == nil {
+		r.events = make(map[string]model.ArchivedEvent)
+	}
+
+	r.events[ev.Event.ID] = ev
+
+	return true
+}
+
+func (r *TestArchiveRepository) List() []model.ArchivedEvent {
+	var list []model.ArchivedEvent
+	for _, ev := range r.events {
+		list = append(list, ev)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ArchivedAt.After(list[j].ArchivedAt) })
+
+	return list
+}
+
+func (r *TestArchiveRepository) GetById(id string) (*model.ArchivedEvent, bool) {
+	ev, ok := r.events[id]
+
+	return &ev, ok
+}
+
+func (r *TestArchiveRepository) Purge(before time.Time) int {
+	n := 0
+	for id, ev := range r.events {
+		if ev.ArchivedAt.Before(before) {
+			delete(r.events, id)
+			n++
+		}
+	}
+
+	return n
+}
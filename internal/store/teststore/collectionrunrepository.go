@@ -0,0 +1,37 @@
+package teststore
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+)
+
+type (
+	TestCollectionRunRepository struct {
+		runs []model.CollectionRun // the newest last
+	}
+)
+
+func (r *TestCollectionRunRepository) Add(run model.CollectionRun) bool {
+	r.runs = append(r.runs, run)
+
+	return true
+}
+
+func (r *TestCollectionRunRepository) List(limit int) []model.CollectionRun {
+	var runs []model.CollectionRun
+
+	for i := len(r.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		runs = append(runs, r.runs[i])
+	}
+
+	return runs
+}
+
+func (r *TestCollectionRunRepository) GetById(id string) (*model.CollectionRun, bool) {
+	for _, run := range r.runs {
+		if run.ID == id {
+			return &run, true
+		}
+	}
+
+	return nil, false
+}
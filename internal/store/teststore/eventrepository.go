@@ -44,15 +44,15 @@ func (r *TestEventRepository) GetById(id string) (*model.Event, bool) {
 }
 
 func (r *TestEventRepository) Add(event *model.Event) {
+	if event.ID == "" { // event of the source without ids, see model.EventId
+		event.ID = model.EventId(event.SourceName, event.SourceId, *event)
+	}
+
 	if _, isExist := r.GetById(event.ID); isExist {
 		log.Debugln("add event, already exists, will be skipped|", event.ID, event.Title)
 		return
 	}
 
-	if event.ID == "" {
-		event.ID = event.Title
-	}
-
 	r.events[event.ID] = *event
 }
 
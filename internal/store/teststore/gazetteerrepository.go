@@ -0,0 +1,42 @@
+package teststore
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"slices"
+)
+
+type (
+	TestGazetteerRepository struct {
+		entries []model.GazetteerEntry
+		cache   map[string]model.Geocode
+	}
+)
+
+func (r *TestGazetteerRepository) Import(entries []model.GazetteerEntry) bool {
+	r.entries, r.cache = slices.Clone(entries), nil
+
+	return true
+}
+
+func (r *TestGazetteerRepository) List() []model.GazetteerEntry {
+	return slices.Clone(r.entries)
+}
+
+func (r *TestGazetteerRepository) Cached(address string) (*model.Geocode, bool) {
+	g, ok := r.cache[address]
+
+	return &g, ok
+}
+
+func (r *TestGazetteerRepository) Cache(address string, g model.Geocode) bool {
+	if address == "" {
+		return false
+	}
+	if r.cache == nil {
+		r.cache = make(map[string]model.Geocode)
+	}
+
+	r.cache[address] = g
+
+	return true
+}
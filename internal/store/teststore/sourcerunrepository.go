@@ -0,0 +1,32 @@
+package teststore
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+)
+
+type (
+	TestSourceRunRepository struct {
+		runs map[string][]model.SourceRun // by source url, the newest last
+	}
+)
+
+func (r *TestSourceRunRepository) Add(run model.SourceRun) bool {
+	if r.runs == nil {
+		r.runs = make(map[string][]model.SourceRun)
+	}
+
+	r.runs[run.Url] = append(r.runs[run.Url], run)
+
+	return true
+}
+
+func (r *TestSourceRunRepository) Runs(sourceUrl string, limit int) []model.SourceRun {
+	var runs []model.SourceRun
+
+	all := r.runs[sourceUrl]
+	for i := len(all) - 1; i >= 0 && len(runs) < limit; i-- {
+		runs = append(runs, all[i])
+	}
+
+	return runs
+}
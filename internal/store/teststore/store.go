@@ -5,15 +5,45 @@ import (
 )
 
 type Store struct {
-	eventRepository *TestEventRepository
+	eventRepository         *TestEventRepository
+	sourceRunRepository     *TestSourceRunRepository
+	collectionRunRepository *TestCollectionRunRepository
+	archiveRepository       *TestArchiveRepository
+	venueRepository         *TestVenueRepository
+	gazetteerRepository     *TestGazetteerRepository
 }
 
 func (s *Store) Event() store.EventRepository {
 	return s.eventRepository
 }
 
+func (s *Store) SourceRun() store.SourceRunRepository {
+	return s.sourceRunRepository
+}
+
+func (s *Store) CollectionRun() store.CollectionRunRepository {
+	return s.collectionRunRepository
+}
+
+func (s *Store) Archive() store.ArchiveRepository {
+	return s.archiveRepository
+}
+
+func (s *Store) Venue() store.VenueRepository {
+	return s.venueRepository
+}
+
+func (s *Store) Gazetteer() store.GazetteerRepository {
+	return s.gazetteerRepository
+}
+
 func New() *Store {
 	return &Store{
-		eventRepository: &TestEventRepository{},
+		eventRepository:         &TestEventRepository{},
+		sourceRunRepository:     &TestSourceRunRepository{},
+		collectionRunRepository: &TestCollectionRunRepository{},
+		archiveRepository:       &TestArchiveRepository{},
+		venueRepository:         &TestVenueRepository{},
+		gazetteerRepository:     &TestGazetteerRepository{},
 	}
 }
@@ -0,0 +1,51 @@
+package teststore
+
+import (
+	"github.com/oleksiy-os/porto-events/internal/model"
+	"sort"
+)
+
+type (
+	TestVenueRepository struct {
+		venues map[string]model.Venue
+	}
+)
+
+func (r *TestVenueRepository) List() []model.Venue {
+	var list []model.Venue
+	for _, v := range r.venues {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func (r *TestVenueRepository) GetById(id string) (*model.Venue, bool) {
+	v, ok := r.venues[id]
+
+	return &v, ok
+}
+
+func (r *TestVenueRepository) Save(v model.Venue) bool {
+	if v.ID == "" {
+		return false
+	}
+	if r.venues == nil {
+		r.venues = make(map[string]model.Venue)
+	}
+
+	r.venues[v.ID] = v
+
+	return true
+}
+
+func (r *TestVenueRepository) Delete(id string) bool {
+	if _, ok := r.venues[id]; !ok {
+		return false
+	}
+
+	delete(r.venues, id)
+
+	return true
+}
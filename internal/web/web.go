@@ -12,6 +12,10 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -19,19 +23,60 @@ const (
 	htmlPath = "internal/web/templates/"
 )
 
-var templates = template.Must(template.ParseFiles(htmlPath + "home.html"))
+// defaultEditor name of editors of events without auth
+const defaultEditor = "web"
+
+// runsListLimit default count of collection runs in the history
+const runsListLimit = 20
+
+// layoutQueryDate date of the query params: "2006-01-02"
+const layoutQueryDate = "2006-01-02"
+
+var (
+	templateFiles = []string{htmlPath + "home.html", htmlPath + "history.html", htmlPath + "venues.html"}
+	templates     = template.Must(template.ParseFiles(templateFiles...))
+)
 
 type (
 	Server struct {
-		store  store.StoreInterface
-		config *configs.Config
+		store    store.StoreInterface
+		config   *configs.Config
+		archiver *event.Archiver // ended events out of the lists
 	}
+
+	// eventView event of the page with its state derived from the event dates
+	eventView struct {
+		model.Event
+		Ended bool   // the event is over, it's not published
+		Order int    // position in the list of events sorted by dates
+		When  string // dates for people, see model.FormatDates
+		Hours string // times for people
+	}
+
+	// lockData lock of the event field, see model.FieldProvenance
+	lockData struct {
+		Id     string
+		Field  string
+		Locked bool
+	}
+
+	// duplicateData editor decision about the possible duplicate event
+	duplicateData struct {
+		Id     string
+		Action string // duplicateMerge or duplicateSplit
+	}
+)
+
+const (
+	duplicateMerge = "merge"
+	duplicateSplit = "split"
 )
 
 func New(config *configs.Config, store *store.StoreInterface) *Server {
 	s := &Server{
-		store:  *store,
-		config: config,
+		store:    *store,
+		config:   config,
+		archiver: event.NewArchiver(config.Archive),
 	}
 
 	s.configureRouter()
@@ -47,9 +92,20 @@ func (s *Server) configureRouter() {
 	http.HandleFunc("/", s.homeHandler)
 	http.HandleFunc("/move/", s.changeCategoryHandler)
 	http.HandleFunc("/save/", s.saveHandler)
+	http.HandleFunc("/lock/", s.lockHandler)
 	http.HandleFunc("/delete/", s.deleteHandler)
 	http.HandleFunc("/get/", s.getHandler)
 	http.HandleFunc("/publish/", s.publishHandler)
+	http.HandleFunc("/health/", s.healthHandler)
+	http.HandleFunc("/runs/", s.runsHandler)
+	http.HandleFunc("/history/", s.historyHandler)
+	http.HandleFunc("/archive/", s.archiveHandler)
+	http.HandleFunc("/occurrences/", s.occurrencesHandler)
+	http.HandleFunc("/duplicate/", s.duplicateHandler)
+	http.HandleFunc("/reviewed/", s.reviewedHandler)
+	http.HandleFunc("/venues/", s.venuesHandler)
+	http.HandleFunc("/venue/", s.venueHandler)
+	http.HandleFunc("/geocode/", s.geocodeHandler)
 
 	http.HandleFunc("/assets/", s.staticHandler)
 	http.HandleFunc("/templates/", s.staticHandler)
@@ -57,14 +113,128 @@ func (s *Server) configureRouter() {
 
 func (s *Server) homeHandler(w http.ResponseWriter, _ *http.Request) {
 	if !s.config.ProductionMode { // for live changes in html during develop
-		templates = template.Must(template.ParseFiles(htmlPath + "home.html"))
+		templates = template.Must(template.ParseFiles(templateFiles...))
+	}
+
+	s.archiver.RunDue(s.store, time.Now())
+
+	if err := templates.ExecuteTemplate(w, "home.html", eventsView(*s.store.Event().Get(), s.config.Server.Lang, time.Now())); err != nil {
+		log.Error("exec template|", err)
+	}
+}
+
+// historyHandler page of the collection runs history
+func (s *Server) historyHandler(w http.ResponseWriter, _ *http.Request) {
+	if !s.config.ProductionMode { // for live changes in html during develop
+		templates = template.Must(template.ParseFiles(templateFiles...))
 	}
 
-	if err := templates.ExecuteTemplate(w, "home.html", *s.store.Event().Get()); err != nil {
+	if err := templates.ExecuteTemplate(w, "history.html", nil); err != nil {
 		log.Error("exec template|", err)
 	}
 }
 
+// venuesHandler page of the venues registry, for admins
+func (s *Server) venuesHandler(w http.ResponseWriter, _ *http.Request) {
+	if !s.config.ProductionMode { // for live changes in html during develop
+		templates = template.Must(template.ParseFiles(templateFiles...))
+	}
+
+	if err := templates.ExecuteTemplate(w, "venues.html", nil); err != nil {
+		log.Error("exec template|", err)
+	}
+}
+
+// venueHandler JSON of the venues and places of events without venue (GET), saves the venue (PUT, body: venue JSON)
+// or deletes it (DELETE, body: id of the venue)
+func (s *Server) venueHandler(w http.ResponseWriter, r *http.Request) {
+	var data any
+
+	switch r.Method {
+	case "GET":
+		venues := s.store.Venue().List()
+		if venues == nil {
+			venues = []model.Venue{}
+		}
+		data = struct {
+			Venues []model.Venue      `json:"venues"`
+			Places []event.PlaceCount `json:"places"` // places of events without venue
+		}{venues, event.UnmatchedPlaces(s.store)}
+	case "PUT", "DELETE":
+		defer closeBody(r.Body)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || len(body) == 0 {
+			log.Error("read body|", err)
+			http.Error(w, "wrong data", http.StatusBadRequest)
+			return
+		}
+
+		if r.Method == "DELETE" {
+			if err = event.DeleteVenue(s.store, string(body)); err != nil {
+				log.Error("delete venue|", err)
+				http.Error(w, "failed delete data", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var venue model.Venue
+		if err = json.Unmarshal(body, &venue); err != nil {
+			log.Error("unmarshal|", err)
+			http.Error(w, "wrong data", http.StatusBadRequest)
+			return
+		}
+
+		saved, n, err := event.SaveVenue(s.store, venue)
+		if err != nil {
+			log.Error("save venue|", err)
+			http.Error(w, "failed save data", http.StatusInternalServerError)
+			return
+		}
+		data = struct {
+			Venue  *model.Venue `json:"venue"`
+			Events int          `json:"events"` // stored events changed by the venue
+		}{saved, n}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	res, err := json.Marshal(data)
+	if err != nil {
+		log.Error("venues, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(res); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
+// geocodeHandler JSON of the address coordinates by the local gazetteer: "/geocode/?q=Rua de Passos Manuel 137"
+func (s *Server) geocodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	res, err := json.Marshal(event.Geocode(s.store, r.URL.Query().Get("q")))
+	if err != nil {
+		log.Error("geocode, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(res); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
 func (s *Server) changeCategoryHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "PUT" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -117,12 +287,47 @@ func (s *Server) saveHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ok := s.store.Event().Save(&ev); !ok {
+	saved, err := event.SaveEdited(s.store, ev, editorName(r), time.Now())
+	if err != nil {
+		log.Error("save event|", err)
 		http.Error(w, "failed save data", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	writeEvent(w, saved)
+}
+
+// lockHandler lock or unlock the field of the event for collections
+func (s *Server) lockHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	defer closeBody(r.Body)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Error("read body|", err)
+		http.Error(w, "wrong data", http.StatusBadRequest)
+		return
+	}
+
+	var data lockData
+	if err = json.Unmarshal(body, &data); err != nil {
+		log.Error("unmarshal|", err)
+		http.Error(w, "wrong data", http.StatusBadRequest)
+		return
+	}
+
+	ev, err := event.LockField(s.store, data.Id, data.Field, data.Locked)
+	if err != nil {
+		log.Error("lock field|", err)
+		http.Error(w, "failed save data", http.StatusInternalServerError)
+		return
+	}
+
+	writeEvent(w, ev)
 }
 
 func (s *Server) deleteHandler(w http.ResponseWriter, r *http.Request) {
@@ -154,20 +359,23 @@ func (s *Server) getHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sources, err := model.GetSources(s.config.SourcesListPath)
+	sources, err := event.LoadSources(s.config.SourcesListPath)
 	if err != nil {
 		log.Error("parse toml| ", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	events := event.Collect(sources)
+	event.CollectAndStore(r.Context(), s.store, sources, model.TriggerManual) // stops if client disconnected
+	s.archiver.RunDue(s.store, time.Now())
 
-	for _, e := range *events {
-		s.store.Event().Add(&e)
+	for _, h := range s.sourcesHealth(sources) {
+		if h.Status != model.HealthOk {
+			log.WithFields(log.Fields{"source": h.Source, "url": h.Url}).Warnln("source health|", h.Status, h.Problems)
+		}
 	}
 
-	evs, err := json.Marshal(s.store.Event().Get())
+	evs, err := json.Marshal(eventsView(*s.store.Event().Get(), s.config.Server.Lang, time.Now()))
 	if err != nil {
 		log.Error("get events, json marshal|", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -182,6 +390,263 @@ func (s *Server) getHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// runsHandler JSON of the collection runs: "/runs/?limit=N" the last runs, "/runs/{id}" the run with added events
+func (s *Server) runsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var data any
+
+	if id := strings.TrimPrefix(r.URL.Path, "/runs/"); id != "" {
+		run, ok := s.store.CollectionRun().GetById(id)
+		if !ok {
+			http.Error(w, "not found collection run", http.StatusNotFound)
+			return
+		}
+
+		added := make([]model.Event, 0, len(run.Added))
+		for _, eventId := range run.Added {
+			if ev, ok := s.store.Event().GetById(eventId); ok {
+				added = append(added, *ev)
+			}
+		}
+
+		data = struct {
+			Run    *model.CollectionRun `json:"run"`
+			Events []model.Event        `json:"events"` // added events still in the store
+		}{run, added}
+	} else {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit < 1 {
+			limit = runsListLimit
+		}
+
+		runs := s.store.CollectionRun().List(limit)
+		if runs == nil {
+			runs = []model.CollectionRun{}
+		}
+		data = runs
+	}
+
+	res, err := json.Marshal(data)
+	if err != nil {
+		log.Error("collection runs, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(res); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
+// occurrencesHandler JSON of the stored events on their occurrences, sorted by start:
+// "/occurrences/?from=2024-01-20&to=2024-01-22" days in Lisbon, "to" is exclusive, today by default
+func (s *Server) occurrencesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	from := model.LisbonDate(time.Now().In(model.Lisbon))
+	if v := r.URL.Query().Get("from"); v != "" {
+		day, err := time.ParseInLocation(layoutQueryDate, v, model.Lisbon)
+		if err != nil {
+			http.Error(w, "wrong from date", http.StatusBadRequest)
+			return
+		}
+		from = day
+	}
+
+	to := from.AddDate(0, 0, 1)
+	if v := r.URL.Query().Get("to"); v != "" {
+		day, err := time.ParseInLocation(layoutQueryDate, v, model.Lisbon)
+		if err != nil || !day.After(from) {
+			http.Error(w, "wrong to date", http.StatusBadRequest)
+			return
+		}
+		to = day
+	}
+
+	events := make([]model.Event, 0)
+	for _, ev := range *s.store.Event().Get() {
+		events = append(events, ev)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID }) // the same order of simultaneous occurrences
+
+	occurrences := model.ExpandOccurrences(events, from, to)
+	if occurrences == nil {
+		occurrences = []model.EventOccurrence{}
+	}
+
+	res, err := json.Marshal(occurrences)
+	if err != nil {
+		log.Error("occurrences, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(res); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
+// archiveHandler JSON of the archived events, the last archived first: "/archive/?q=fado" events with the text
+// in title, description or place
+func (s *Server) archiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	list := make([]model.ArchivedEvent, 0)
+	for _, ev := range s.store.Archive().List() {
+		text := strings.ToLower(ev.Event.Title + " " + ev.Event.Description + " " + ev.Event.Place)
+		if q == "" || strings.Contains(text, q) {
+			list = append(list, ev)
+		}
+	}
+
+	res, err := json.Marshal(list)
+	if err != nil {
+		log.Error("archive, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(res); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
+// duplicateHandler merge the possible duplicate event into the flagged one, or mark them as different events.
+// Responds with the events after the change
+func (s *Server) duplicateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	defer closeBody(r.Body)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Error("read body|", err)
+		http.Error(w, "wrong data", http.StatusBadRequest)
+		return
+	}
+
+	var data duplicateData
+	if err = json.Unmarshal(body, &data); err != nil {
+		log.Error("unmarshal|", err)
+		http.Error(w, "wrong data", http.StatusBadRequest)
+		return
+	}
+
+	switch data.Action {
+	case duplicateMerge:
+		var sources []model.Source
+		if sources, err = event.LoadSources(s.config.SourcesListPath); err != nil {
+			log.Error("parse toml| ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, err = event.ConfirmDuplicate(s.store, sources, data.Id)
+	case duplicateSplit:
+		err = event.SplitDuplicate(s.store, data.Id)
+	default:
+		http.Error(w, "wrong action", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		log.Error("duplicate|", err)
+		http.Error(w, "failed save data", http.StatusInternalServerError)
+		return
+	}
+
+	evs, err := json.Marshal(eventsView(*s.store.Event().Get(), s.config.Server.Lang, time.Now()))
+	if err != nil {
+		log.Error("duplicate, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(evs); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
+// reviewedHandler changes of the event in its source are seen by an editor. Body: id of the event
+func (s *Server) reviewedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	defer closeBody(r.Body)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || len(body) == 0 {
+		log.Error("read body|", err)
+		http.Error(w, "wrong data", http.StatusBadRequest)
+		return
+	}
+
+	if err = event.ReviewChanges(s.store, string(body)); err != nil {
+		log.Error("review changes|", err)
+		http.Error(w, "failed save data", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// healthHandler health of the sources from the sources list, for admins
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	sources, err := event.LoadSources(s.config.SourcesListPath)
+	if err != nil {
+		log.Error("parse toml| ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	health, err := json.Marshal(s.sourcesHealth(sources))
+	if err != nil {
+		log.Error("sources health, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(health); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
+// sourcesHealth by stored runs of the sources
+func (s *Server) sourcesHealth(sources []model.Source) []model.SourceHealth {
+	health := make([]model.SourceHealth, 0, len(sources))
+	for _, source := range sources {
+		runs := s.store.SourceRun().Runs(source.Url, model.HealthHistoryRuns+1)
+		health = append(health, model.Health(source, runs))
+	}
+
+	return health
+}
+
 func (s *Server) staticHandler(w http.ResponseWriter, r *http.Request) {
 	if _, err := os.Stat(pathWeb + r.URL.Path); err != nil {
 		log.Error("file path|", err)
@@ -196,8 +661,15 @@ func (s *Server) publishHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	now := time.Now()
+	s.archiver.RunDue(s.store, now)
+
 	bot := telegramApi.New(s.config.Telegram)
 	for _, ev := range *s.store.Event().GetCategoryPublish() {
+		if ev.Ended(now) {
+			log.Warnln("publish, event is over, skipped|", ev.ID, ev.Title)
+			continue
+		}
 		if err := bot.Publish(&ev); err != nil {
 			continue
 		}
@@ -210,6 +682,55 @@ func (s *Server) publishHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// eventsView events of the page by id, ordered and marked as ended by their dates at now, dates formatted in lang
+func eventsView(events map[string]model.Event, lang model.Lang, now time.Time) map[string]eventView {
+	list := make([]model.Event, 0, len(events))
+	for _, ev := range events {
+		list = append(list, ev)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		switch {
+		case list[i].Before(list[j]):
+			return true
+		case list[j].Before(list[i]):
+			return false
+		}
+		return list[i].ID < list[j].ID // the same order of events with the same dates
+	})
+
+	view := make(map[string]eventView, len(list))
+	for i, ev := range list {
+		when, hours := model.FormatDates(ev, lang, now)
+		view[ev.ID] = eventView{Event: ev, Ended: ev.Ended(now), Order: i, When: when, Hours: hours}
+	}
+
+	return view
+}
+
+// editorName of the request: user of basic auth of the proxy in front of the server, defaultEditor without auth
+func editorName(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok && user != "" {
+		return user
+	}
+
+	return defaultEditor
+}
+
+// writeEvent JSON of the event to the response
+func writeEvent(w http.ResponseWriter, ev *model.Event) {
+	res, err := json.Marshal(ev)
+	if err != nil {
+		log.Error("event, json marshal|", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(res); err != nil {
+		log.Error("write data to response|", err)
+	}
+}
+
 func closeBody(body io.ReadCloser) {
 	err := body.Close()
 	if err != nil {